@@ -0,0 +1,109 @@
+// Command apikeys manages the API keys of the services calling the /admin API.
+// It talks to MongoDB directly, which is how the first key gets created.
+//
+//	apikeys create -name <name> -scopes rooms:admin,players:read
+//	apikeys list
+//	apikeys revoke <id>
+//	apikeys audit [-limit n] <id>
+package main
+
+import (
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	// The environment wins over the .env file, as inside the container.
+	godotenv.Load("./internal/env/.env")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(os.Getenv("MONGODB_URL")))
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer mongoClient.Disconnect(ctx)
+	apiKeyStorage := storage.NewAPIKeyStorage(
+		mongoClient.Database("DeathfireArsenal").Collection("apikeys"),
+		mongoClient.Database("DeathfireArsenal").Collection("audit"),
+	)
+	if err := apiKeyStorage.EnsureIndexes(ctx); err != nil {
+		log.Fatal("Failed to create MongoDB indexes:", err)
+	}
+	apiKeys := auth.NewAPIKeys(apiKeyStorage)
+
+	switch os.Args[1] {
+	case "create":
+		flags := flag.NewFlagSet("create", flag.ExitOnError)
+		name := flags.String("name", "", "who the key is for")
+		scopes := flags.String("scopes", "", "comma separated scopes: "+strings.Join(auth.AllScopes, ", "))
+		flags.Parse(os.Args[2:])
+
+		fullKey, key, err := apiKeys.Create(ctx, *name, strings.Split(*scopes, ","))
+		if err != nil {
+			log.Fatal("Failed to create the key: ", err)
+		}
+		fmt.Println("Created key", key.ID, "with scopes", strings.Join(key.Scopes, ","))
+		fmt.Println("Store it now, it is not shown again:")
+		fmt.Println(fullKey)
+	case "list":
+		keys, err := apiKeys.List(ctx)
+		if err != nil {
+			log.Fatal("Failed to list the keys: ", err)
+		}
+		for _, key := range keys {
+			state := "active"
+			if !key.RevokedAt.IsZero() {
+				state = "revoked " + key.RevokedAt.Format(time.RFC3339)
+			}
+			lastUsed := "never"
+			if !key.LastUsedAt.IsZero() {
+				lastUsed = key.LastUsedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%s\t%s\t%s\tlast used %s\t%s\n", key.ID, key.Name, strings.Join(key.Scopes, ","), lastUsed, state)
+		}
+	case "revoke":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		if err := apiKeys.Revoke(ctx, os.Args[2]); err != nil {
+			log.Fatal("Failed to revoke the key: ", err)
+		}
+		fmt.Println("Revoked key", os.Args[2])
+	case "audit":
+		flags := flag.NewFlagSet("audit", flag.ExitOnError)
+		limit := flags.Int("limit", 100, "number of entries to show")
+		flags.Parse(os.Args[2:])
+		if flags.NArg() != 1 {
+			usage()
+		}
+		entries, err := apiKeys.AuditTrail(ctx, flags.Arg(0), *limit)
+		if err != nil {
+			log.Fatal("Failed to read the audit trail: ", err)
+		}
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s %s\t%d\t%s\n", entry.At.Format(time.RFC3339), entry.Method, entry.Path, entry.Status, entry.RemoteAddr)
+		}
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: apikeys create -name <name> -scopes <scope,...> | list | revoke <id> | audit [-limit n] <id>")
+	os.Exit(2)
+}
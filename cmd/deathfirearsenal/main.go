@@ -2,9 +2,18 @@ package main
 
 import (
 	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/internal/regions"
 	api_handlers "DeathfireArsenal/pkg/api"
+	"DeathfireArsenal/pkg/auth"
 	"DeathfireArsenal/pkg/cache"
+	"DeathfireArsenal/pkg/chat"
+	"DeathfireArsenal/pkg/events"
+	grpc_handlers "DeathfireArsenal/pkg/grpc"
+	"DeathfireArsenal/pkg/idempotency"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/ratelimit"
 	"DeathfireArsenal/pkg/storage"
+	"DeathfireArsenal/pkg/webhooks"
 	"context"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -12,10 +21,13 @@ import (
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"time"
 )
 
@@ -37,9 +49,27 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to connect to MongoDB:", err)
 	}
-	defer mongoClient.Disconnect(ctx)
+	// The connect context has long expired by the time the server stops.
+	defer func() {
+		disconnectCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		mongoClient.Disconnect(disconnectCtx)
+	}()
 	roomCollection := mongoClient.Database("DeathfireArsenal").Collection("rooms")
 	playerCollection := mongoClient.Database("DeathfireArsenal").Collection("players")
+	trendCollection := mongoClient.Database("DeathfireArsenal").Collection("trends")
+	retiredIDCollection := mongoClient.Database("DeathfireArsenal").Collection("retiredplayerids")
+	friendshipCollection := mongoClient.Database("DeathfireArsenal").Collection("friendships")
+	blockCollection := mongoClient.Database("DeathfireArsenal").Collection("blocks")
+	apiKeyCollection := mongoClient.Database("DeathfireArsenal").Collection("apikeys")
+	auditCollection := mongoClient.Database("DeathfireArsenal").Collection("audit")
+	matchCollection := mongoClient.Database("DeathfireArsenal").Collection("matches")
+	reportCollection := mongoClient.Database("DeathfireArsenal").Collection("reports")
+	banCollection := mongoClient.Database("DeathfireArsenal").Collection("bans")
+	webhookCollection := mongoClient.Database("DeathfireArsenal").Collection("webhooks")
+	outboxCollection := mongoClient.Database("DeathfireArsenal").Collection("webhookoutbox")
+	deliveryCollection := mongoClient.Database("DeathfireArsenal").Collection("webhookdeliveries")
+	domainEventCollection := mongoClient.Database("DeathfireArsenal").Collection("domainevents")
 
 	// Redis Setup
 	redisClient := redis.NewClient(&redis.Options{
@@ -47,24 +77,181 @@ func main() {
 		Password: "",
 		DB:       0,
 	})
-	redisClient.FlushAll(ctx)
 
-	mongoDBStorage := storage.NewMongoDBStorage(roomCollection, playerCollection)
-	redisCache := cache.NewRedisCache(redisClient)
-	businessLogic := logic.NewBusinessLogic(mongoDBStorage, redisCache)
+	mongoDBStorage := storage.NewMongoDBStorage(roomCollection, playerCollection, trendCollection, retiredIDCollection, friendshipCollection, blockCollection)
+	cacheNamespace := os.Getenv("CACHE_NAMESPACE")
+	if cacheNamespace == "" {
+		cacheNamespace = "deathfire"
+	}
+	redisCache := cache.NewRedisCache(redisClient, cacheNamespace)
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// With CACHE_L1_TTL set, every replica keeps an in-process copy in front of Redis and
+	// receives the invalidations of the other replicas over pub/sub.
+	var appCache cache.Cache = redisCache
+	if localTTL := durationFromEnv("CACHE_L1_TTL", 0); localTTL > 0 {
+		tieredCache := cache.NewTieredCache(redisCache, localTTL)
+		go tieredCache.Run(backgroundCtx)
+		appCache = tieredCache
+	} else {
+		// Only our own namespace is cleared on start; anything else stored in Redis survives.
+		purgeCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		redisCache.Purge(purgeCtx)
+		cancel()
+	}
+
+	cachePolicy := cache.Policy{
+		SoftTTL: durationFromEnv("CACHE_SOFT_TTL", 5*time.Minute),
+		HardTTL: durationFromEnv("CACHE_HARD_TTL", 10*time.Minute),
+		LockTTL: durationFromEnv("CACHE_LOAD_LOCK_TTL", 0),
+	}
+	eventLogSize, err := strconv.ParseInt(os.Getenv("EVENT_LOG_SIZE"), 10, 64)
+	if err != nil || eventLogSize <= 0 {
+		eventLogSize = events.DefaultLogSize
+	}
+	// Kept outside the cache namespace so that purging the cache leaves the event log alone.
+	eventBus := events.NewRedisBus(redisClient, "events:"+cacheNamespace, eventLogSize)
+	go eventBus.Run(backgroundCtx)
+	// PLAYER_ID_REUSE says when the ID of a deleted player can be registered again:
+	// "immediate", "never" or after a duration such as "720h".
+	idReuse := logic.ReuseIDsImmediately
+	if value := os.Getenv("PLAYER_ID_REUSE"); value != "" {
+		idReuse, err = logic.ParsePlayerIDReuse(value)
+		if err != nil {
+			log.Fatal("Invalid PLAYER_ID_REUSE: ", err)
+		}
+	}
+	// The regions players can belong to are listed in REGION_CATALOG, a JSON file.
+	regionCatalogPath := os.Getenv("REGION_CATALOG")
+	if regionCatalogPath == "" {
+		regionCatalogPath = "./internal/env/regions.json"
+	}
+	regionCatalog, err := regions.Load(regionCatalogPath)
+	if err != nil {
+		log.Fatal("Failed to load the region catalog:", err)
+	}
+	moderationStorage := storage.NewModerationStorage(matchCollection, reportCollection, banCollection)
+	// Room chat keeps the last CHAT_HISTORY_SIZE messages of each room and masks the words listed in CHAT_WORDLIST.
+	chatHistorySize, err := strconv.ParseInt(os.Getenv("CHAT_HISTORY_SIZE"), 10, 64)
+	if err != nil || chatHistorySize <= 0 {
+		chatHistorySize = chat.DefaultHistorySize
+	}
+	chatHistory := chat.NewHistory(redisClient, "chat:"+cacheNamespace, chatHistorySize)
+	chatWordListPath := os.Getenv("CHAT_WORDLIST")
+	if chatWordListPath == "" {
+		chatWordListPath = "./internal/env/chat_wordlist.txt"
+	}
+	chatFilter, err := chat.LoadFilter(chatWordListPath)
+	if err != nil {
+		log.Fatal("Failed to load the chat word list:", err)
+	}
+	webhookStorage := storage.NewWebhookStorage(webhookCollection, outboxCollection, deliveryCollection)
+	webhookRegistry := webhooks.NewRegistry(webhookStorage)
+	domainEventStorage := storage.NewDomainEventStorage(domainEventCollection)
+	businessLogic := logic.NewBusinessLogic(mongoDBStorage, appCache, cachePolicy, eventBus, idReuse, regionCatalog, moderationStorage, chatHistory, chatFilter, webhookRegistry, domainEventStorage)
+
+	apiKeyStorage := storage.NewAPIKeyStorage(apiKeyCollection, auditCollection)
+	// Each startup step below gets MIGRATION_TIMEOUT of its own: the ones scanning whole
+	// collections take a while on a real dataset.
+	migrationTimeout := durationFromEnv("MIGRATION_TIMEOUT", 10*time.Minute)
+	// Changes are stored in transactions along with their outbox events, which takes a replica set.
+	runMigration(migrationTimeout, "MongoDB can't run transactions:", mongoDBStorage.CheckTransactions)
+	runMigration(migrationTimeout, "Failed to create MongoDB indexes:", mongoDBStorage.EnsureIndexes)
+	runMigration(migrationTimeout, "Failed to create MongoDB indexes:", apiKeyStorage.EnsureIndexes)
+	runMigration(migrationTimeout, "Failed to create MongoDB indexes:", moderationStorage.EnsureIndexes)
+	runMigration(migrationTimeout, "Failed to create MongoDB indexes:", webhookStorage.EnsureIndexes)
+	runMigration(migrationTimeout, "Failed to create MongoDB indexes:", domainEventStorage.EnsureIndexes)
+	// Deleting a player takes them out of the webhook events naming them; find those stored before that.
+	runMigration(migrationTimeout, "Failed to index the players of webhook events:", webhookRegistry.IndexPlayers)
+	// Region codes used to be stored as sent; the counters rebuilt below pick up the normalized codes.
+	runMigration(migrationTimeout, "Failed to normalize player regions:", mongoDBStorage.NormalizePlayerRegions)
+	// Room listings filter on counters kept beside the members; fill them in for rooms that predate them.
+	runMigration(migrationTimeout, "Failed to rebuild room counters:", mongoDBStorage.RebuildRoomCounters)
+
+	// Trend counters are maintained on every mutation; rebuild them on start and then periodically to correct drift.
+	runMigration(migrationTimeout, "Failed to rebuild trend counters:", mongoDBStorage.RebuildTrendCounters)
+	trendRebuildInterval := durationFromEnv("TREND_REBUILD_INTERVAL", 10*time.Minute)
+	go businessLogic.RunTrendReconciler(backgroundCtx, trendRebuildInterval)
+
+	// Webhook events wait in the MongoDB outbox until a dispatcher delivers them. Failed deliveries
+	// are retried with exponential backoff and become dead letters after WEBHOOK_MAX_ATTEMPTS attempts.
+	retryPolicy := webhooks.DefaultRetryPolicy
+	if maxAttempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && maxAttempts > 0 {
+		retryPolicy.MaxAttempts = maxAttempts
+	}
+	retryPolicy.BaseDelay = durationFromEnv("WEBHOOK_RETRY_BASE_DELAY", retryPolicy.BaseDelay)
+	retryPolicy.MaxDelay = durationFromEnv("WEBHOOK_RETRY_MAX_DELAY", retryPolicy.MaxDelay)
+	webhookWorkers, err := strconv.Atoi(os.Getenv("WEBHOOK_WORKERS"))
+	if err != nil || webhookWorkers <= 0 {
+		webhookWorkers = 4
+	}
+	webhookDispatcher := webhooks.NewDispatcher(webhookStorage, retryPolicy, webhookWorkers,
+		durationFromEnv("WEBHOOK_POLL_INTERVAL", time.Second), durationFromEnv("WEBHOOK_RETENTION", 72*time.Hour))
+	go webhookDispatcher.Run(backgroundCtx)
+
+	// Session tokens are signed with AUTH_ACTIVE_KEY out of AUTH_KEYS ("id:secret,..."); tokens signed
+	// with any of the listed keys are accepted, so a new key can be rolled out before the old one is dropped.
+	var keyring *auth.Keyring
+	if keys := os.Getenv("AUTH_KEYS"); keys != "" {
+		keyring, err = auth.ParseKeyring(keys, os.Getenv("AUTH_ACTIVE_KEY"))
+	} else {
+		log.Println("AUTH_KEYS is not set; session tokens will not survive a restart")
+		keyring, err = auth.NewRandomKeyring()
+	}
+	if err != nil {
+		log.Fatal("Failed to load the session keys:", err)
+	}
+	sessions := auth.NewSessions(keyring, durationFromEnv("AUTH_TOKEN_TTL", 24*time.Hour), redisClient, "auth:"+cacheNamespace)
+	// The /v2 routes always need a session token to act for a player. The /api routes and gRPC
+	// only need one with AUTH_REQUIRED=true, so old clients keep working while their players are
+	// issued secrets.
+	requireAuth := false
+	if value := os.Getenv("AUTH_REQUIRED"); value != "" {
+		if requireAuth, err = strconv.ParseBool(value); err != nil {
+			log.Fatal("AUTH_REQUIRED must be true or false:", err)
+		}
+	}
+	countCtx, cancelCount := context.WithTimeout(context.Background(), migrationTimeout)
+	legacyPlayers, err := mongoDBStorage.CountPlayersWithoutSecret(countCtx)
+	cancelCount()
+	if err != nil {
+		log.Println("Failed to count the players without a secret:", err)
+	} else if legacyPlayers > 0 {
+		log.Printf("%d players have no secret and can't log in; issue them one with POST /admin/players/{id}/secret", legacyPlayers)
+	}
+
 	apiHandlers := api_handlers.APIHandlers{
-		Logic: businessLogic,
+		Logic:       businessLogic,
+		Events:      eventBus,
+		Sessions:    sessions,
+		APIKeys:     auth.NewAPIKeys(apiKeyStorage),
+		Webhooks:    webhookRegistry,
+		RequireAuth: requireAuth,
+
+		// Buckets live in Redis so that limits hold across replicas, and in memory while Redis is down.
+		Limiter: ratelimit.NewFallbackLimiter(
+			ratelimit.NewRedisLimiter(redisClient, "ratelimit:"+cacheNamespace),
+			ratelimit.NewMemoryLimiter(),
+		),
+		RateLimits: map[string]ratelimit.Limit{
+			api_handlers.RouteClassRead:    limitFromEnv("RATE_LIMIT_READ", ratelimit.Limit{Requests: 300, Period: time.Minute}),
+			api_handlers.RouteClassWrite:   limitFromEnv("RATE_LIMIT_WRITE", ratelimit.Limit{Requests: 60, Period: time.Minute}),
+			api_handlers.RouteClassSession: limitFromEnv("RATE_LIMIT_SESSION", ratelimit.Limit{Requests: 10, Period: time.Minute}),
+			api_handlers.RouteClassAdmin:   limitFromEnv("RATE_LIMIT_ADMIN", ratelimit.Limit{Requests: 1200, Period: time.Minute}),
+			api_handlers.RouteClassChat:    limitFromEnv("RATE_LIMIT_CHAT", ratelimit.Limit{Requests: 20, Period: 30 * time.Second}),
+		},
 	}
+	apiHandlers.TrustForwardedFor, _ = strconv.ParseBool(os.Getenv("TRUST_FORWARDED_FOR"))
+	// Replayed responses are kept for IDEMPOTENCY_TTL; a request still running after the server's
+	// write timeout has long been cut off, so its claim on a key only needs to outlive that.
+	apiHandlers.Idempotency = idempotency.NewStore(redisClient, "idempotency:"+cacheNamespace,
+		durationFromEnv("IDEMPOTENCY_TTL", 24*time.Hour), 30*time.Second)
 
 	router := mux.NewRouter()
 
-	router.HandleFunc("/api/createPlayer", apiHandlers.CreatePlayerHandler).Methods("POST")
-	router.HandleFunc("/api/createRoom", apiHandlers.CreateRoomHandler).Methods("POST")
-	router.HandleFunc("/api/getRooms", apiHandlers.GetRoomsHandler).Methods("GET")
-	router.HandleFunc("/api/joinRoom", apiHandlers.JoinRoomHandler).Methods("POST")
-	router.HandleFunc("/api/leaveRoom", apiHandlers.LeaveRoomHandler).Methods("POST")
-	router.HandleFunc("/api/getModeTrendsByRegion", apiHandlers.GetModeTrendsByRegion).Methods("GET")
-	router.HandleFunc("/api/getModeTrendsByRegionV2", apiHandlers.GetModeTrendsByRegionV2).Methods("GET")
+	apiHandlers.RegisterRoutes(router)
 
 	server := &http.Server{
 		Addr:         ":8080",
@@ -73,11 +260,47 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	// gRPC Setup - shares the business logic with the HTTP router on its own port
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatal("Failed to listen for gRPC:", err)
+	}
+	grpcServer := grpc.NewServer()
+	models.RegisterDeathfireArsenalServer(grpcServer, &grpc_handlers.Server{
+		Logic:       businessLogic,
+		Events:      eventBus,
+		Sessions:    sessions,
+		RequireAuth: requireAuth,
+		Idempotency: apiHandlers.Idempotency,
+	})
+	go func() {
+		fmt.Println("gRPC server is now running on :" + grpcPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal("gRPC server failed to start:", err)
+		}
+	}()
+
 	stopChan := make(chan os.Signal, 1)
 	signal.Notify(stopChan, os.Interrupt)
 	go func() {
 		<-stopChan
 		log.Println("Shutting down the server...")
+		stopBackground()
+		// Streaming RPCs never finish on their own, so don't wait for them forever.
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+		select {
+		case <-grpcStopped:
+		case <-time.After(5 * time.Second):
+			grpcServer.Stop()
+		}
 		server.Shutdown(context.Background())
 	}()
 
@@ -86,3 +309,35 @@ func main() {
 		log.Fatal("Server failed to start:", err)
 	}
 }
+
+// Helper function to run a startup step with a timeout of its own, and stop the
+// server when it fails.
+func runMigration(timeout time.Duration, failure string, migration func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := migration(ctx); err != nil {
+		log.Fatal(failure, err)
+	}
+}
+
+// Helper function to read a duration such as "30s" from the environment.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// Helper function to read a rate limit such as "30/1m" from the environment.
+func limitFromEnv(name string, fallback ratelimit.Limit) ratelimit.Limit {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		log.Fatal("Invalid "+name+": ", err)
+	}
+	return limit
+}
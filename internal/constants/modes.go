@@ -43,3 +43,16 @@ var roomLimitMap = map[Mode]int{
 func RoomLimit(mode Mode) int {
 	return roomLimitMap[mode]
 }
+
+const (
+	RoomOpen = "open"
+	RoomFull = "full"
+)
+
+// Function to get the state of a room of the given mode with playerCount players
+func RoomState(mode Mode, playerCount int) string {
+	if playerCount >= RoomLimit(mode) {
+		return RoomFull
+	}
+	return RoomOpen
+}
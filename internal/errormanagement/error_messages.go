@@ -1,13 +1,72 @@
 package errormanagement
 
-import "errors"
+// Error is a business error with a stable, machine-readable code that clients
+// can rely on instead of the message.
+type Error struct {
+	Code    string
+	Message string
+}
 
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// declared holds every error made with New, so that none of them can be left
+// without a status.
+var declared []*Error
+
+func New(code string, message string) *Error {
+	err := &Error{Code: code, Message: message}
+	declared = append(declared, err)
+	return err
+}
+
+var (
+	PlayerIdAlreadyExists = New("player_id_taken", "Player ID is already taken")
+	PlayerNotFound        = New("player_not_found", "Player does not exist")
+	RoomNotFound          = New("room_not_found", "Room not found")
+	RoomIsFull            = New("room_full", "Current Room is full")
+	PlayerOccupied        = New("player_in_room", "Player is already in a combat for his virtual life. Can't afford to join another.")
+	PlayerIdle            = New("player_not_in_room", "Player not playing any game rn...")
+	InvalidMode           = New("invalid_mode", "This Mode of Game does not exist.")
+	UnknownRegion         = New("unknown_region", "No such region on the map")
+	NotInRoom             = New("not_room_member", "Player is not part of this room")
+	FriendNotFound        = New("friend_not_found", "Not on your friends list")
+	FriendRequestNotFound = New("friend_request_not_found", "No such friend request")
+	AlreadyFriends        = New("already_friends", "You are friends already")
+	BlockNotFound         = New("block_not_found", "You have not blocked this player")
+	RoomUnavailable       = New("room_unavailable", "You can't join this room")
+	NoSharedMatch         = New("no_shared_match", "You haven't played with this player lately")
+	ReportNotFound        = New("report_not_found", "Report does not exist")
+	BanNotFound           = New("ban_not_found", "Ban does not exist")
+	PlayerBanned          = New("player_banned", "You are banned from the battlefield")
+)
+
+// Errors about the shape of a request rather than the state of the game.
+var (
+	MalformedRequest       = New("malformed_request", "Fix the request bruh...")
+	MissingParameter       = New("missing_parameter", "At least type something...")
+	InvalidParameter       = New("invalid_parameter", "A parameter has an invalid value")
+	ValidationFailed       = New("validation_failed", "Some fields of the request are invalid")
+	UnsupportedContentType = New("unsupported_content_type", "Content-Type must be application/json or application/x-protobuf")
+	RateLimited            = New("rate_limited", "Easy on the trigger. Try again in a bit")
+	IdempotencyInProgress  = New("idempotency_in_progress", "A request with this Idempotency-Key is still being served")
+	IdempotencyKeyReused   = New("idempotency_key_reused", "This Idempotency-Key was used for a different request")
+	// The first request went through, but its response held a secret that isn't kept for replays.
+	IdempotencyResponseWithheld = New("idempotency_response_withheld", "That request went through already, and its response can't be replayed")
+)
+
+// Errors about who is making a request.
+var (
+	Unauthorized       = New("unauthorized", "Log in first, soldier")
+	InvalidCredentials = New("invalid_credentials", "Player ID or secret is wrong")
+	Forbidden          = New("forbidden", "You can only act as yourself")
+	MissingScope       = New("missing_scope", "This API key is not allowed to do that")
+	APIKeyNotFound     = New("api_key_not_found", "API key does not exist")
+)
+
+// Errors about the webhooks services subscribe.
 var (
-	PlayerIdAlreadyExists = errors.New("Player ID is already taken")
-	PlayerNotFound        = errors.New("Player does not exist")
-	RoomNotFound          = errors.New("Room not found")
-	RoomIsFull            = errors.New("Current Room is full")
-	PlayerOccupied        = errors.New("Player is already in a combat for his virtual life. Can't afford to join another.")
-	PlayerIdle            = errors.New("Player not playing any game rn...")
-	InvalidMode           = errors.New("This Mode of Game does not exist.")
+	WebhookNotFound         = New("webhook_not_found", "Webhook does not exist")
+	WebhookDeliveryNotFound = New("webhook_delivery_not_found", "Webhook delivery does not exist")
 )
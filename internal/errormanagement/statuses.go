@@ -0,0 +1,72 @@
+package errormanagement
+
+import (
+	"google.golang.org/grpc/codes"
+	"net/http"
+)
+
+// Status is how a business error is answered over HTTP and over gRPC.
+type Status struct {
+	HTTP int
+	GRPC codes.Code
+}
+
+// InternalStatus answers errors that are not business errors.
+var InternalStatus = Status{http.StatusInternalServerError, codes.Internal}
+
+var (
+	badRequest   = Status{http.StatusBadRequest, codes.InvalidArgument}
+	unauthorized = Status{http.StatusUnauthorized, codes.Unauthenticated}
+	forbidden    = Status{http.StatusForbidden, codes.PermissionDenied}
+	notFound     = Status{http.StatusNotFound, codes.NotFound}
+	alreadyTaken = Status{http.StatusConflict, codes.AlreadyExists}
+	conflict     = Status{http.StatusConflict, codes.FailedPrecondition}
+)
+
+// statuses is the single place where business errors get their HTTP status
+// and gRPC code, so that both APIs answer an error the same way.
+var statuses = map[*Error]Status{
+	MalformedRequest:            badRequest,
+	MissingParameter:            badRequest,
+	InvalidParameter:            badRequest,
+	UnknownRegion:               badRequest,
+	ValidationFailed:            badRequest,
+	InvalidMode:                 badRequest,
+	FriendNotFound:              notFound,
+	FriendRequestNotFound:       notFound,
+	AlreadyFriends:              alreadyTaken,
+	BlockNotFound:               notFound,
+	RoomUnavailable:             conflict,
+	NoSharedMatch:               forbidden,
+	ReportNotFound:              notFound,
+	BanNotFound:                 notFound,
+	PlayerBanned:                forbidden,
+	UnsupportedContentType:      {http.StatusUnsupportedMediaType, codes.InvalidArgument},
+	RateLimited:                 {http.StatusTooManyRequests, codes.ResourceExhausted},
+	IdempotencyInProgress:       {http.StatusConflict, codes.Aborted},
+	IdempotencyKeyReused:        {http.StatusUnprocessableEntity, codes.InvalidArgument},
+	IdempotencyResponseWithheld: alreadyTaken,
+	Unauthorized:                unauthorized,
+	InvalidCredentials:          unauthorized,
+	Forbidden:                   forbidden,
+	MissingScope:                forbidden,
+	APIKeyNotFound:              notFound,
+	WebhookNotFound:             notFound,
+	WebhookDeliveryNotFound:     notFound,
+	PlayerNotFound:              notFound,
+	RoomNotFound:                notFound,
+	NotInRoom:                   forbidden,
+	PlayerIdAlreadyExists:       alreadyTaken,
+	RoomIsFull:                  conflict,
+	PlayerOccupied:              conflict,
+	PlayerIdle:                  conflict,
+}
+
+// StatusOf returns how err is answered. An error missing from the table is
+// answered as an internal error.
+func StatusOf(err *Error) Status {
+	if status, ok := statuses[err]; ok {
+		return status
+	}
+	return InternalStatus
+}
@@ -0,0 +1,31 @@
+package errormanagement
+
+import (
+	"google.golang.org/grpc/codes"
+	"testing"
+)
+
+func TestEveryErrorHasAStatus(t *testing.T) {
+	if len(declared) == 0 {
+		t.Fatal("no error was declared")
+	}
+	for _, err := range declared {
+		status, ok := statuses[err]
+		if !ok {
+			t.Errorf("%s has no status", err.Code)
+			continue
+		}
+		if status.HTTP < 400 || status.HTTP >= 500 {
+			t.Errorf("%s is answered with HTTP %d, want a client error", err.Code, status.HTTP)
+		}
+		if status.GRPC == codes.OK || status.GRPC == codes.Internal || status.GRPC == codes.Unknown {
+			t.Errorf("%s is answered with gRPC %s, want a client error", err.Code, status.GRPC)
+		}
+	}
+}
+
+func TestStatusOfAnUndeclaredError(t *testing.T) {
+	if got := StatusOf(&Error{Code: "mystery"}); got != InternalStatus {
+		t.Fatalf("StatusOf = %+v, want %+v", got, InternalStatus)
+	}
+}
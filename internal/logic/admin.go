@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+)
+
+// The operations below are reserved to services holding an API key with the
+// matching scope; players cannot reach them.
+
+// CloseRoom deletes a room and releases everyone still inside.
+func (b *BusinessLogic) CloseRoom(ctx context.Context, roomID string) error {
+	//	Check if room exists
+	room, err := b.storage.GetRoomByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	domainEvents := make([]storage.DomainEvent, 0, len(room.PlayerIds)+1)
+	for _, member := range room.PlayerIds {
+		domainEvents = append(domainEvents, storage.DomainEvent{Type: PlayerLeft, PlayerID: member, RoomID: room.Id, Mode: room.Mode, Reason: PlayerRoomClosed})
+	}
+	domainEvents = append(domainEvents, storage.DomainEvent{Type: RoomDeleted, RoomID: room.Id, Mode: room.Mode, Reason: RoomClosed})
+
+	// The members may come from any region, so every trend is dropped
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		if err := b.storage.DeleteRoom(ctx, roomID); err != nil {
+			return change{}, err
+		}
+		return change{
+			actor:        actorOf(ctx, ""),
+			domainEvents: domainEvents,
+			roomEvents:   []events.Event{newRoomEvent(events.RoomDeleted, room.Id, room.Mode, "", nil)},
+			cacheTags:    []string{roomListTag(room.Mode), allTrendsTag},
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	b.recordMatches(ctx, room, room.PlayerIds...)
+	b.deleteChat(ctx, room.Id)
+	return nil
+}
+
+// KickPlayer removes a player from a room on behalf of a game server.
+func (b *BusinessLogic) KickPlayer(ctx context.Context, roomID string, playerID string) error {
+	return b.LeaveRoomByID(ctx, playerID, roomID)
+}
+
+// IssuePlayerSecret replaces the secret of a player and returns the new one.
+// It lets players created before secrets existed log in, and locks out anyone
+// holding the old secret.
+func (b *BusinessLogic) IssuePlayerSecret(ctx context.Context, playerID string) (string, error) {
+	secret, secretHash, err := auth.NewSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := b.storage.SetPlayerSecretHash(ctx, playerID, secretHash); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// UpdatePlayerRegion moves a player to another region. A player sitting in a
+// room counts towards the trends of its new region from now on.
+func (b *BusinessLogic) UpdatePlayerRegion(ctx context.Context, playerID string, region string) (*models.Player, error) {
+	region, err := b.checkRegion(region)
+	if err != nil {
+		return nil, err
+	}
+	//	Check if player exists
+	player, err := b.storage.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cacheTags []string
+	if player.Room != "" {
+		room, err := b.storage.GetRoomByID(ctx, player.Room)
+		if err == nil {
+			cacheTags = append(roomMutationTags(room.Mode, player.Region), trendTag(region))
+			// The player now raises the counter of its new region
+			if err := b.storage.EnsureTrendCounter(ctx, region, room.Mode); err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		if err := b.storage.UpdatePlayerRegion(ctx, playerID, region); err != nil {
+			return change{}, err
+		}
+		return change{
+			actor:        actorOf(ctx, ""),
+			domainEvents: []storage.DomainEvent{{Type: RegionChanged, PlayerID: playerID, Region: region}},
+			cacheTags:    cacheTags,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.storage.GetPlayerByID(ctx, playerID)
+}
@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"fmt"
+	"time"
+)
+
+// BlockedPlayer is an entry of the block list of a player. Block lists are
+// private: players never learn who blocked them.
+type BlockedPlayer struct {
+	PlayerID  string    `json:"player_id"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// BlockPlayer adds otherID to the block list of the player. The two players
+// stop being friends, any pending request between them is dropped, and they
+// can't end up in the same room through a join anymore.
+func (b *BusinessLogic) BlockPlayer(ctx context.Context, playerID string, otherID string) error {
+	if playerID == otherID {
+		return fmt.Errorf("%w: players can't block themselves", errormanagement.InvalidParameter)
+	}
+	//	Check if both players exist
+	if _, err := b.storage.GetPlayerByID(ctx, playerID); err != nil {
+		return err
+	}
+	if _, err := b.storage.GetPlayerByID(ctx, otherID); err != nil {
+		return err
+	}
+
+	if err := b.storage.BlockPlayer(ctx, playerID, otherID); err != nil {
+		return err
+	}
+	if _, err := b.storage.DeleteFriendship(ctx, playerID, otherID, true); err != nil {
+		return err
+	}
+	_, err := b.storage.DeleteFriendship(ctx, playerID, otherID, false)
+	return err
+}
+
+// UnblockPlayer removes otherID from the block list of the player.
+func (b *BusinessLogic) UnblockPlayer(ctx context.Context, playerID string, otherID string) error {
+	deleted, err := b.storage.UnblockPlayer(ctx, playerID, otherID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errormanagement.BlockNotFound
+	}
+	return nil
+}
+
+// ListBlockedPlayers returns the block list of a player, oldest first.
+func (b *BusinessLogic) ListBlockedPlayers(ctx context.Context, playerID string) ([]BlockedPlayer, error) {
+	//	Check if player exists
+	if _, err := b.storage.GetPlayerByID(ctx, playerID); err != nil {
+		return nil, err
+	}
+	blocks, err := b.storage.ListBlocks(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make([]BlockedPlayer, 0, len(blocks))
+	for _, block := range blocks {
+		blocked = append(blocked, BlockedPlayer{PlayerID: block.Blocked, BlockedAt: block.CreatedAt})
+	}
+	return blocked, nil
+}
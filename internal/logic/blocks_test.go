@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBlockedPlayersCantJoinEachOther(t *testing.T) {
+	b, _ := newTestLogic(t)
+	ctx := context.Background()
+	createTestPlayers(t, b, "Furious", "Valiant", "Brave", "Steady")
+	furiousRoom := createTestRoom(t, b, "Furious")
+	braveRoom := createTestRoom(t, b, "Brave")
+
+	if err := b.BlockPlayer(ctx, "Furious", "Valiant"); err != nil {
+		t.Fatalf("BlockPlayer: %v", err)
+	}
+	// Neither the blocked player nor the one who blocked can join the other.
+	if err := b.JoinRoom(ctx, "Valiant", furiousRoom); !errors.Is(err, errormanagement.RoomUnavailable) {
+		t.Fatalf("the blocked player joining = %v, want %v", err, errormanagement.RoomUnavailable)
+	}
+	if err := b.JoinRoom(ctx, "Valiant", braveRoom); err != nil {
+		t.Fatalf("the blocked player joining someone else: %v", err)
+	}
+	if err := b.LeaveRoom(ctx, "Furious"); err != nil {
+		t.Fatalf("LeaveRoom: %v", err)
+	}
+	if err := b.JoinRoom(ctx, "Furious", braveRoom); !errors.Is(err, errormanagement.RoomUnavailable) {
+		t.Fatalf("the blocking player joining = %v, want %v", err, errormanagement.RoomUnavailable)
+	}
+	if err := b.JoinRoom(ctx, "Steady", braveRoom); err != nil {
+		t.Fatalf("another player joining: %v", err)
+	}
+
+	if err := b.UnblockPlayer(ctx, "Furious", "Valiant"); err != nil {
+		t.Fatalf("UnblockPlayer: %v", err)
+	}
+	if err := b.JoinRoom(ctx, "Furious", braveRoom); err != nil {
+		t.Fatalf("joining once unblocked: %v", err)
+	}
+	if err := b.UnblockPlayer(ctx, "Furious", "Valiant"); !errors.Is(err, errormanagement.BlockNotFound) {
+		t.Fatalf("unblocking twice = %v, want %v", err, errormanagement.BlockNotFound)
+	}
+}
+
+func TestBlockingEndsTheFriendship(t *testing.T) {
+	b, _ := newTestLogic(t)
+	ctx := context.Background()
+	createTestPlayers(t, b, "Furious", "Valiant")
+	if _, err := b.SendFriendRequest(ctx, "Furious", "Valiant"); err != nil {
+		t.Fatalf("SendFriendRequest: %v", err)
+	}
+	if err := b.AcceptFriendRequest(ctx, "Valiant", "Furious"); err != nil {
+		t.Fatalf("AcceptFriendRequest: %v", err)
+	}
+	createTestRoom(t, b, "Valiant")
+
+	if err := b.BlockPlayer(ctx, "Valiant", "Furious"); err != nil {
+		t.Fatalf("BlockPlayer: %v", err)
+	}
+	if friends, err := b.ListFriends(ctx, "Furious"); err != nil || len(friends) != 0 {
+		t.Fatalf("friends of Furious = %+v, %v, want none", friends, err)
+	}
+	if _, err := b.JoinFriend(ctx, "Furious", "Valiant"); !errors.Is(err, errormanagement.FriendNotFound) {
+		t.Fatalf("joining the friend who blocked = %v, want %v", err, errormanagement.FriendNotFound)
+	}
+
+	// A request from the blocked player is dropped without telling them.
+	if accepted, err := b.SendFriendRequest(ctx, "Furious", "Valiant"); err != nil || accepted {
+		t.Fatalf("SendFriendRequest from the blocked player = %t, %v, want it silently dropped", accepted, err)
+	}
+	if requests, err := b.ListFriendRequests(ctx, "Valiant"); err != nil || len(requests) != 0 {
+		t.Fatalf("requests of Valiant = %+v, %v, want none", requests, err)
+	}
+}
@@ -3,41 +3,106 @@ package logic
 import (
 	"DeathfireArsenal/internal/constants"
 	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/auth"
 	"DeathfireArsenal/pkg/cache"
+	"DeathfireArsenal/pkg/chat"
+	"DeathfireArsenal/pkg/events"
 	"DeathfireArsenal/pkg/storage"
+	"DeathfireArsenal/pkg/webhooks"
 	"context"
 	"errors"
-	"fmt"
 	"strings"
-	"time"
 )
 
 type BusinessLogic struct {
-	storage *storage.MongoDBStorage
-	cache   *cache.RedisCache
+	storage     *storage.MongoDBStorage
+	cache       cache.Cache
+	cachePolicy cache.Policy
+	events      events.Publisher
+	idReuse     PlayerIDReuse
+	regions     *regions.Catalog
+	moderation  *storage.ModerationStorage
+	chat        *chat.History
+	chatFilter  *chat.Filter
+	webhooks    *webhooks.Registry
+	// Every change to the players and rooms is appended here.
+	domainEvents *storage.DomainEventStorage
 }
 
-func NewBusinessLogic(storage *storage.MongoDBStorage, cache *cache.RedisCache) *BusinessLogic {
+func NewBusinessLogic(storage *storage.MongoDBStorage, cache cache.Cache, cachePolicy cache.Policy, publisher events.Publisher, idReuse PlayerIDReuse, catalog *regions.Catalog, moderation *storage.ModerationStorage, chatHistory *chat.History, chatFilter *chat.Filter, webhookRegistry *webhooks.Registry, domainEvents *storage.DomainEventStorage) *BusinessLogic {
 	return &BusinessLogic{
-		storage: storage,
-		cache:   cache,
+		storage:      storage,
+		cache:        cache,
+		cachePolicy:  cachePolicy,
+		events:       publisher,
+		idReuse:      idReuse,
+		regions:      catalog,
+		moderation:   moderation,
+		chat:         chatHistory,
+		chatFilter:   chatFilter,
+		webhooks:     webhookRegistry,
+		domainEvents: domainEvents,
 	}
 }
 
-func (b *BusinessLogic) CreatePlayer(playerID string, regionCode string) error {
+// CreatePlayer registers the player and returns the secret it logs in with.
+// Only a hash of the secret is stored.
+func (b *BusinessLogic) CreatePlayer(ctx context.Context, playerID string, regionCode string) (string, error) {
+	//	Check if region is open to players
+	region, err := b.checkRegion(regionCode)
+	if err != nil {
+		return "", err
+	}
 	// Check if Player already exists
 	if b.storage.PlayerIsAlreadyRegistered(playerID) {
-		return errormanagement.PlayerIdAlreadyExists
+		return "", errormanagement.PlayerIdAlreadyExists
+	}
+	secret, secretHash, err := auth.NewSecret()
+	if err != nil {
+		return "", err
+	}
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		if err := b.storage.CreatePlayer(ctx, playerID, region, secretHash); err != nil {
+			return change{}, err
+		}
+		return change{
+			actor:        actorOf(ctx, playerID),
+			domainEvents: []storage.DomainEvent{{Type: PlayerCreated, PlayerID: playerID, Region: region}},
+		}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// CheckPlayerSecret verifies the secret handed out when the player was created.
+// Unknown players and wrong secrets are reported alike.
+func (b *BusinessLogic) CheckPlayerSecret(playerID string, secret string) error {
+	secretHash, err := b.storage.GetPlayerSecretHash(playerID)
+	if errors.Is(err, errormanagement.PlayerNotFound) {
+		return errormanagement.InvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+	if !auth.CheckSecret(secret, secretHash) {
+		return errormanagement.InvalidCredentials
 	}
-	return b.storage.CreatePlayer(playerID, regionCode)
+	return nil
 }
 
-func (b *BusinessLogic) CreateRoom(playerID string, mode string) (string, error) {
+func (b *BusinessLogic) CreateRoom(ctx context.Context, playerID string, mode string, private bool) (string, error) {
 	//	Check if player exists
-	player, err := b.storage.GetPlayerByID(playerID)
+	player, err := b.storage.GetPlayerByID(ctx, playerID)
 	if err != nil {
 		return "", err
 	}
+	//	Check if player is banned
+	if err := b.checkNotBanned(ctx, playerID); err != nil {
+		return "", err
+	}
 	//	Check if mode is valid
 	if !isValidMode(mode) {
 		return "", errormanagement.InvalidMode
@@ -47,57 +112,44 @@ func (b *BusinessLogic) CreateRoom(playerID string, mode string) (string, error)
 		return "", errormanagement.PlayerOccupied
 	}
 
-	room, err := b.storage.CreateRoom(playerID, mode)
-	if err != nil {
+	// The counter the room raises is created ahead of the transaction
+	if err := b.storage.EnsureTrendCounter(ctx, player.Region, mode); err != nil {
 		return "", err
 	}
-
-	b.cache.Invalidate(context.Background())
-	return room, nil
-}
-
-func (b *BusinessLogic) GetRoomsByMode(mode string) ([]string, error) {
-	//	Check if mode is correct
-	if !isValidMode(mode) {
-		return nil, errormanagement.InvalidMode
-	}
-
-	cacheKey := fmt.Sprintf("GetRoomsByMode:%s", mode)
-
-	// Try to get the data from the cache
-	var roomIDs []string
-	err := b.cache.Get(context.Background(), cacheKey, &roomIDs)
-	if err == nil {
-		return roomIDs, nil
-	} else if !errors.Is(err, cache.ErrCacheMiss) {
-		return nil, err
-	}
-
-	rooms, err := b.storage.GetRoomsByMode(mode)
-
+	var room string
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		var err error
+		if room, err = b.storage.CreateRoom(ctx, playerID, mode, private); err != nil {
+			return change{}, err
+		}
+		return change{
+			actor: actorOf(ctx, playerID),
+			domainEvents: []storage.DomainEvent{
+				{Type: RoomCreated, PlayerID: playerID, RoomID: room, Mode: mode},
+				{Type: PlayerJoined, PlayerID: playerID, RoomID: room, Mode: mode},
+			},
+			roomEvents: []events.Event{newRoomEvent(events.RoomCreated, room, mode, playerID, []string{playerID})},
+			cacheTags:  roomMutationTags(mode, player.Region),
+		}, nil
+	})
 	if err != nil {
-		return nil, err
-	}
-
-	var room_ids []string
-	room_ids = make([]string, len(rooms))
-
-	for i := 0; i < len(rooms); i++ {
-		room_ids[i] = rooms[i].Id
+		return "", err
 	}
-	b.cache.Set(context.Background(), cacheKey, room_ids, 5*time.Minute)
-
-	return room_ids, nil
+	return room, nil
 }
 
-func (b *BusinessLogic) JoinRoom(playerID string, roomID string) error {
+func (b *BusinessLogic) JoinRoom(ctx context.Context, playerID string, roomID string) error {
 	//	Check if player exists
-	player, err := b.storage.GetPlayerByID(playerID)
+	player, err := b.storage.GetPlayerByID(ctx, playerID)
 	if err != nil {
 		return err
 	}
+	//	Check if player is banned
+	if err := b.checkNotBanned(ctx, playerID); err != nil {
+		return err
+	}
 	//	Check if room exists
-	room, err := b.storage.GetRoomByID(roomID)
+	room, err := b.storage.GetRoomByID(ctx, roomID)
 	if err != nil {
 		return err
 	}
@@ -112,13 +164,41 @@ func (b *BusinessLogic) JoinRoom(playerID string, roomID string) error {
 		return errormanagement.PlayerOccupied
 	}
 
-	b.cache.Invalidate(context.Background())
-	return b.storage.AddPlayerToRoom(playerID, roomID)
+	//	Check that nobody in the room blocked the player, or was blocked by it.
+	//	The error is the same either way so that it doesn't tell who blocked whom.
+	blocked, err := b.storage.IsBlockedAmong(ctx, playerID, room.PlayerIds)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return errormanagement.RoomUnavailable
+	}
+
+	// The counter the player raises is created ahead of the transaction
+	if err := b.storage.EnsureTrendCounter(ctx, player.Region, room.Mode); err != nil {
+		return err
+	}
+	members := append(room.PlayerIds, playerID)
+	roomEvents := []events.Event{newRoomEvent(events.PlayerJoined, roomID, room.Mode, playerID, members)}
+	if constants.RoomState(constants.ParseMode(room.Mode), len(members)) == constants.RoomFull {
+		roomEvents = append(roomEvents, newRoomEvent(events.StateChanged, roomID, room.Mode, playerID, members))
+	}
+	return b.commit(ctx, func(ctx context.Context) (change, error) {
+		if err := b.storage.AddPlayerToRoom(ctx, playerID, roomID); err != nil {
+			return change{}, err
+		}
+		return change{
+			actor:        actorOf(ctx, playerID),
+			domainEvents: []storage.DomainEvent{{Type: PlayerJoined, PlayerID: playerID, RoomID: roomID, Mode: room.Mode}},
+			roomEvents:   roomEvents,
+			cacheTags:    roomMutationTags(room.Mode, player.Region),
+		}, nil
+	})
 }
 
 func (b *BusinessLogic) LeaveRoom(ctx context.Context, playerID string) error {
 	//	Check if player exists
-	player, err := b.storage.GetPlayerByID(playerID)
+	player, err := b.storage.GetPlayerByID(ctx, playerID)
 	if err != nil {
 		return err
 	}
@@ -127,50 +207,66 @@ func (b *BusinessLogic) LeaveRoom(ctx context.Context, playerID string) error {
 		return errormanagement.PlayerIdle
 	}
 
-	b.cache.Invalidate(ctx)
-	return b.storage.RemovePlayerFromRoom(ctx, playerID)
-}
-
-func (b *BusinessLogic) GetModeTrendsByRegion(region string) (map[string]int, error) {
-	cacheKey := fmt.Sprintf("GetModesTrendByRegion:%s", region)
-
-	var modes map[string]int
-	err := b.cache.Get(context.Background(), cacheKey, &modes)
-	if err == nil {
-		return modes, nil
-	} else if !errors.Is(err, cache.ErrCacheMiss) {
-		return nil, err
+	room, err := b.storage.GetRoomByID(ctx, player.Room)
+	if err != nil {
+		return err
 	}
 
-	modes, err = b.storage.GetModesByRegionTrend(region)
-
-	if err == nil {
-		b.cache.Set(context.Background(), cacheKey, modes, 5*time.Minute)
+	members := withoutPlayer(room.PlayerIds, playerID)
+	domainEvents := []storage.DomainEvent{{Type: PlayerLeft, PlayerID: playerID, RoomID: room.Id, Mode: room.Mode}}
+	var roomEvents []events.Event
+	if len(members) == 0 {
+		// The storage deletes a room once its last player is out
+		domainEvents = append(domainEvents, storage.DomainEvent{Type: RoomDeleted, RoomID: room.Id, Mode: room.Mode, Reason: RoomEmptied})
+		roomEvents = append(roomEvents, newRoomEvent(events.RoomDeleted, room.Id, room.Mode, playerID, members))
+	} else {
+		roomEvents = append(roomEvents, newRoomEvent(events.PlayerLeft, room.Id, room.Mode, playerID, members))
+		if len(room.PlayerIds) > 0 && room.PlayerIds[0] == playerID {
+			roomEvents = append(roomEvents, newRoomEvent(events.HostChanged, room.Id, room.Mode, playerID, members))
+		}
+		mode := constants.ParseMode(room.Mode)
+		if constants.RoomState(mode, len(room.PlayerIds)) != constants.RoomState(mode, len(members)) {
+			roomEvents = append(roomEvents, newRoomEvent(events.StateChanged, room.Id, room.Mode, playerID, members))
+		}
+	}
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		if err := b.storage.RemovePlayerFromRoom(ctx, playerID); err != nil {
+			return change{}, err
+		}
+		return change{
+			actor:        actorOf(ctx, playerID),
+			domainEvents: domainEvents,
+			roomEvents:   roomEvents,
+			cacheTags:    roomMutationTags(room.Mode, player.Region),
+		}, nil
+	})
+	if err != nil {
+		return err
 	}
-	return modes, err
-}
-
-func (b *BusinessLogic) GetModeTrendsByPlayerRegion(playerId string) (map[string]int, error) {
-	cacheKey := fmt.Sprintf("GetModesTrendByPlayerRegion:%s", playerId)
 
-	var modes map[string]int
-	err := b.cache.Get(context.Background(), cacheKey, &modes)
-	if err == nil {
-		return modes, nil
-	} else if !errors.Is(err, cache.ErrCacheMiss) {
-		return nil, err
+	b.recordMatches(ctx, room, playerID)
+	if len(members) == 0 {
+		b.deleteChat(ctx, room.Id)
 	}
+	return nil
+}
 
+// LeaveRoomByID is LeaveRoom for callers that name the room being left, which
+// must be the player's current room.
+func (b *BusinessLogic) LeaveRoomByID(ctx context.Context, playerID string, roomID string) error {
 	//	Check if player exists
-	player, err := b.storage.GetPlayerByID(playerId)
+	player, err := b.storage.GetPlayerByID(ctx, playerID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if len(player.Room) == 0 {
+		return errormanagement.PlayerIdle
 	}
-	modes, err = b.storage.GetModesByRegionTrend(player.Region)
-	if err == nil {
-		b.cache.Set(context.Background(), cacheKey, modes, 5*time.Minute)
+	if player.Room != roomID {
+		return errormanagement.NotInRoom
 	}
-	return modes, err
+	return b.LeaveRoom(ctx, playerID)
 }
 
 // Helper function to check if the mode is valid.
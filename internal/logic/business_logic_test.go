@@ -0,0 +1,103 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/cache"
+	"DeathfireArsenal/pkg/chat"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/storage"
+	"DeathfireArsenal/pkg/webhooks"
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"testing"
+	"time"
+)
+
+const testRegion = "BLR"
+
+// newTestLogic returns business logic on a database of the test's own on the
+// MongoDB replica set of MONGODB_TEST_URL, dropped once the test is over, and
+// on a Redis of its own. Tests needing MongoDB are skipped without it.
+func newTestLogic(t *testing.T) (*BusinessLogic, *mongo.Database) {
+	t.Helper()
+	url := os.Getenv("MONGODB_TEST_URL")
+	if url == "" {
+		t.Skip("MONGODB_TEST_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(url))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	database := client.Database("test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+
+	server := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	catalog, err := regions.New([]regions.Region{{Code: testRegion, Name: "Bengaluru", Enabled: true}})
+	if err != nil {
+		t.Fatalf("regions.New: %v", err)
+	}
+	mongoDBStorage := storage.NewMongoDBStorage(database.Collection("rooms"), database.Collection("players"), database.Collection("trends"),
+		database.Collection("retiredplayerids"), database.Collection("friendships"), database.Collection("blocks"))
+	moderationStorage := storage.NewModerationStorage(database.Collection("matches"), database.Collection("reports"), database.Collection("bans"))
+	webhookStorage := storage.NewWebhookStorage(database.Collection("webhooks"), database.Collection("webhookoutbox"), database.Collection("webhookdeliveries"))
+	domainEventStorage := storage.NewDomainEventStorage(database.Collection("domainevents"))
+	if err := mongoDBStorage.CheckTransactions(ctx); err != nil {
+		t.Fatalf("CheckTransactions: %v", err)
+	}
+	for _, ensureIndexes := range []func(context.Context) error{
+		mongoDBStorage.EnsureIndexes,
+		moderationStorage.EnsureIndexes,
+		webhookStorage.EnsureIndexes,
+		domainEventStorage.EnsureIndexes,
+	} {
+		if err := ensureIndexes(ctx); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+	}
+
+	b := NewBusinessLogic(mongoDBStorage,
+		cache.NewRedisCache(redisClient, "test"), cache.Policy{SoftTTL: time.Minute, HardTTL: time.Minute},
+		events.NewRedisBus(redisClient, "events:test", 100), ReuseIDsImmediately, catalog, moderationStorage,
+		chat.NewHistory(redisClient, "chat:test", 10), chat.NewFilter(nil),
+		webhooks.NewRegistry(webhookStorage), domainEventStorage)
+	return b, database
+}
+
+// Helper function to register players in the test region.
+func createTestPlayers(t *testing.T, b *BusinessLogic, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := b.CreatePlayer(context.Background(), id, testRegion); err != nil {
+			t.Fatalf("CreatePlayer(%s): %v", id, err)
+		}
+	}
+}
+
+// Helper function to put players in a new room, the first of them hosting it.
+func createTestRoom(t *testing.T, b *BusinessLogic, host string, others ...string) string {
+	t.Helper()
+	ctx := context.Background()
+	roomID, err := b.CreateRoom(ctx, host, "mayhem", false)
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	for _, id := range others {
+		if err := b.JoinRoom(ctx, id, roomID); err != nil {
+			t.Fatalf("JoinRoom(%s): %v", id, err)
+		}
+	}
+	return roomID
+}
@@ -0,0 +1,20 @@
+package logic
+
+// Cache tags group the cached entries that a mutation can make stale, so that
+// only those entries are dropped.
+const allTrendsTag = "trends"
+
+func roomListTag(mode string) string {
+	return "rooms:mode:" + mode
+}
+
+func trendTag(region string) string {
+	return "trends:region:" + region
+}
+
+// roomMutationTags lists the tags touched when a player of region enters or
+// leaves a room of mode: the room list of the mode, the trends of the region
+// and the global trends.
+func roomMutationTags(mode string, region string) []string {
+	return []string{roomListTag(mode), trendTag(region), trendTag(AllRegions)}
+}
@@ -0,0 +1,62 @@
+package logic
+
+import (
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log"
+	"time"
+)
+
+// change is what a mutation leaves behind besides the documents it writes.
+type change struct {
+	// Who made the change, and the domain events it appends to the log along
+	// with it, all at the same instant and in order.
+	actor        storage.Actor
+	domainEvents []storage.DomainEvent
+	// Room events describe the rooms right after the change. The webhook
+	// events they stand for are written along with it; they are published
+	// once it is stored.
+	roomEvents []events.Event
+	// Cache tags to invalidate once the change is stored.
+	cacheTags []string
+}
+
+// commit runs a mutation in a MongoDB transaction together with appending its
+// domain events and writing the outbox, so that the log and the webhooks hear
+// of every change that is stored and of no other. The mutation has to make
+// its storage calls with the context it is given, and may run more than once.
+// Invalidating the cache and publishing follow the commit and are best effort.
+func (b *BusinessLogic) commit(ctx context.Context, mutate func(ctx context.Context) (change, error)) error {
+	var stored change
+	err := b.storage.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if stored, err = mutate(ctx); err != nil {
+			return err
+		}
+		at := time.Now().UTC()
+		for i := range stored.domainEvents {
+			stored.domainEvents[i].ID = primitive.NewObjectID().Hex()
+			stored.domainEvents[i].Actor = stored.actor
+			stored.domainEvents[i].At = at
+		}
+		if err := b.domainEvents.AppendDomainEvents(ctx, stored.domainEvents); err != nil {
+			return err
+		}
+		return b.webhooks.Enqueue(ctx, stored.roomEvents...)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(stored.cacheTags) > 0 {
+		b.cache.Invalidate(ctx, stored.cacheTags...)
+	}
+	for _, event := range stored.roomEvents {
+		if err := b.events.Publish(ctx, event); err != nil {
+			log.Println("Failed to publish room event:", err)
+		}
+	}
+	return nil
+}
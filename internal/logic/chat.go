@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/chat"
+	"DeathfireArsenal/pkg/events"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const MaxChatMessageLength = 500
+
+// PostChatMessage posts a message in the room of the player, once the words
+// of the word list are masked, and hands it to the members following the
+// room.
+func (b *BusinessLogic) PostChatMessage(ctx context.Context, playerID string, roomID string, text string) (*chat.Message, error) {
+	text, err := normalizeChatText(text)
+	if err != nil {
+		return nil, err
+	}
+	//	Check if player is a member of the room
+	if err := b.CheckRoomSubscription(playerID, roomID); err != nil {
+		return nil, err
+	}
+
+	message := &chat.Message{
+		RoomID:   roomID,
+		PlayerID: playerID,
+		SentAt:   time.Now().UTC(),
+	}
+	message.Text, message.Filtered = b.chatFilter.Clean(text)
+	if err := b.chat.Append(ctx, message); err != nil {
+		return nil, err
+	}
+
+	event := events.Event{
+		Type:      events.ChatMessage,
+		RoomID:    roomID,
+		PlayerID:  playerID,
+		Timestamp: message.SentAt,
+		Chat:      message,
+	}
+	if err := b.events.Publish(ctx, event); err != nil {
+		log.Println("Failed to publish chat message:", err)
+	}
+	return message, nil
+}
+
+// ChatHistory returns up to limit of the latest messages of the room of the
+// player, oldest first. A limit of 0 returns the whole history.
+func (b *BusinessLogic) ChatHistory(ctx context.Context, playerID string, roomID string, limit int) ([]chat.Message, error) {
+	if limit == 0 {
+		limit = b.chat.Size()
+	}
+	if limit < 1 || limit > b.chat.Size() {
+		return nil, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, b.chat.Size())
+	}
+	//	Check if player is a member of the room
+	if err := b.CheckRoomSubscription(playerID, roomID); err != nil {
+		return nil, err
+	}
+	return b.chat.Latest(ctx, roomID, limit)
+}
+
+// Helper function to drop the chat of a room that is gone. The history
+// expires on its own should this fail.
+func (b *BusinessLogic) deleteChat(ctx context.Context, roomID string) {
+	if err := b.chat.Delete(ctx, roomID); err != nil {
+		log.Println("Failed to delete the chat of room", roomID+":", err)
+	}
+}
+
+// Helper function to trim a chat message and check that it is not empty, at
+// most MaxChatMessageLength characters long and printable.
+func normalizeChatText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("%w: text is required", errormanagement.MissingParameter)
+	}
+	if utf8.RuneCountInString(text) > MaxChatMessageLength {
+		return "", fmt.Errorf("%w: text must be at most %d characters long", errormanagement.InvalidParameter, MaxChatMessageLength)
+	}
+	for _, r := range text {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("%w: text must be printable", errormanagement.InvalidParameter)
+		}
+	}
+	return text, nil
+}
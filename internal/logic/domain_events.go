@@ -0,0 +1,151 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Types of the domain events every change to the players and rooms appends
+// to the domain event log.
+const (
+	PlayerCreated = "player_created"
+	PlayerDeleted = "player_deleted"
+	RoomCreated   = "room_created"
+	RoomDeleted   = "room_deleted"
+	PlayerJoined  = "player_joined"
+	PlayerLeft    = "player_left"
+	// Changes to a player's profile and standing.
+	RegionChanged      = "region_changed"
+	DisplayNameChanged = "display_name_changed"
+	PlayerBanned       = "player_banned"
+	BanLifted          = "ban_lifted"
+)
+
+// Reasons a room is deleted, and the reason a player leaves a room closed
+// under them.
+const (
+	RoomEmptied      = "emptied"
+	RoomClosed       = "closed"
+	PlayerRoomClosed = "room_closed"
+)
+
+const (
+	DefaultDomainEventLimit = 100
+	MaxDomainEventLimit     = 1000
+)
+
+// DomainEventQuery selects a page of the domain event log. From is inclusive
+// and To exclusive; zero times leave the range open.
+type DomainEventQuery struct {
+	PlayerID string
+	RoomID   string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	// NextCursor of the previous page.
+	Cursor string
+}
+
+// DomainEventPage is one page of the domain event log. NextCursor is empty on the last page.
+type DomainEventPage struct {
+	Events     []storage.DomainEvent
+	NextCursor string
+}
+
+// domainEventCursor records the last event of a page of the domain event log.
+type domainEventCursor struct {
+	At time.Time `json:"at"`
+	ID string    `json:"id"`
+}
+
+// ListDomainEvents returns a page of the domain events of a player, a room
+// or a time range, oldest first.
+func (b *BusinessLogic) ListDomainEvents(ctx context.Context, query DomainEventQuery) (DomainEventPage, error) {
+	var page DomainEventPage
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return page, fmt.Errorf("%w: from must be before to", errormanagement.InvalidParameter)
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultDomainEventLimit
+	}
+	if query.Limit < 1 || query.Limit > MaxDomainEventLimit {
+		return page, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, MaxDomainEventLimit)
+	}
+
+	filter := storage.DomainEventFilter{
+		PlayerID: query.PlayerID,
+		RoomID:   query.RoomID,
+		From:     query.From,
+		To:       query.To,
+		// One more than asked tells whether there is another page.
+		Limit: query.Limit + 1,
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeDomainEventCursor(query.Cursor)
+		if err != nil {
+			return page, fmt.Errorf("%w: cursor is not valid", errormanagement.InvalidParameter)
+		}
+		filter.After = &storage.DomainEvent{ID: cursor.ID, At: cursor.At}
+	}
+
+	domainEvents, err := b.domainEvents.ListDomainEvents(ctx, filter)
+	if err != nil {
+		return page, err
+	}
+	if len(domainEvents) > query.Limit {
+		domainEvents = domainEvents[:query.Limit]
+		last := domainEvents[len(domainEvents)-1]
+		page.NextCursor = encodeDomainEventCursor(domainEventCursor{At: last.At, ID: last.ID})
+	}
+	page.Events = domainEvents
+	return page, nil
+}
+
+// Helper function to tell who is behind a request: the service whose API key
+// it carries, the player of its session token, or else the player it names.
+// Requests naming no one are made by the system.
+func actorOf(ctx context.Context, playerID string) storage.Actor {
+	if key, ok := auth.APIKeyFromContext(ctx); ok {
+		return storage.Actor{Type: storage.ActorService, ID: key.ID}
+	}
+	if claims, ok := auth.FromContext(ctx); ok {
+		return storage.Actor{Type: storage.ActorPlayer, ID: claims.Subject}
+	}
+	if playerID != "" {
+		return storage.Actor{Type: storage.ActorPlayer, ID: playerID}
+	}
+	return storage.Actor{Type: storage.ActorSystem}
+}
+
+// Helper function to make up the pseudonym a deleted player goes by in the
+// domain event log. It is random, so it can't be traced back to the player.
+func newPseudonym() (string, error) {
+	data := make([]byte, 8)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return "deleted-" + hex.EncodeToString(data), nil
+}
+
+func encodeDomainEventCursor(cursor domainEventCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeDomainEventCursor(encoded string) (domainEventCursor, error) {
+	var cursor domainEventCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestActorOf(t *testing.T) {
+	session := auth.WithClaims(context.Background(), &auth.Claims{Subject: "Valiant"})
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		playerID string
+		want     storage.Actor
+	}{
+		{"a service", auth.WithAPIKey(session, &storage.APIKey{ID: "k1"}), "Furious", storage.Actor{Type: storage.ActorService, ID: "k1"}},
+		{"a session", session, "Furious", storage.Actor{Type: storage.ActorPlayer, ID: "Valiant"}},
+		{"a named player", context.Background(), "Furious", storage.Actor{Type: storage.ActorPlayer, ID: "Furious"}},
+		{"no one", context.Background(), "", storage.Actor{Type: storage.ActorSystem}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := actorOf(test.ctx, test.playerID); got != test.want {
+				t.Fatalf("actorOf = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDeletePlayerPseudonymizesDomainEvents(t *testing.T) {
+	b, _ := newTestLogic(t)
+	ctx := auth.WithClaims(context.Background(), &auth.Claims{Subject: "Furious"})
+	createTestPlayers(t, b, "Furious", "Valiant")
+	name := "Fury"
+	if _, err := b.UpdateProfile(ctx, "Furious", ProfileUpdate{DisplayName: &name}); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	roomID := createTestRoom(t, b, "Furious", "Valiant")
+
+	if err := b.DeletePlayer(ctx, "Furious"); err != nil {
+		t.Fatalf("DeletePlayer: %v", err)
+	}
+
+	page, err := b.ListDomainEvents(context.Background(), DomainEventQuery{})
+	if err != nil {
+		t.Fatalf("ListDomainEvents: %v", err)
+	}
+	var pseudonym string
+	for _, event := range page.Events {
+		if event.PlayerID == "Furious" || event.Actor.ID == "Furious" || event.DisplayName == name {
+			t.Fatalf("event %+v still names the deleted player", event)
+		}
+		if event.Type == PlayerDeleted {
+			pseudonym = event.PlayerID
+		}
+	}
+	if !strings.HasPrefix(pseudonym, "deleted-") {
+		t.Fatalf("the player_deleted event names %q, want a pseudonym", pseudonym)
+	}
+
+	// The history of the player is kept under one pseudonym.
+	page, err = b.ListDomainEvents(context.Background(), DomainEventQuery{PlayerID: pseudonym})
+	if err != nil {
+		t.Fatalf("ListDomainEvents: %v", err)
+	}
+	var types []string
+	for _, event := range page.Events {
+		types = append(types, event.Type)
+		if event.Actor != (storage.Actor{Type: storage.ActorPlayer, ID: pseudonym}) {
+			t.Errorf("%s event was made by %+v, want the pseudonym", event.Type, event.Actor)
+		}
+		if event.Type == RoomCreated && event.RoomID != roomID {
+			t.Errorf("room_created event of %s, want %s", event.RoomID, roomID)
+		}
+	}
+	want := []string{PlayerCreated, DisplayNameChanged, RoomCreated, PlayerJoined, PlayerLeft, PlayerDeleted}
+	if strings.Join(types, " ") != strings.Join(want, " ") {
+		t.Fatalf("events of the pseudonym = %v, want %v", types, want)
+	}
+
+	// Those of the other player are untouched.
+	page, err = b.ListDomainEvents(context.Background(), DomainEventQuery{PlayerID: "Valiant"})
+	if err != nil {
+		t.Fatalf("ListDomainEvents: %v", err)
+	}
+	if len(page.Events) == 0 {
+		t.Fatal("the events of Valiant are gone")
+	}
+}
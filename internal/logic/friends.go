@@ -0,0 +1,270 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PresenceTimeout is how long a player stays online after its last heartbeat.
+// Clients are expected to send one every 30 seconds.
+const PresenceTimeout = 90 * time.Second
+
+const (
+	PresenceOffline = "offline"
+	PresenceOnline  = "online"
+	PresenceInRoom  = "in_room"
+)
+
+// Presence tells whether a player is around, and where. The room fields are
+// only set while the player is in a room.
+type Presence struct {
+	Status    string    `json:"status"`
+	LastSeen  time.Time `json:"last_seen"`
+	RoomID    string    `json:"room_id,omitempty"`
+	Mode      string    `json:"mode,omitempty"`
+	FreeSlots int       `json:"free_slots,omitempty"`
+}
+
+// Friend is an entry of a friends list.
+type Friend struct {
+	PlayerID    string    `json:"player_id"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Since       time.Time `json:"since"`
+	Presence    Presence  `json:"presence"`
+}
+
+const (
+	FriendRequestIncoming = "incoming"
+	FriendRequestOutgoing = "outgoing"
+)
+
+// FriendRequest is a pending request sent or received by a player.
+type FriendRequest struct {
+	PlayerID  string    `json:"player_id"`
+	Direction string    `json:"direction"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SendFriendRequest asks another player to be friends. Asking someone who has
+// already asked the player accepts their request instead, which is reported
+// by the result.
+func (b *BusinessLogic) SendFriendRequest(ctx context.Context, playerID string, otherID string) (bool, error) {
+	if playerID == otherID {
+		return false, fmt.Errorf("%w: players can't befriend themselves", errormanagement.InvalidParameter)
+	}
+	//	Check if both players exist
+	if _, err := b.storage.GetPlayerByID(ctx, playerID); err != nil {
+		return false, err
+	}
+	if _, err := b.storage.GetPlayerByID(ctx, otherID); err != nil {
+		return false, err
+	}
+
+	// A request to someone the player blocked is a mistake worth telling, but
+	// one from a player that was blocked is dropped as if it had been sent.
+	if blocked, err := b.storage.HasBlocked(ctx, playerID, otherID); err != nil || blocked {
+		if err == nil {
+			err = fmt.Errorf("%w: unblock %s first", errormanagement.InvalidParameter, otherID)
+		}
+		return false, err
+	}
+	if blocked, err := b.storage.HasBlocked(ctx, otherID, playerID); err != nil || blocked {
+		return false, err
+	}
+
+	friendship, err := b.storage.GetFriendship(ctx, playerID, otherID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case friendship == nil:
+		return false, b.storage.CreateFriendRequest(ctx, playerID, otherID)
+	case friendship.Accepted:
+		return false, errormanagement.AlreadyFriends
+	case friendship.Requester == playerID:
+		// Asking again changes nothing.
+		return false, nil
+	default:
+		return true, b.storage.AcceptFriendRequest(ctx, otherID, playerID)
+	}
+}
+
+// AcceptFriendRequest accepts the request the player received from requesterID.
+func (b *BusinessLogic) AcceptFriendRequest(ctx context.Context, playerID string, requesterID string) error {
+	err := b.storage.AcceptFriendRequest(ctx, requesterID, playerID)
+	if errors.Is(err, errormanagement.FriendRequestNotFound) {
+		// Accepting twice succeeds, so the request can safely be repeated.
+		if friendship, getErr := b.storage.GetFriendship(ctx, playerID, requesterID); getErr == nil && friendship != nil && friendship.Accepted {
+			return nil
+		}
+	}
+	return err
+}
+
+// DeclineFriendRequest declines a request the player received from otherID,
+// or withdraws one it sent to otherID.
+func (b *BusinessLogic) DeclineFriendRequest(ctx context.Context, playerID string, otherID string) error {
+	deleted, err := b.storage.DeleteFriendship(ctx, playerID, otherID, false)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errormanagement.FriendRequestNotFound
+	}
+	return nil
+}
+
+// RemoveFriend ends the friendship of two players.
+func (b *BusinessLogic) RemoveFriend(ctx context.Context, playerID string, friendID string) error {
+	deleted, err := b.storage.DeleteFriendship(ctx, playerID, friendID, true)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errormanagement.FriendNotFound
+	}
+	return nil
+}
+
+// ListFriendRequests returns the pending requests of a player, oldest first.
+func (b *BusinessLogic) ListFriendRequests(ctx context.Context, playerID string) ([]FriendRequest, error) {
+	//	Check if player exists
+	if _, err := b.storage.GetPlayerByID(ctx, playerID); err != nil {
+		return nil, err
+	}
+	friendships, err := b.storage.ListFriendships(ctx, playerID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]FriendRequest, 0, len(friendships))
+	for _, friendship := range friendships {
+		direction := FriendRequestIncoming
+		if friendship.Requester == playerID {
+			direction = FriendRequestOutgoing
+		}
+		requests = append(requests, FriendRequest{
+			PlayerID:  friendship.Other(playerID),
+			Direction: direction,
+			CreatedAt: friendship.CreatedAt,
+		})
+	}
+	return requests, nil
+}
+
+// ListFriends returns the friends of a player with their presence: those in a
+// room first, then those online, then the rest, each by ID.
+func (b *BusinessLogic) ListFriends(ctx context.Context, playerID string) ([]Friend, error) {
+	//	Check if player exists
+	if _, err := b.storage.GetPlayerByID(ctx, playerID); err != nil {
+		return nil, err
+	}
+	friendships, err := b.storage.ListFriendships(ctx, playerID, true)
+	if err != nil {
+		return nil, err
+	}
+	since := make(map[string]time.Time, len(friendships))
+	friendIDs := make([]string, 0, len(friendships))
+	for _, friendship := range friendships {
+		friendID := friendship.Other(playerID)
+		since[friendID] = friendship.AcceptedAt
+		friendIDs = append(friendIDs, friendID)
+	}
+
+	presences, err := b.storage.GetPlayerPresences(ctx, friendIDs)
+	if err != nil {
+		return nil, err
+	}
+	var roomIDs []string
+	for _, presence := range presences {
+		if presence.Room != "" {
+			roomIDs = append(roomIDs, presence.Room)
+		}
+	}
+	rooms, err := b.storage.GetRoomsByIDs(ctx, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	roomsByID := make(map[string]*RoomDetails, len(rooms))
+	for _, room := range rooms {
+		roomsByID[room.Id] = roomDetails(room)
+	}
+
+	now := time.Now()
+	friends := make([]Friend, 0, len(presences))
+	for _, presence := range presences {
+		friends = append(friends, Friend{
+			PlayerID:    presence.ID,
+			DisplayName: presence.DisplayName,
+			Since:       since[presence.ID],
+			Presence:    presenceOf(presence, roomsByID[presence.Room], now),
+		})
+	}
+	order := map[string]int{PresenceInRoom: 0, PresenceOnline: 1, PresenceOffline: 2}
+	sort.Slice(friends, func(i, j int) bool {
+		if order[friends[i].Presence.Status] != order[friends[j].Presence.Status] {
+			return order[friends[i].Presence.Status] < order[friends[j].Presence.Status]
+		}
+		return friends[i].PlayerID < friends[j].PlayerID
+	})
+	return friends, nil
+}
+
+// Heartbeat keeps a player online for another PresenceTimeout.
+func (b *BusinessLogic) Heartbeat(ctx context.Context, playerID string) error {
+	return b.storage.TouchPlayer(ctx, playerID, time.Now())
+}
+
+// JoinFriend puts the player in the room of a friend and returns the room.
+// Joining the room the player is already in succeeds.
+func (b *BusinessLogic) JoinFriend(ctx context.Context, playerID string, friendID string) (string, error) {
+	friendship, err := b.storage.GetFriendship(ctx, playerID, friendID)
+	if err != nil {
+		return "", err
+	}
+	if friendship == nil || !friendship.Accepted {
+		return "", errormanagement.FriendNotFound
+	}
+
+	friend, err := b.storage.GetPlayerByID(ctx, friendID)
+	if err != nil {
+		return "", err
+	}
+	if len(friend.Room) == 0 {
+		return "", fmt.Errorf("%w: %s is not in a room", errormanagement.PlayerIdle, friendID)
+	}
+
+	err = b.JoinRoom(ctx, playerID, friend.Room)
+	if errors.Is(err, errormanagement.PlayerOccupied) {
+		if player, playerErr := b.storage.GetPlayerByID(ctx, playerID); playerErr == nil && player.Room == friend.Room {
+			return friend.Room, nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return friend.Room, nil
+}
+
+// Helper function to work out the presence of a player. Players whose last
+// heartbeat is older than PresenceTimeout are offline, even in a room.
+func presenceOf(player storage.PlayerPresence, room *RoomDetails, now time.Time) Presence {
+	presence := Presence{Status: PresenceOffline, LastSeen: player.LastSeenAt}
+	if player.LastSeenAt.IsZero() || now.Sub(player.LastSeenAt) > PresenceTimeout {
+		return presence
+	}
+	if room == nil {
+		presence.Status = PresenceOnline
+		return presence
+	}
+	presence.Status = PresenceInRoom
+	presence.RoomID = room.ID
+	presence.Mode = room.Mode
+	presence.FreeSlots = room.FreeSlots
+	return presence
+}
@@ -0,0 +1,148 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/constants"
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPresenceOf(t *testing.T) {
+	now := time.Now()
+	room := &RoomDetails{ID: "dfjlnas", Mode: "mayhem", FreeSlots: 3}
+	tests := []struct {
+		name     string
+		lastSeen time.Time
+		room     *RoomDetails
+		want     Presence
+	}{
+		{"never seen", time.Time{}, nil, Presence{Status: PresenceOffline}},
+		{"seen long ago", now.Add(-PresenceTimeout - time.Second), nil, Presence{Status: PresenceOffline, LastSeen: now.Add(-PresenceTimeout - time.Second)}},
+		{"seen long ago in a room", now.Add(-time.Hour), room, Presence{Status: PresenceOffline, LastSeen: now.Add(-time.Hour)}},
+		{"seen lately", now.Add(-time.Minute), nil, Presence{Status: PresenceOnline, LastSeen: now.Add(-time.Minute)}},
+		{"seen lately in a room", now.Add(-time.Minute), room,
+			Presence{Status: PresenceInRoom, LastSeen: now.Add(-time.Minute), RoomID: "dfjlnas", Mode: "mayhem", FreeSlots: 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := presenceOf(storage.PlayerPresence{ID: "Furious", LastSeenAt: test.lastSeen}, test.room, now)
+			if got != test.want {
+				t.Fatalf("presenceOf = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFriendRequests(t *testing.T) {
+	b, _ := newTestLogic(t)
+	ctx := context.Background()
+	createTestPlayers(t, b, "Furious", "Valiant", "Brave", "Steady")
+
+	if accepted, err := b.SendFriendRequest(ctx, "Furious", "Valiant"); err != nil || accepted {
+		t.Fatalf("SendFriendRequest = %t, %v, want a request sent", accepted, err)
+	}
+	requests, err := b.ListFriendRequests(ctx, "Valiant")
+	if err != nil {
+		t.Fatalf("ListFriendRequests: %v", err)
+	}
+	if len(requests) != 1 || requests[0].PlayerID != "Furious" || requests[0].Direction != FriendRequestIncoming {
+		t.Fatalf("requests of Valiant = %+v, want one from Furious", requests)
+	}
+	if err := b.AcceptFriendRequest(ctx, "Valiant", "Furious"); err != nil {
+		t.Fatalf("AcceptFriendRequest: %v", err)
+	}
+	if err := b.AcceptFriendRequest(ctx, "Valiant", "Furious"); err != nil {
+		t.Fatalf("accepting twice: %v", err)
+	}
+
+	// Asking someone who asked first accepts their request.
+	if _, err := b.SendFriendRequest(ctx, "Brave", "Furious"); err != nil {
+		t.Fatalf("SendFriendRequest: %v", err)
+	}
+	if accepted, err := b.SendFriendRequest(ctx, "Furious", "Brave"); err != nil || !accepted {
+		t.Fatalf("SendFriendRequest back = %t, %v, want the request accepted", accepted, err)
+	}
+
+	if _, err := b.SendFriendRequest(ctx, "Furious", "Steady"); err != nil {
+		t.Fatalf("SendFriendRequest: %v", err)
+	}
+	if err := b.DeclineFriendRequest(ctx, "Steady", "Furious"); err != nil {
+		t.Fatalf("DeclineFriendRequest: %v", err)
+	}
+	if requests, err := b.ListFriendRequests(ctx, "Furious"); err != nil || len(requests) != 0 {
+		t.Fatalf("requests of Furious = %+v, %v, want none", requests, err)
+	}
+
+	friends, err := b.ListFriends(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("ListFriends: %v", err)
+	}
+	if len(friends) != 2 || friends[0].PlayerID != "Brave" || friends[1].PlayerID != "Valiant" {
+		t.Fatalf("friends of Furious = %+v, want Brave and Valiant", friends)
+	}
+
+	if err := b.RemoveFriend(ctx, "Furious", "Brave"); err != nil {
+		t.Fatalf("RemoveFriend: %v", err)
+	}
+	if err := b.RemoveFriend(ctx, "Furious", "Brave"); !errors.Is(err, errormanagement.FriendNotFound) {
+		t.Fatalf("removing twice = %v, want %v", err, errormanagement.FriendNotFound)
+	}
+}
+
+func TestFriendPresenceAndJoinFriend(t *testing.T) {
+	b, _ := newTestLogic(t)
+	ctx := context.Background()
+	createTestPlayers(t, b, "Furious", "Valiant", "Brave", "Steady")
+	for _, friendID := range []string{"Valiant", "Brave", "Steady"} {
+		if _, err := b.SendFriendRequest(ctx, "Furious", friendID); err != nil {
+			t.Fatalf("SendFriendRequest(%s): %v", friendID, err)
+		}
+		if err := b.AcceptFriendRequest(ctx, friendID, "Furious"); err != nil {
+			t.Fatalf("AcceptFriendRequest(%s): %v", friendID, err)
+		}
+	}
+	roomID := createTestRoom(t, b, "Valiant")
+	for _, id := range []string{"Valiant", "Brave"} {
+		if err := b.Heartbeat(ctx, id); err != nil {
+			t.Fatalf("Heartbeat(%s): %v", id, err)
+		}
+	}
+
+	friends, err := b.ListFriends(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("ListFriends: %v", err)
+	}
+	statuses := map[string]string{"Valiant": PresenceInRoom, "Brave": PresenceOnline, "Steady": PresenceOffline}
+	if len(friends) != len(statuses) {
+		t.Fatalf("friends of Furious = %+v, want %d", friends, len(statuses))
+	}
+	for i, id := range []string{"Valiant", "Brave", "Steady"} {
+		if friends[i].PlayerID != id || friends[i].Presence.Status != statuses[id] {
+			t.Fatalf("friend %d = %+v, want %s %s", i, friends[i], id, statuses[id])
+		}
+	}
+	capacity := constants.RoomLimit(constants.ParseMode("mayhem"))
+	if presence := friends[0].Presence; presence.RoomID != roomID || presence.Mode != "mayhem" || presence.FreeSlots != capacity-1 {
+		t.Fatalf("presence of Valiant = %+v, want in %s with %d free slots", presence, roomID, capacity-1)
+	}
+
+	for i := 0; i < 2; i++ {
+		joined, err := b.JoinFriend(ctx, "Furious", "Valiant")
+		if err != nil || joined != roomID {
+			t.Fatalf("JoinFriend #%d = %s, %v, want %s", i+1, joined, err, roomID)
+		}
+	}
+	if _, err := b.JoinFriend(ctx, "Steady", "Brave"); !errors.Is(err, errormanagement.FriendNotFound) {
+		t.Fatalf("joining a stranger = %v, want %v", err, errormanagement.FriendNotFound)
+	}
+	if _, err := b.JoinFriend(ctx, "Furious", "Steady"); !errors.Is(err, errormanagement.PlayerIdle) {
+		t.Fatalf("joining a friend out of any room = %v, want %v", err, errormanagement.PlayerIdle)
+	}
+	// The friend who joined can be joined in turn.
+	if joined, err := b.JoinFriend(ctx, "Brave", "Furious"); err != nil || joined != roomID {
+		t.Fatalf("JoinFriend(Brave, Furious) = %s, %v, want %s", joined, err, roomID)
+	}
+}
@@ -0,0 +1,425 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RecentMatchWindow is how long after leaving a room its players can still
+// report each other.
+const RecentMatchWindow = 24 * time.Hour
+
+const MaxReportTextLength = 1000
+
+// Categories a report can be filed under.
+var ReportCategories = []string{"cheating", "harassment", "hate_speech", "griefing", "spam", "inappropriate_name", "other"}
+
+// States of a report in the moderation queue.
+const (
+	ReportOpen     = "open"
+	ReportReviewed = "reviewed"
+	ReportActioned = "actioned"
+)
+
+// Sources of the room of a report.
+const (
+	ReportRoomCurrent = "current"
+	ReportRoomRecent  = "recent"
+)
+
+const (
+	DefaultReportLimit = 50
+	MaxReportLimit     = 200
+)
+
+// ReportQuery selects a page of the moderation queue.
+type ReportQuery struct {
+	State    string
+	Reported string
+	Limit    int
+	// NextCursor of the previous page.
+	Cursor string
+}
+
+// ReportPage is one page of the moderation queue. NextCursor is empty on the last page.
+type ReportPage struct {
+	Reports    []storage.Report
+	NextCursor string
+}
+
+// ReportContext is a report along with what a moderator needs to judge it:
+// the room as it is now, if it is still open, and the ban the reported player
+// is serving, if any.
+type ReportContext struct {
+	Report    storage.Report
+	Room      *RoomDetails
+	ActiveBan *storage.Ban
+}
+
+// BanRequest describes a ban to issue. A ban either lasts Duration or, when
+// Permanent is set, until it is lifted.
+type BanRequest struct {
+	PlayerID  string
+	Reason    string
+	Duration  time.Duration
+	Permanent bool
+	// Reports of the player that led to the ban; they are marked actioned.
+	ReportIDs []string
+	// The API key of the moderator issuing the ban.
+	IssuedBy string
+}
+
+// reportCursor records the last report of a page of the moderation queue.
+type reportCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// ReportPlayer files a report about a player the reporter is in a room with,
+// or played with in the last RecentMatchWindow. The room is recorded with the
+// report. Reporting a player again for the same room while the first report
+// is open returns the first report.
+func (b *BusinessLogic) ReportPlayer(ctx context.Context, reporterID string, reportedID string, category string, text string) (*storage.Report, error) {
+	if reporterID == reportedID {
+		return nil, fmt.Errorf("%w: players can't report themselves", errormanagement.InvalidParameter)
+	}
+	category = strings.ToLower(strings.TrimSpace(category))
+	if !isReportCategory(category) {
+		return nil, fmt.Errorf("%w: category must be one of %s", errormanagement.InvalidParameter, strings.Join(ReportCategories, ", "))
+	}
+	text, err := normalizeReportText(text)
+	if err != nil {
+		return nil, err
+	}
+	//	Check if both players exist
+	reporter, err := b.storage.GetPlayerByID(ctx, reporterID)
+	if err != nil {
+		return nil, err
+	}
+	reported, err := b.storage.GetPlayerByID(ctx, reportedID)
+	if err != nil {
+		return nil, err
+	}
+
+	room, err := b.sharedRoom(ctx, reporter, reported)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := b.moderation.FindOpenReport(ctx, reporterID, reportedID, room.ID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	id, err := newModerationID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	report := &storage.Report{
+		ID:        id,
+		Reporter:  reporterID,
+		Reported:  reportedID,
+		Category:  category,
+		Text:      text,
+		Room:      *room,
+		State:     ReportOpen,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := b.moderation.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListReports returns a page of the moderation queue, oldest first.
+func (b *BusinessLogic) ListReports(ctx context.Context, query ReportQuery) (ReportPage, error) {
+	var page ReportPage
+	filter := storage.ReportFilter{Reported: query.Reported}
+	if query.State != "" {
+		if !isReportState(query.State) {
+			return page, invalidReportState()
+		}
+		filter.State = query.State
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultReportLimit
+	}
+	if query.Limit < 1 || query.Limit > MaxReportLimit {
+		return page, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, MaxReportLimit)
+	}
+	// One more than asked tells whether there is another page.
+	filter.Limit = query.Limit + 1
+	if query.Cursor != "" {
+		cursor, err := decodeReportCursor(query.Cursor)
+		if err != nil {
+			return page, fmt.Errorf("%w: cursor is not valid", errormanagement.InvalidParameter)
+		}
+		filter.After = &storage.Report{ID: cursor.ID, CreatedAt: cursor.CreatedAt}
+	}
+
+	reports, err := b.moderation.ListReports(ctx, filter)
+	if err != nil {
+		return page, err
+	}
+	if len(reports) > query.Limit {
+		reports = reports[:query.Limit]
+		last := reports[len(reports)-1]
+		page.NextCursor = encodeReportCursor(reportCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	page.Reports = reports
+	return page, nil
+}
+
+// GetReport returns a report along with its context.
+func (b *BusinessLogic) GetReport(ctx context.Context, id string) (*ReportContext, error) {
+	report, err := b.moderation.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	reportContext := &ReportContext{Report: *report}
+
+	room, err := b.storage.GetRoomByID(ctx, report.Room.ID)
+	if err == nil {
+		reportContext.Room = roomDetails(room)
+	} else if !errors.Is(err, errormanagement.RoomNotFound) {
+		return nil, err
+	}
+	reportContext.ActiveBan, err = b.moderation.ActiveBan(ctx, report.Reported, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return reportContext, nil
+}
+
+// ReviewReport moves a report to another state on behalf of a moderator,
+// with an optional note.
+func (b *BusinessLogic) ReviewReport(ctx context.Context, id string, state string, moderator string, note string) (*storage.Report, error) {
+	if !isReportState(state) {
+		return nil, invalidReportState()
+	}
+	if _, err := b.moderation.GetReport(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := b.moderation.UpdateReportState(ctx, []string{id}, state, moderator, strings.TrimSpace(note)); err != nil {
+		return nil, err
+	}
+	return b.moderation.GetReport(ctx, id)
+}
+
+// BanPlayer keeps a player out of rooms. A player banned while in a room is
+// removed from it.
+func (b *BusinessLogic) BanPlayer(ctx context.Context, request BanRequest) (*storage.Ban, error) {
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		return nil, fmt.Errorf("%w: a ban needs a reason", errormanagement.MissingParameter)
+	}
+	if request.Permanent == (request.Duration > 0) {
+		return nil, fmt.Errorf("%w: a ban is either permanent or lasts a positive duration", errormanagement.InvalidParameter)
+	}
+	//	Check if player exists
+	player, err := b.storage.GetPlayerByID(ctx, request.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+	//	Check that the reports are about the player
+	for _, reportID := range request.ReportIDs {
+		report, err := b.moderation.GetReport(ctx, reportID)
+		if err != nil {
+			return nil, err
+		}
+		if report.Reported != request.PlayerID {
+			return nil, fmt.Errorf("%w: report %s is about another player", errormanagement.InvalidParameter, reportID)
+		}
+	}
+
+	id, err := newModerationID()
+	if err != nil {
+		return nil, err
+	}
+	ban := &storage.Ban{
+		ID:        id,
+		PlayerID:  request.PlayerID,
+		Reason:    request.Reason,
+		ReportIDs: request.ReportIDs,
+		IssuedBy:  request.IssuedBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	if !request.Permanent {
+		ban.ExpiresAt = ban.CreatedAt.Add(request.Duration)
+	}
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		if err := b.moderation.CreateBan(ctx, ban); err != nil {
+			return change{}, err
+		}
+		if len(request.ReportIDs) > 0 {
+			if err := b.moderation.UpdateReportState(ctx, request.ReportIDs, ReportActioned, request.IssuedBy, ""); err != nil {
+				return change{}, err
+			}
+		}
+		return change{
+			actor:        actorOf(ctx, ""),
+			domainEvents: []storage.DomainEvent{{Type: PlayerBanned, PlayerID: ban.PlayerID, BanID: ban.ID}},
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(player.Room) != 0 {
+		if err := b.LeaveRoom(ctx, request.PlayerID); err != nil && !errors.Is(err, errormanagement.PlayerIdle) {
+			return nil, err
+		}
+	}
+	return ban, nil
+}
+
+// ListBans returns every ban a player was issued, newest first.
+func (b *BusinessLogic) ListBans(ctx context.Context, playerID string) ([]storage.Ban, error) {
+	//	Check if player exists
+	if _, err := b.storage.GetPlayerByID(ctx, playerID); err != nil {
+		return nil, err
+	}
+	return b.moderation.ListBans(ctx, playerID)
+}
+
+// LiftBan ends a ban of a player early.
+func (b *BusinessLogic) LiftBan(ctx context.Context, playerID string, banID string) (*storage.Ban, error) {
+	var ban *storage.Ban
+	err := b.commit(ctx, func(ctx context.Context) (change, error) {
+		var lifted bool
+		var err error
+		if ban, lifted, err = b.moderation.LiftBan(ctx, playerID, banID); err != nil || !lifted {
+			return change{}, err
+		}
+		return change{
+			actor:        actorOf(ctx, ""),
+			domainEvents: []storage.DomainEvent{{Type: BanLifted, PlayerID: playerID, BanID: banID}},
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+// Helper function to refuse a banned player, telling it when the ban ends.
+func (b *BusinessLogic) checkNotBanned(ctx context.Context, playerID string) error {
+	ban, err := b.moderation.ActiveBan(ctx, playerID, time.Now())
+	if err != nil {
+		return err
+	}
+	if ban == nil {
+		return nil
+	}
+	if ban.ExpiresAt.IsZero() {
+		return fmt.Errorf("%w: for good", errormanagement.PlayerBanned)
+	}
+	return fmt.Errorf("%w: until %s", errormanagement.PlayerBanned, ban.ExpiresAt.Format(time.RFC3339))
+}
+
+// Helper function to find the room two players share, or shared last.
+func (b *BusinessLogic) sharedRoom(ctx context.Context, reporter *models.Player, reported *models.Player) (*storage.ReportRoom, error) {
+	if reporter.Room != "" && reporter.Room == reported.Room {
+		room, err := b.storage.GetRoomByID(ctx, reporter.Room)
+		if err == nil {
+			return &storage.ReportRoom{ID: room.Id, Mode: room.Mode, Players: room.PlayerIds, Source: ReportRoomCurrent}, nil
+		}
+		if !errors.Is(err, errormanagement.RoomNotFound) {
+			return nil, err
+		}
+	}
+
+	match, err := b.moderation.LastSharedMatch(ctx, reporter.Id, reported.Id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if match == nil {
+		return nil, errormanagement.NoSharedMatch
+	}
+	players := append([]string{match.Player}, match.Players...)
+	return &storage.ReportRoom{ID: match.RoomID, Mode: match.Mode, Players: players, Source: ReportRoomRecent}, nil
+}
+
+// Helper function to remember who the leaving players played with, so that
+// they can report each other for a while. Losing a match only costs a report,
+// so the room change goes through regardless.
+func (b *BusinessLogic) recordMatches(ctx context.Context, room *models.Room, leaving ...string) {
+	now := time.Now()
+	if err := b.moderation.RecordMatches(ctx, room.Id, room.Mode, room.PlayerIds, leaving, now, now.Add(RecentMatchWindow)); err != nil {
+		log.Println("Failed to record the match of room", room.Id+":", err)
+	}
+}
+
+// Helper function to trim the text of a report and check that it is at most
+// MaxReportTextLength characters long and printable, line breaks aside.
+func normalizeReportText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > MaxReportTextLength {
+		return "", fmt.Errorf("%w: text must be at most %d characters long", errormanagement.InvalidParameter, MaxReportTextLength)
+	}
+	for _, r := range text {
+		if !unicode.IsPrint(r) && r != '\n' {
+			return "", fmt.Errorf("%w: text must be printable", errormanagement.InvalidParameter)
+		}
+	}
+	return text, nil
+}
+
+func isReportCategory(category string) bool {
+	for _, known := range ReportCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+func isReportState(state string) bool {
+	switch state {
+	case ReportOpen, ReportReviewed, ReportActioned:
+		return true
+	default:
+		return false
+	}
+}
+
+func invalidReportState() error {
+	return fmt.Errorf("%w: state must be %s, %s or %s", errormanagement.InvalidParameter, ReportOpen, ReportReviewed, ReportActioned)
+}
+
+// Helper function to generate the ID of a report or a ban.
+func newModerationID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func encodeReportCursor(cursor reportCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeReportCursor(encoded string) (reportCursor, error) {
+	var cursor reportCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
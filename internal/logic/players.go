@@ -0,0 +1,217 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const MaxDisplayNameLength = 32
+
+// PlayerIDReuse says how long the ID of a deleted player stays taken.
+type PlayerIDReuse time.Duration
+
+const (
+	// ReuseIDsImmediately frees the ID as soon as the player is deleted.
+	ReuseIDsImmediately PlayerIDReuse = 0
+	// NeverReuseIDs keeps the ID taken for good.
+	NeverReuseIDs PlayerIDReuse = -1
+)
+
+// ParsePlayerIDReuse reads "immediate", "never" or a duration such as "720h".
+func ParsePlayerIDReuse(value string) (PlayerIDReuse, error) {
+	switch value {
+	case "immediate":
+		return ReuseIDsImmediately, nil
+	case "never":
+		return NeverReuseIDs, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("expected \"immediate\", \"never\" or a duration, got %q", value)
+	}
+	return PlayerIDReuse(duration), nil
+}
+
+// ProfileUpdate holds the changes a player makes to its profile. Fields left
+// empty, or nil, are not changed.
+type ProfileUpdate struct {
+	Region string
+	// An empty name removes the display name.
+	DisplayName *string
+}
+
+// UpdateProfile applies the changes a player makes to its own profile. The
+// region can't be changed from inside a room, since the player already counts
+// towards the trends and region filters of the old one.
+func (b *BusinessLogic) UpdateProfile(ctx context.Context, playerID string, update ProfileUpdate) (*models.Player, error) {
+	if update.Region != "" {
+		var err error
+		if update.Region, err = b.checkRegion(update.Region); err != nil {
+			return nil, err
+		}
+	}
+	var displayName string
+	if update.DisplayName != nil {
+		var err error
+		if displayName, err = normalizeDisplayName(*update.DisplayName); err != nil {
+			return nil, err
+		}
+	}
+	//	Check if player exists
+	player, err := b.storage.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	changeRegion := update.Region != "" && update.Region != player.Region
+	if changeRegion && len(player.Room) != 0 {
+		return nil, fmt.Errorf("%w: leave the room before changing region", errormanagement.PlayerOccupied)
+	}
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		var domainEvents []storage.DomainEvent
+		if changeRegion {
+			if err := b.storage.UpdatePlayerRegion(ctx, playerID, update.Region); err != nil {
+				return change{}, err
+			}
+			domainEvents = append(domainEvents, storage.DomainEvent{Type: RegionChanged, PlayerID: playerID, Region: update.Region})
+		}
+		if update.DisplayName != nil {
+			if err := b.storage.UpdatePlayerDisplayName(ctx, playerID, displayName); err != nil {
+				return change{}, err
+			}
+			domainEvents = append(domainEvents, storage.DomainEvent{Type: DisplayNameChanged, PlayerID: playerID, DisplayName: displayName})
+		}
+		return change{actor: actorOf(ctx, playerID), domainEvents: domainEvents}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.storage.GetPlayerByID(ctx, playerID)
+}
+
+// DeletePlayer deletes the account of a player. The player leaves its room
+// first, its friendships and events are dropped, and its ID stays taken for
+// as long as the PlayerIDReuse policy says. Its bans are kept, and the ID of
+// a banned player stays taken at least until the ban ends. Its chat messages
+// are deleted, and the domain event log, the moderation reports and the
+// webhook events keep its record under a pseudonym.
+func (b *BusinessLogic) DeletePlayer(ctx context.Context, playerID string) error {
+	//	Check if player exists
+	player, err := b.storage.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return err
+	}
+
+	if len(player.Room) != 0 {
+		if err := b.LeaveRoom(ctx, playerID); err != nil && !errors.Is(err, errormanagement.PlayerIdle) {
+			return err
+		}
+	}
+
+	// Scrub the chat while the account can still be found, so that a failure
+	// can be retried. The rest goes in one transaction.
+	if err := b.chat.Forget(ctx, playerID); err != nil {
+		return err
+	}
+	pseudonym, err := newPseudonym()
+	if err != nil {
+		return err
+	}
+	actor := actorOf(ctx, playerID)
+	if actor.Type == storage.ActorPlayer && actor.ID == playerID {
+		actor.ID = pseudonym
+	}
+
+	err = b.commit(ctx, func(ctx context.Context) (change, error) {
+		if err := b.domainEvents.PseudonymizePlayer(ctx, playerID, pseudonym); err != nil {
+			return change{}, err
+		}
+		if err := b.moderation.PseudonymizePlayer(ctx, playerID, pseudonym); err != nil {
+			return change{}, err
+		}
+		if err := b.webhooks.PseudonymizePlayer(ctx, playerID, pseudonym); err != nil {
+			return change{}, err
+		}
+		// Retire the ID before deleting the player, so it is never free in between.
+		ban, err := b.moderation.ActiveBan(ctx, playerID, time.Now())
+		if err != nil {
+			return change{}, err
+		}
+		if retire, reusableAt := b.idRetirement(ban); retire {
+			if err := b.storage.RetirePlayerID(ctx, playerID, reusableAt); err != nil {
+				return change{}, err
+			}
+		}
+		if err := b.storage.DeletePlayer(ctx, playerID); err != nil {
+			return change{}, err
+		}
+		if err := b.storage.DeleteFriendshipsOf(ctx, playerID); err != nil {
+			return change{}, err
+		}
+		if err := b.storage.DeleteBlocksOf(ctx, playerID); err != nil {
+			return change{}, err
+		}
+		if err := b.moderation.DeleteMatchesOf(ctx, playerID); err != nil {
+			return change{}, err
+		}
+		return change{
+			actor:        actor,
+			domainEvents: []storage.DomainEvent{{Type: PlayerDeleted, PlayerID: pseudonym}},
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The account is gone either way; the event log is trimmed over time regardless.
+	if err := b.events.Forget(ctx, playerID); err != nil {
+		log.Println("Failed to remove the events of", playerID, "from the event log:", err)
+	}
+	return nil
+}
+
+// Helper function to tell whether the ID of a deleted player is retired, and
+// until when; a zero time retires it for good. A player under a ban can't
+// shed it by registering the ID again, so the ID is retired at least until
+// the ban ends, whatever the PlayerIDReuse policy.
+func (b *BusinessLogic) idRetirement(ban *storage.Ban) (bool, time.Time) {
+	retire := b.idReuse != ReuseIDsImmediately
+	var reusableAt time.Time
+	if retire && b.idReuse != NeverReuseIDs {
+		reusableAt = time.Now().Add(time.Duration(b.idReuse))
+	}
+	if ban == nil {
+		return retire, reusableAt
+	}
+	switch {
+	case ban.ExpiresAt.IsZero():
+		reusableAt = time.Time{}
+	case !retire || (!reusableAt.IsZero() && ban.ExpiresAt.After(reusableAt)):
+		reusableAt = ban.ExpiresAt
+	}
+	return true, reusableAt
+}
+
+// Helper function to trim a display name and check that it is printable and
+// at most MaxDisplayNameLength characters long.
+func normalizeDisplayName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > MaxDisplayNameLength {
+		return "", fmt.Errorf("%w: display_name must be at most %d characters long", errormanagement.InvalidParameter, MaxDisplayNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("%w: display_name must only contain printable characters", errormanagement.InvalidParameter)
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIDRetirementOfBannedPlayers(t *testing.T) {
+	day := 24 * time.Hour
+	inAWeek := time.Now().Add(7 * day)
+	inAYear := time.Now().Add(365 * day)
+
+	tests := []struct {
+		name       string
+		idReuse    PlayerIDReuse
+		ban        *storage.Ban
+		retire     bool
+		reusableAt time.Time
+		// Policies relative to now are checked against this duration instead.
+		reusableIn time.Duration
+	}{
+		{name: "immediate without ban", idReuse: ReuseIDsImmediately, retire: false},
+		{name: "never without ban", idReuse: NeverReuseIDs, retire: true},
+		{name: "duration without ban", idReuse: PlayerIDReuse(30 * day), retire: true, reusableIn: 30 * day},
+		{name: "immediate with timed ban", idReuse: ReuseIDsImmediately, ban: &storage.Ban{ExpiresAt: inAWeek}, retire: true, reusableAt: inAWeek},
+		{name: "immediate with permanent ban", idReuse: ReuseIDsImmediately, ban: &storage.Ban{}, retire: true},
+		{name: "duration outlasting the ban", idReuse: PlayerIDReuse(30 * day), ban: &storage.Ban{ExpiresAt: inAWeek}, retire: true, reusableIn: 30 * day},
+		{name: "ban outlasting the duration", idReuse: PlayerIDReuse(30 * day), ban: &storage.Ban{ExpiresAt: inAYear}, retire: true, reusableAt: inAYear},
+		{name: "duration with permanent ban", idReuse: PlayerIDReuse(30 * day), ban: &storage.Ban{}, retire: true},
+		{name: "never with timed ban", idReuse: NeverReuseIDs, ban: &storage.Ban{ExpiresAt: inAWeek}, retire: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &BusinessLogic{idReuse: test.idReuse}
+			retire, reusableAt := b.idRetirement(test.ban)
+			if retire != test.retire {
+				t.Fatalf("retire = %v, want %v", retire, test.retire)
+			}
+			want := test.reusableAt
+			if test.reusableIn != 0 {
+				want = time.Now().Add(test.reusableIn)
+			}
+			if want.IsZero() != reusableAt.IsZero() || reusableAt.Sub(want).Abs() > time.Minute {
+				t.Fatalf("reusableAt = %v, want %v", reusableAt, want)
+			}
+		})
+	}
+}
+
+func TestDeletePlayerPseudonymizesReportsAndWebhooks(t *testing.T) {
+	b, database := newTestLogic(t)
+	ctx := context.Background()
+	createTestPlayers(t, b, "Furious", "Valiant", "Brave")
+	createTestRoom(t, b, "Furious", "Valiant", "Brave")
+
+	for _, report := range []struct{ reporter, reported, text string }{
+		{"Furious", "Valiant", "Valiant never misses"},
+		{"Valiant", "Furious", "Furious keeps griefing"},
+		{"Brave", "Valiant", "Valiant and Furious are teaming up"},
+	} {
+		if _, err := b.ReportPlayer(ctx, report.reporter, report.reported, "cheating", report.text); err != nil {
+			t.Fatalf("ReportPlayer(%s, %s): %v", report.reporter, report.reported, err)
+		}
+	}
+
+	// A delivery of the room's creation gave up on its webhook.
+	webhookStorage := storage.NewWebhookStorage(database.Collection("webhooks"), database.Collection("webhookoutbox"), database.Collection("webhookdeliveries"))
+	event, err := webhookStorage.ClaimOutboxEvent(ctx, time.Minute)
+	if err != nil || event == nil {
+		t.Fatalf("ClaimOutboxEvent = %v, %v", event, err)
+	}
+	dead := storage.WebhookDelivery{ID: "d1", WebhookID: "w1", EventID: event.ID, EventType: event.Type, Body: event.Body, Players: event.Players, State: storage.DeliveryDead}
+	if err := webhookStorage.FanOut(ctx, event, []storage.WebhookDelivery{dead}); err != nil {
+		t.Fatalf("FanOut: %v", err)
+	}
+
+	if err := b.DeletePlayer(ctx, "Furious"); err != nil {
+		t.Fatalf("DeletePlayer: %v", err)
+	}
+
+	reports, err := b.moderation.ListReports(ctx, storage.ReportFilter{})
+	if err != nil {
+		t.Fatalf("ListReports: %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("%d reports are left, want 3", len(reports))
+	}
+	for _, report := range reports {
+		fields := append([]string{report.Reporter, report.Reported, report.Text, report.Note}, report.Room.Players...)
+		if strings.Contains(strings.Join(fields, " "), "Furious") {
+			t.Fatalf("report %+v still names the deleted player", report)
+		}
+		switch report.Reporter {
+		case "Valiant":
+			if !strings.HasPrefix(report.Reported, "deleted-") || !strings.HasPrefix(report.Text, report.Reported+" ") {
+				t.Fatalf("report about the deleted player = %+v, want it under their pseudonym", report)
+			}
+		case "Brave":
+			if !strings.Contains(report.Text, "deleted-") {
+				t.Fatalf("report mentioning the deleted player = %q, want the pseudonym in its text", report.Text)
+			}
+		default:
+			if !strings.HasPrefix(report.Reporter, "deleted-") || report.Text != "" {
+				t.Fatalf("report of the deleted player = %+v, want it under their pseudonym without text", report)
+			}
+		}
+	}
+
+	for _, name := range []string{"webhookoutbox", "webhookdeliveries"} {
+		cursor, err := database.Collection(name).Find(ctx, bson.M{})
+		if err != nil {
+			t.Fatalf("Find(%s): %v", name, err)
+		}
+		var documents []struct {
+			Body    string   `bson:"body"`
+			Players []string `bson:"players"`
+		}
+		if err := cursor.All(ctx, &documents); err != nil {
+			t.Fatalf("All(%s): %v", name, err)
+		}
+		if len(documents) == 0 {
+			t.Fatalf("%s is empty", name)
+		}
+		for _, document := range documents {
+			if strings.Contains(document.Body, "Furious") || strings.Contains(strings.Join(document.Players, " "), "Furious") {
+				t.Fatalf("%s still names the deleted player: %+v", name, document)
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/chat"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/storage"
+	"time"
+)
+
+// TrendsToProto converts a ranked trend list to its protobuf message.
+func TrendsToProto(trends []ModeTrend) *models.ModeTrendsResponse {
+	response := &models.ModeTrendsResponse{}
+	for _, trend := range trends {
+		response.Trends = append(response.Trends, &models.ModeTrend{
+			Rank:  int32(trend.Rank),
+			Mode:  trend.Mode,
+			Count: int32(trend.Count),
+			Share: trend.Share,
+		})
+	}
+	return response
+}
+
+// BreakdownToProto converts a region x mode breakdown to its protobuf message.
+func BreakdownToProto(breakdown *TrendBreakdown) *models.TrendBreakdown {
+	response := &models.TrendBreakdown{Total: int32(breakdown.Total), Modes: countsToProto(breakdown.Modes)}
+	for _, region := range breakdown.Regions {
+		response.Regions = append(response.Regions, &models.RegionBreakdown{
+			Region: region.Region,
+			Parent: region.Parent,
+			Total:  int32(region.Total),
+			Modes:  countsToProto(region.Modes),
+		})
+	}
+	return response
+}
+
+func countsToProto(counts map[string]int) map[string]int32 {
+	converted := make(map[string]int32, len(counts))
+	for key, count := range counts {
+		converted[key] = int32(count)
+	}
+	return converted
+}
+
+// RoomToProto converts a room to its protobuf message.
+func RoomToProto(room *RoomDetails) *models.RoomDetails {
+	details := &models.RoomDetails{
+		Id:        room.ID,
+		Mode:      room.Mode,
+		PlayerIds: room.PlayerIDs,
+		Capacity:  int32(room.Capacity),
+		FreeSlots: int32(room.FreeSlots),
+		State:     room.State,
+		Host:      room.Host,
+		Private:   room.Private,
+	}
+	if !room.CreatedAt.IsZero() {
+		details.CreatedAtUnixMs = room.CreatedAt.UnixMilli()
+	}
+	return details
+}
+
+// RoomPageToProto converts a page of rooms to its protobuf message.
+func RoomPageToProto(page *RoomPage) *models.RoomListResponse {
+	response := &models.RoomListResponse{Data: []*models.RoomDetails{}, NextCursor: page.NextCursor}
+	for i := range page.Rooms {
+		response.Data = append(response.Data, RoomToProto(&page.Rooms[i]))
+	}
+	return response
+}
+
+// RegionsToProto converts the region catalog to its protobuf message.
+func RegionsToProto(catalog []regions.Region) *models.RegionListResponse {
+	response := &models.RegionListResponse{Data: []*models.Region{}}
+	for _, region := range catalog {
+		response.Data = append(response.Data, &models.Region{
+			Code:    region.Code,
+			Name:    region.Name,
+			Parent:  region.Parent,
+			Enabled: region.Enabled,
+		})
+	}
+	return response
+}
+
+// PlayerToProto converts a stored player to its protobuf message.
+func PlayerToProto(player *models.Player) *models.PlayerDetails {
+	return &models.PlayerDetails{
+		Id:          player.Id,
+		Region:      player.Region,
+		Room:        player.Room,
+		DisplayName: player.DisplayName,
+	}
+}
+
+// FriendsToProto converts a friends list to its protobuf message.
+func FriendsToProto(friends []Friend) *models.FriendListResponse {
+	response := &models.FriendListResponse{Data: []*models.Friend{}}
+	for _, friend := range friends {
+		presence := &models.Presence{
+			Status:    friend.Presence.Status,
+			RoomId:    friend.Presence.RoomID,
+			Mode:      friend.Presence.Mode,
+			FreeSlots: int32(friend.Presence.FreeSlots),
+		}
+		if !friend.Presence.LastSeen.IsZero() {
+			presence.LastSeenAtUnixMs = friend.Presence.LastSeen.UnixMilli()
+		}
+		converted := &models.Friend{PlayerId: friend.PlayerID, DisplayName: friend.DisplayName, Presence: presence}
+		if !friend.Since.IsZero() {
+			converted.SinceUnixMs = friend.Since.UnixMilli()
+		}
+		response.Data = append(response.Data, converted)
+	}
+	return response
+}
+
+// FriendRequestsToProto converts a list of pending friend requests to its protobuf message.
+func FriendRequestsToProto(requests []FriendRequest) *models.FriendRequestListResponse {
+	response := &models.FriendRequestListResponse{Data: []*models.FriendRequest{}}
+	for _, request := range requests {
+		response.Data = append(response.Data, &models.FriendRequest{
+			PlayerId:        request.PlayerID,
+			Direction:       request.Direction,
+			CreatedAtUnixMs: request.CreatedAt.UnixMilli(),
+		})
+	}
+	return response
+}
+
+// BlockedPlayersToProto converts a block list to its protobuf message.
+func BlockedPlayersToProto(blocked []BlockedPlayer) *models.BlockListResponse {
+	response := &models.BlockListResponse{Data: []*models.BlockedPlayer{}}
+	for _, player := range blocked {
+		response.Data = append(response.Data, &models.BlockedPlayer{
+			PlayerId:        player.PlayerID,
+			BlockedAtUnixMs: player.BlockedAt.UnixMilli(),
+		})
+	}
+	return response
+}
+
+// ReportToProto converts a report of the moderation queue to its protobuf message.
+func ReportToProto(report *storage.Report) *models.Report {
+	message := &models.Report{
+		Id:         report.ID,
+		ReporterId: report.Reporter,
+		ReportedId: report.Reported,
+		Category:   report.Category,
+		Text:       report.Text,
+		Room: &models.ReportRoom{
+			Id:        report.Room.ID,
+			Mode:      report.Room.Mode,
+			PlayerIds: report.Room.Players,
+			Source:    report.Room.Source,
+		},
+		State:           report.State,
+		CreatedAtUnixMs: report.CreatedAt.UnixMilli(),
+		UpdatedAtUnixMs: report.UpdatedAt.UnixMilli(),
+		ReviewedBy:      report.ReviewedBy,
+		Note:            report.Note,
+	}
+	if message.Room.PlayerIds == nil {
+		message.Room.PlayerIds = []string{}
+	}
+	return message
+}
+
+// BanToProto converts a ban to its protobuf message.
+func BanToProto(ban *storage.Ban) *models.Ban {
+	message := &models.Ban{
+		Id:              ban.ID,
+		PlayerId:        ban.PlayerID,
+		Reason:          ban.Reason,
+		ReportIds:       ban.ReportIDs,
+		IssuedBy:        ban.IssuedBy,
+		CreatedAtUnixMs: ban.CreatedAt.UnixMilli(),
+		Permanent:       ban.ExpiresAt.IsZero(),
+		Active:          ban.LiftedAt.IsZero() && (ban.ExpiresAt.IsZero() || ban.ExpiresAt.After(time.Now())),
+	}
+	if message.ReportIds == nil {
+		message.ReportIds = []string{}
+	}
+	if !ban.ExpiresAt.IsZero() {
+		message.ExpiresAtUnixMs = ban.ExpiresAt.UnixMilli()
+	}
+	if !ban.LiftedAt.IsZero() {
+		message.LiftedAtUnixMs = ban.LiftedAt.UnixMilli()
+	}
+	return message
+}
+
+// ReportReceiptToProto converts a report to what its reporter gets to see of it.
+func ReportReceiptToProto(report *storage.Report) *models.ReportReceipt {
+	return &models.ReportReceipt{
+		Id:              report.ID,
+		Category:        report.Category,
+		State:           report.State,
+		CreatedAtUnixMs: report.CreatedAt.UnixMilli(),
+	}
+}
+
+// ChatHistoryToProto converts the chat history of a room to its protobuf message.
+func ChatHistoryToProto(messages []chat.Message) *models.ChatHistoryResponse {
+	response := &models.ChatHistoryResponse{Data: []*models.ChatMessage{}}
+	for i := range messages {
+		response.Data = append(response.Data, events.ChatMessageProto(&messages[i]))
+	}
+	return response
+}
+
+// DomainEventToProto converts an entry of the domain event log to its protobuf message.
+func DomainEventToProto(event *storage.DomainEvent) *models.DomainEvent {
+	return &models.DomainEvent{
+		Id:          event.ID,
+		Type:        event.Type,
+		PlayerId:    event.PlayerID,
+		RoomId:      event.RoomID,
+		Mode:        event.Mode,
+		Region:      event.Region,
+		Reason:      event.Reason,
+		ActorType:   event.Actor.Type,
+		ActorId:     event.Actor.ID,
+		AtUnixMs:    event.At.UnixMilli(),
+		DisplayName: event.DisplayName,
+		BanId:       event.BanID,
+	}
+}
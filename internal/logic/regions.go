@@ -0,0 +1,25 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/regions"
+	"fmt"
+)
+
+// ListRegions returns the region catalog, disabled regions included.
+func (b *BusinessLogic) ListRegions() []regions.Region {
+	return b.regions.All()
+}
+
+// Helper function to normalize a region code and check that players can
+// belong to the region.
+func (b *BusinessLogic) checkRegion(code string) (string, error) {
+	region, ok := b.regions.Lookup(code)
+	if !ok {
+		return "", fmt.Errorf("%w: %q is not in the region catalog", errormanagement.UnknownRegion, code)
+	}
+	if !region.Enabled {
+		return "", fmt.Errorf("%w: %s is not open to players", errormanagement.UnknownRegion, region.Code)
+	}
+	return region.Code, nil
+}
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/constants"
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"time"
+)
+
+// RoomDetails is the full representation of a room.
+type RoomDetails struct {
+	ID        string    `json:"id"`
+	Mode      string    `json:"mode"`
+	PlayerIDs []string  `json:"player_ids"`
+	Capacity  int       `json:"capacity"`
+	FreeSlots int       `json:"free_slots"`
+	State     string    `json:"state"`
+	Host      string    `json:"host"`
+	Private   bool      `json:"private"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (b *BusinessLogic) GetRoom(roomID string) (*RoomDetails, error) {
+	room, err := b.storage.GetRoomByID(context.Background(), roomID)
+	if err != nil {
+		return nil, err
+	}
+	return roomDetails(room), nil
+}
+
+func (b *BusinessLogic) GetPlayer(playerID string) (*models.Player, error) {
+	return b.storage.GetPlayerByID(context.Background(), playerID)
+}
+
+// ListRooms returns one page of the rooms of a mode matching the query.
+func (b *BusinessLogic) ListRooms(query RoomQuery) (*RoomPage, error) {
+	//	Check if mode is correct
+	if !isValidMode(query.Mode) {
+		return nil, errormanagement.InvalidMode
+	}
+	query.Region = regions.Normalize(query.Region)
+	filter, err := query.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every parameter is part of the key so that each query shape is cached on its own
+	cacheKey := "ListRooms:" + query.shape()
+
+	// Concurrent misses share a single storage query
+	var page RoomPage
+	err = b.cache.GetOrLoad(context.Background(), cacheKey, &page, b.cachePolicy, func(ctx context.Context) (interface{}, error) {
+		// One room more than asked for tells whether there is a next page
+		filter.Limit++
+		stored, err := b.storage.ListRooms(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded := RoomPage{Rooms: []RoomDetails{}}
+		if len(stored) > query.Limit {
+			stored = stored[:query.Limit]
+			loaded.NextCursor = encodeRoomCursor(roomCursor{
+				Sort:       query.Sort,
+				Descending: query.Descending,
+				After:      storage.Position(stored[len(stored)-1], filter.SortBy),
+			})
+		}
+		for _, room := range stored {
+			loaded.Rooms = append(loaded.Rooms, *roomDetails(room))
+		}
+		return loaded, nil
+	}, roomListTag(query.Mode))
+
+	return &page, err
+}
+
+func roomDetails(room *models.Room) *RoomDetails {
+	mode := constants.ParseMode(room.Mode)
+	details := &RoomDetails{
+		ID:        room.Id,
+		Mode:      room.Mode,
+		PlayerIDs: room.PlayerIds,
+		Capacity:  constants.RoomLimit(mode),
+		State:     constants.RoomState(mode, len(room.PlayerIds)),
+		Private:   room.Private,
+	}
+	// Rooms stored before creation times were recorded have none
+	if room.CreatedAt != 0 {
+		details.CreatedAt = time.UnixMilli(room.CreatedAt)
+	}
+	if details.PlayerIDs == nil {
+		details.PlayerIDs = []string{}
+	}
+	details.FreeSlots = details.Capacity - len(room.PlayerIds)
+	if details.FreeSlots < 0 {
+		details.FreeSlots = 0
+	}
+	if len(room.PlayerIds) > 0 {
+		details.Host = room.PlayerIds[0]
+	}
+	return details
+}
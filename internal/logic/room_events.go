@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/constants"
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/events"
+	"context"
+	"time"
+)
+
+// CheckRoomSubscription makes sure a player may follow the events of a room,
+// which only its members can.
+func (b *BusinessLogic) CheckRoomSubscription(playerID string, roomID string) error {
+	//	Check if player exists
+	player, err := b.storage.GetPlayerByID(context.Background(), playerID)
+	if err != nil {
+		return err
+	}
+	//	Check if room exists
+	if _, err := b.storage.GetRoomByID(context.Background(), roomID); err != nil {
+		return err
+	}
+	if player.Room != roomID {
+		return errormanagement.NotInRoom
+	}
+	return nil
+}
+
+// CheckLobbySubscription makes sure a player may follow the room list of a
+// mode, which any registered player can.
+func (b *BusinessLogic) CheckLobbySubscription(playerID string, mode string) error {
+	//	Check if mode is valid
+	if !isValidMode(mode) {
+		return errormanagement.InvalidMode
+	}
+	//	Check if player exists
+	_, err := b.storage.GetPlayerByID(context.Background(), playerID)
+	return err
+}
+
+// newRoomEvent describes a room with the given members, right after a change,
+// to the subscribers of the room and of its mode's lobby.
+func newRoomEvent(eventType events.Type, roomID string, mode string, playerID string, members []string) events.Event {
+	event := events.Event{
+		Type:        eventType,
+		RoomID:      roomID,
+		Mode:        mode,
+		PlayerID:    playerID,
+		PlayerCount: len(members),
+		Capacity:    constants.RoomLimit(constants.ParseMode(mode)),
+		Timestamp:   time.Now().UTC(),
+	}
+	if len(members) > 0 {
+		event.Host = members[0]
+		event.State = constants.RoomState(constants.ParseMode(mode), len(members))
+	}
+	return event
+}
+
+// Helper function to list the members of a room without the given player.
+func withoutPlayer(members []string, playerID string) []string {
+	remaining := make([]string, 0, len(members))
+	for _, member := range members {
+		if member != playerID {
+			remaining = append(remaining, member)
+		}
+	}
+	return remaining
+}
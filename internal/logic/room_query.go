@@ -0,0 +1,164 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/constants"
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/storage"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	SortByAge       = "age"
+	SortByOccupancy = "occupancy"
+
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+	VisibilityAll     = "all"
+
+	DefaultRoomLimit = 50
+	MaxRoomLimit     = 200
+)
+
+// RoomQuery selects a page of the rooms of a mode. Apart from the mode every
+// field is optional.
+type RoomQuery struct {
+	Mode string
+	// Only rooms with at least one free slot.
+	FreeSlots bool
+	// constants.RoomOpen or constants.RoomFull; both when empty.
+	State string
+	// Only rooms with at least one member from this region.
+	Region string
+	// VisibilityPublic when empty, VisibilityPrivate or VisibilityAll.
+	Visibility   string
+	CreatedAfter time.Time
+	// SortByAge when empty, or SortByOccupancy.
+	Sort       string
+	Descending bool
+	// DefaultRoomLimit when zero.
+	Limit int
+	// NextCursor of the previous page.
+	Cursor string
+}
+
+// RoomPage is one page of a room listing. NextCursor is empty on the last page.
+type RoomPage struct {
+	Rooms      []RoomDetails `json:"rooms"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// roomCursor records where a page ended. It also carries the ordering so that
+// a cursor cannot be used to continue a listing sorted differently.
+type roomCursor struct {
+	Sort       string               `json:"sort"`
+	Descending bool                 `json:"desc"`
+	After      storage.RoomPosition `json:"after"`
+}
+
+// filter fills in the defaults of the query and translates it to a storage
+// filter.
+func (q *RoomQuery) filter() (storage.RoomFilter, error) {
+	filter := storage.RoomFilter{
+		Mode:       q.Mode,
+		Region:     q.Region,
+		Descending: q.Descending,
+	}
+	capacity := constants.RoomLimit(constants.ParseMode(q.Mode))
+
+	if q.FreeSlots {
+		filter.MaxPlayers = capacity
+	}
+	switch q.State {
+	case "":
+	case constants.RoomOpen:
+		filter.MaxPlayers = capacity
+	case constants.RoomFull:
+		filter.MinPlayers = capacity
+	default:
+		return filter, fmt.Errorf("%w: state must be %q or %q", errormanagement.InvalidParameter, constants.RoomOpen, constants.RoomFull)
+	}
+
+	if q.Visibility == "" {
+		q.Visibility = VisibilityPublic
+	}
+	switch q.Visibility {
+	case VisibilityPublic, VisibilityPrivate:
+		private := q.Visibility == VisibilityPrivate
+		filter.Private = &private
+	case VisibilityAll:
+	default:
+		return filter, fmt.Errorf("%w: visibility must be %q, %q or %q", errormanagement.InvalidParameter, VisibilityPublic, VisibilityPrivate, VisibilityAll)
+	}
+
+	if !q.CreatedAfter.IsZero() {
+		filter.CreatedAfter = q.CreatedAfter.UnixMilli()
+	}
+
+	if q.Sort == "" {
+		q.Sort = SortByAge
+	}
+	switch q.Sort {
+	case SortByAge:
+		filter.SortBy = storage.SortRoomsByAge
+	case SortByOccupancy:
+		filter.SortBy = storage.SortRoomsByOccupancy
+	default:
+		return filter, fmt.Errorf("%w: sort must be %q or %q", errormanagement.InvalidParameter, SortByAge, SortByOccupancy)
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultRoomLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxRoomLimit {
+		return filter, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, MaxRoomLimit)
+	}
+	filter.Limit = q.Limit
+
+	if q.Cursor != "" {
+		cursor, err := decodeRoomCursor(q.Cursor)
+		if err != nil || cursor.Sort != q.Sort || cursor.Descending != q.Descending {
+			return filter, fmt.Errorf("%w: cursor does not belong to this listing", errormanagement.InvalidParameter)
+		}
+		filter.After = &cursor.After
+	}
+	return filter, nil
+}
+
+// shape is a canonical encoding of every parameter of the query, once its
+// defaults are filled in.
+func (q *RoomQuery) shape() string {
+	values := url.Values{}
+	values.Set("mode", q.Mode)
+	values.Set("free_slots", strconv.FormatBool(q.FreeSlots))
+	values.Set("state", q.State)
+	values.Set("region", q.Region)
+	values.Set("visibility", q.Visibility)
+	values.Set("sort", q.Sort)
+	values.Set("desc", strconv.FormatBool(q.Descending))
+	values.Set("limit", strconv.Itoa(q.Limit))
+	values.Set("cursor", q.Cursor)
+	if !q.CreatedAfter.IsZero() {
+		values.Set("created_after", strconv.FormatInt(q.CreatedAfter.UnixMilli(), 10))
+	}
+	return values.Encode()
+}
+
+func encodeRoomCursor(cursor roomCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeRoomCursor(encoded string) (roomCursor, error) {
+	var cursor roomCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
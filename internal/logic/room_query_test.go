@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/storage"
+	"errors"
+	"testing"
+)
+
+func TestRoomCursorRoundTrip(t *testing.T) {
+	cursor := roomCursor{
+		Sort:       SortByOccupancy,
+		Descending: true,
+		After:      storage.RoomPosition{Value: 3, ID: "dfjlnas"},
+	}
+	query := RoomQuery{Mode: "mayhem", Sort: SortByOccupancy, Descending: true, Cursor: encodeRoomCursor(cursor)}
+
+	filter, err := query.filter()
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+	if filter.After == nil || *filter.After != cursor.After {
+		t.Fatalf("After = %v, want %v", filter.After, cursor.After)
+	}
+	if filter.SortBy != storage.SortRoomsByOccupancy || !filter.Descending {
+		t.Fatalf("filter sorts by %q descending %t, want by occupancy descending", filter.SortBy, filter.Descending)
+	}
+}
+
+func TestRoomCursorOfAnotherListing(t *testing.T) {
+	ageCursor := encodeRoomCursor(roomCursor{Sort: SortByAge, After: storage.RoomPosition{Value: 1700000000000, ID: "dfjlnas"}})
+
+	tests := []struct {
+		name  string
+		query RoomQuery
+	}{
+		// The default sort is by age, ascending.
+		{"other sort", RoomQuery{Mode: "mayhem", Sort: SortByOccupancy, Cursor: ageCursor}},
+		{"other order", RoomQuery{Mode: "mayhem", Descending: true, Cursor: ageCursor}},
+		{"not a cursor", RoomQuery{Mode: "mayhem", Cursor: "not-a-cursor"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.query.filter(); !errors.Is(err, errormanagement.InvalidParameter) {
+				t.Fatalf("filter = %v, want InvalidParameter", err)
+			}
+		})
+	}
+
+	query := RoomQuery{Mode: "mayhem", Cursor: ageCursor}
+	if _, err := query.filter(); err != nil {
+		t.Fatalf("filter with the cursor of the same listing: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package logic
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	// AllRegions selects the global trends instead of a single region.
+	AllRegions = "*"
+
+	DefaultTrendLimit = 3
+	MaxTrendLimit     = 50
+)
+
+// TrendQuery narrows down a trend request.
+type TrendQuery struct {
+	Region string
+	Mode   string
+	Limit  int
+}
+
+// ModeTrend is one entry of a ranked trend response.
+type ModeTrend struct {
+	Rank  int     `json:"rank"`
+	Mode  string  `json:"mode"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// RegionBreakdown holds the per-mode player counts of a single region.
+type RegionBreakdown struct {
+	Region string `json:"region"`
+	// Parent is empty for top-level regions and regions missing from the catalog.
+	Parent string         `json:"parent,omitempty"`
+	Total  int            `json:"total"`
+	Modes  map[string]int `json:"modes"`
+}
+
+// TrendBreakdown is the region x mode matrix served to the ops dashboard.
+type TrendBreakdown struct {
+	Total   int               `json:"total"`
+	Modes   map[string]int    `json:"modes"`
+	Regions []RegionBreakdown `json:"regions"`
+}
+
+// GetModeTrendsByRegion ranks the modes played in a region. The trends of a
+// region with regions under it in the catalog include theirs.
+func (b *BusinessLogic) GetModeTrendsByRegion(query TrendQuery) ([]ModeTrend, error) {
+	if query.Mode != "" && !isValidMode(query.Mode) {
+		return nil, errormanagement.InvalidMode
+	}
+	if query.Region != AllRegions {
+		query.Region = regions.Normalize(query.Region)
+	}
+
+	counters, err := b.getTrendCounters(query.Region)
+	if err != nil {
+		return nil, err
+	}
+	return rankTrends(counters, query), nil
+}
+
+func (b *BusinessLogic) GetModeTrendsByPlayerRegion(playerId string, query TrendQuery) ([]ModeTrend, error) {
+	//	Check if player exists
+	player, err := b.storage.GetPlayerByID(context.Background(), playerId)
+	if err != nil {
+		return nil, err
+	}
+	query.Region = player.Region
+	return b.GetModeTrendsByRegion(query)
+}
+
+func (b *BusinessLogic) GetTrendBreakdown() (*TrendBreakdown, error) {
+	counters, err := b.getTrendCounters(AllRegions)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &TrendBreakdown{Modes: make(map[string]int), Regions: []RegionBreakdown{}}
+	regionIndex := make(map[string]int)
+	for _, counter := range counters {
+		i, ok := regionIndex[counter.Region]
+		if !ok {
+			i = len(breakdown.Regions)
+			regionIndex[counter.Region] = i
+			region, _ := b.regions.Lookup(counter.Region)
+			breakdown.Regions = append(breakdown.Regions, RegionBreakdown{Region: counter.Region, Parent: region.Parent, Modes: make(map[string]int)})
+		}
+		breakdown.Regions[i].Modes[counter.Mode] += counter.Count
+		breakdown.Regions[i].Total += counter.Count
+		breakdown.Modes[counter.Mode] += counter.Count
+		breakdown.Total += counter.Count
+	}
+
+	sort.Slice(breakdown.Regions, func(i, j int) bool {
+		return breakdown.Regions[i].Region < breakdown.Regions[j].Region
+	})
+	return breakdown, nil
+}
+
+// getTrendCounters reads the raw counters of a region and the regions under
+// it (or of every region for AllRegions) through the cache.
+func (b *BusinessLogic) getTrendCounters(region string) ([]storage.TrendCounter, error) {
+	cacheKey := fmt.Sprintf("GetModesTrendByRegion:%s", region)
+
+	// A roll-up goes stale with any of the regions it covers
+	var storageRegions []string
+	tags := []string{allTrendsTag, trendTag(region)}
+	if region != AllRegions {
+		storageRegions = b.regions.Subtree(region)
+		for _, covered := range storageRegions[1:] {
+			tags = append(tags, trendTag(covered))
+		}
+	}
+
+	var counters []storage.TrendCounter
+	err := b.cache.GetOrLoad(context.Background(), cacheKey, &counters, b.cachePolicy, func(ctx context.Context) (interface{}, error) {
+		return b.storage.GetTrendCounters(storageRegions)
+	}, tags...)
+
+	return counters, err
+}
+
+// rankTrends sums the counters per mode, ranks the modes by player count and
+// applies the mode filter and limit of the query.
+func rankTrends(counters []storage.TrendCounter, query TrendQuery) []ModeTrend {
+	total := 0
+	perMode := make(map[string]int)
+	for _, counter := range counters {
+		perMode[counter.Mode] += counter.Count
+		total += counter.Count
+	}
+
+	trends := make([]ModeTrend, 0, len(perMode))
+	for mode, count := range perMode {
+		trends = append(trends, ModeTrend{Mode: mode, Count: count, Share: float64(count) / float64(total)})
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Count != trends[j].Count {
+			return trends[i].Count > trends[j].Count
+		}
+		return trends[i].Mode < trends[j].Mode
+	})
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultTrendLimit
+	}
+	ranked := []ModeTrend{}
+	for i := range trends {
+		trends[i].Rank = i + 1
+		if query.Mode != "" && trends[i].Mode != query.Mode {
+			continue
+		}
+		ranked = append(ranked, trends[i])
+		if len(ranked) == limit {
+			break
+		}
+	}
+	return ranked
+}
+
+// RunTrendReconciler periodically rebuilds the live trend counters from the
+// players and rooms collections until ctx is cancelled.
+func (b *BusinessLogic) RunTrendReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.storage.RebuildTrendCounters(ctx); err != nil {
+				log.Println("Failed to rebuild trend counters:", err)
+				continue
+			}
+			b.cache.Invalidate(ctx, allTrendsTag)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package regions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Region is one entry of the catalog. A region may sit under a parent, such
+// as a continent, whose trends then include it.
+type Region struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Parent string `json:"parent,omitempty"`
+	// Players can only register in, or move to, enabled regions.
+	Enabled bool `json:"enabled"`
+}
+
+// Catalog holds the regions players can belong to. It is read once on start
+// and never changes afterwards.
+type Catalog struct {
+	regions  []Region
+	byCode   map[string]int
+	children map[string][]string
+}
+
+// Normalize turns a region code into the form it is stored in.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Load reads a catalog from a JSON file holding a list of regions.
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Code    string `json:"code"`
+		Name    string `json:"name"`
+		Parent  string `json:"parent"`
+		Enabled *bool  `json:"enabled"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	regions := make([]Region, 0, len(entries))
+	for _, entry := range entries {
+		// Regions are enabled unless they say otherwise.
+		enabled := entry.Enabled == nil || *entry.Enabled
+		regions = append(regions, Region{Code: entry.Code, Name: entry.Name, Parent: entry.Parent, Enabled: enabled})
+	}
+	return New(regions)
+}
+
+// New builds a catalog, normalizing the codes. Codes must be unique and made
+// of letters and digits, and every parent must be in the catalog without
+// forming a cycle.
+func New(regions []Region) (*Catalog, error) {
+	catalog := &Catalog{byCode: make(map[string]int), children: make(map[string][]string)}
+	for _, region := range regions {
+		region.Code = Normalize(region.Code)
+		region.Parent = Normalize(region.Parent)
+		if region.Code == "" || strings.IndexFunc(region.Code, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) >= 0 {
+			return nil, fmt.Errorf("invalid region code %q", region.Code)
+		}
+		if _, ok := catalog.byCode[region.Code]; ok {
+			return nil, fmt.Errorf("region %s is listed twice", region.Code)
+		}
+		if region.Name == "" {
+			region.Name = region.Code
+		}
+		catalog.byCode[region.Code] = len(catalog.regions)
+		catalog.regions = append(catalog.regions, region)
+	}
+
+	for _, region := range catalog.regions {
+		if region.Parent == "" {
+			continue
+		}
+		if _, ok := catalog.byCode[region.Parent]; !ok {
+			return nil, fmt.Errorf("region %s has unknown parent %s", region.Code, region.Parent)
+		}
+		catalog.children[region.Parent] = append(catalog.children[region.Parent], region.Code)
+	}
+	for _, region := range catalog.regions {
+		seen := map[string]bool{region.Code: true}
+		for parent := region.Parent; parent != ""; parent = catalog.regions[catalog.byCode[parent]].Parent {
+			if seen[parent] {
+				return nil, fmt.Errorf("region %s is its own ancestor", region.Code)
+			}
+			seen[parent] = true
+		}
+	}
+
+	sort.Slice(catalog.regions, func(i, j int) bool {
+		return catalog.regions[i].Code < catalog.regions[j].Code
+	})
+	for i, region := range catalog.regions {
+		catalog.byCode[region.Code] = i
+	}
+	return catalog, nil
+}
+
+// All returns every region, ordered by code.
+func (c *Catalog) All() []Region {
+	return append([]Region(nil), c.regions...)
+}
+
+// Lookup finds a region by code, which needn't be normalized.
+func (c *Catalog) Lookup(code string) (Region, bool) {
+	i, ok := c.byCode[Normalize(code)]
+	if !ok {
+		return Region{}, false
+	}
+	return c.regions[i], true
+}
+
+// Subtree returns the code of a region followed by those of every region
+// under it, at any depth.
+func (c *Catalog) Subtree(code string) []string {
+	code = Normalize(code)
+	subtree := []string{code}
+	for i := 0; i < len(subtree); i++ {
+		subtree = append(subtree, c.children[subtree[i]]...)
+	}
+	return subtree
+}
@@ -0,0 +1,88 @@
+package regions
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	catalog, err := New([]Region{
+		{Code: "blr", Name: "Bengaluru", Parent: " apac"},
+		{Code: "APAC", Name: "Asia Pacific"},
+		{Code: "sgp", Parent: "APAC", Enabled: true},
+		{Code: "KA", Parent: "BLR"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var codes []string
+	for _, region := range catalog.All() {
+		codes = append(codes, region.Code)
+	}
+	if want := []string{"APAC", "BLR", "KA", "SGP"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("All = %v, want %v", codes, want)
+	}
+
+	region, ok := catalog.Lookup(" blr ")
+	if !ok || region.Parent != "APAC" || region.Name != "Bengaluru" {
+		t.Errorf("Lookup(blr) = %+v, %t, want Bengaluru under APAC", region, ok)
+	}
+	if region, _ := catalog.Lookup("SGP"); region.Name != "SGP" || !region.Enabled {
+		t.Errorf("Lookup(SGP) = %+v, want an enabled region named after its code", region)
+	}
+	if _, ok := catalog.Lookup("LHR"); ok {
+		t.Error("Lookup found a region that isn't in the catalog")
+	}
+
+	subtree := catalog.Subtree("apac")
+	sort.Strings(subtree[1:])
+	if want := []string{"APAC", "BLR", "KA", "SGP"}; !reflect.DeepEqual(subtree, want) {
+		t.Errorf("Subtree(apac) = %v, want %v", subtree, want)
+	}
+	if subtree := catalog.Subtree("SGP"); !reflect.DeepEqual(subtree, []string{"SGP"}) {
+		t.Errorf("Subtree(SGP) = %v, want only SGP", subtree)
+	}
+}
+
+func TestNewRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []Region
+	}{
+		{"an empty code", []Region{{Code: " "}}},
+		{"a code with punctuation", []Region{{Code: "US-EAST"}}},
+		{"a code listed twice", []Region{{Code: "BLR"}, {Code: "blr"}}},
+		{"an unknown parent", []Region{{Code: "BLR", Parent: "APAC"}}},
+		{"a region under itself", []Region{{Code: "BLR", Parent: "BLR"}}},
+		{"a cycle", []Region{{Code: "A", Parent: "C"}, {Code: "B", Parent: "A"}, {Code: "C", Parent: "B"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := New(test.regions); err == nil {
+				t.Fatal("New accepted the catalog")
+			}
+		})
+	}
+}
+
+func TestLoadEnablesRegionsByDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.json")
+	data := `[{"code": "BLR", "name": "Bengaluru"}, {"code": "SGP", "enabled": false}]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	catalog, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if region, _ := catalog.Lookup("BLR"); !region.Enabled {
+		t.Error("BLR is disabled, want enabled")
+	}
+	if region, _ := catalog.Lookup("SGP"); region.Enabled {
+		t.Error("SGP is enabled, want disabled")
+	}
+}
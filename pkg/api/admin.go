@@ -0,0 +1,240 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// AuthenticateService resolves the X-API-Key header of a request to the key
+// of a service and records the request in the key's audit trail once it has
+// been answered. Requests without a valid key are rejected.
+func (a *APIHandlers) AuthenticateService(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fullKey := r.Header.Get("X-API-Key")
+		if fullKey == "" {
+			writeError(w, r, fmt.Errorf("%w: send an API key in the X-API-Key header", errormanagement.Unauthorized))
+			return
+		}
+		key, err := a.APIKeys.Authenticate(r.Context(), fullKey)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(auth.WithAPIKey(r.Context(), key)))
+
+		entry := storage.AuditEntry{
+			KeyID:      key.ID,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     recorder.status,
+			RemoteAddr: r.RemoteAddr,
+			At:         time.Now().UTC(),
+		}
+		// The request may already be cancelled; the audit trail must be written regardless.
+		if err := a.APIKeys.RecordUse(context.Background(), entry); err != nil {
+			log.Println("Failed to record the use of API key", key.ID+":", err)
+		}
+	})
+}
+
+// requireScope rejects requests whose API key lacks the scope.
+func requireScope(scope string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, ok := auth.APIKeyFromContext(r.Context())
+		if !ok {
+			writeError(w, r, errormanagement.Unauthorized)
+			return
+		}
+		if !auth.HasScope(key, scope) {
+			writeError(w, r, fmt.Errorf("%w: %s is required", errormanagement.MissingScope, scope))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// statusRecorder remembers the status of a response for the audit trail.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func (a *APIHandlers) CloseRoomHandler(w http.ResponseWriter, r *http.Request) {
+	// Close Room via Business
+	if err := a.Logic.CloseRoom(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) KickPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	// Remove player from Room via Business
+	if err := a.Logic.KickPlayer(r.Context(), mux.Vars(r)["id"], mux.Vars(r)["playerId"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) GetPlayerAdminHandler(w http.ResponseWriter, r *http.Request) {
+	// Get Player via Business
+	player, err := a.Logic.GetPlayer(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.PlayerResponse{Data: logic.PlayerToProto(player)}, nil)
+}
+
+func (a *APIHandlers) UpdatePlayerAdminHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.UpdatePlayerRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Update Player via Business. Unlike players, services can move a player between regions mid-game.
+	playerID := mux.Vars(r)["id"]
+	if request.Region != "" {
+		if _, err := a.Logic.UpdatePlayerRegion(r.Context(), playerID, request.Region); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	player, err := a.Logic.UpdateProfile(r.Context(), playerID, logic.ProfileUpdate{DisplayName: request.DisplayName})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.PlayerResponse{Data: logic.PlayerToProto(player)}, nil)
+}
+
+func (a *APIHandlers) IssuePlayerSecretHandler(w http.ResponseWriter, r *http.Request) {
+	// Issue Player Secret via Business; the sessions of the old secret end with it.
+	playerID := mux.Vars(r)["id"]
+	secret, err := a.Logic.IssuePlayerSecret(r.Context(), playerID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := a.Sessions.RevokePlayer(r.Context(), playerID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	player, err := a.Logic.GetPlayer(playerID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.PlayerResponse{Data: logic.PlayerToProto(player), Secret: secret}, nil)
+}
+
+func (a *APIHandlers) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.CreateAPIKeyRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	fullKey, key, err := a.APIKeys.Create(r.Context(), request.Name, request.Scopes)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/admin/keys/"+key.ID)
+	writeMessage(w, r, http.StatusCreated, &models.APIKeyResponse{Data: apiKeyToProto(key), Key: fullKey}, nil)
+}
+
+func (a *APIHandlers) ListAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := a.APIKeys.List(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response := &models.APIKeyListResponse{Data: []*models.APIKey{}}
+	for i := range keys {
+		response.Data = append(response.Data, apiKeyToProto(&keys[i]))
+	}
+	writeMessage(w, r, http.StatusOK, response, nil)
+}
+
+func (a *APIHandlers) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if err := a.APIKeys.Revoke(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) GetAuditTrailHandler(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultAuditLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxAuditLimit {
+			writeError(w, r, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, MaxAuditLimit))
+			return
+		}
+		limit = n
+	}
+
+	entries, err := a.APIKeys.AuditTrail(r.Context(), mux.Vars(r)["id"], limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response := &models.AuditTrailResponse{Data: []*models.AuditEntry{}}
+	for _, entry := range entries {
+		response.Data = append(response.Data, &models.AuditEntry{
+			KeyId:      entry.KeyID,
+			Method:     entry.Method,
+			Path:       entry.Path,
+			Status:     int32(entry.Status),
+			RemoteAddr: entry.RemoteAddr,
+			AtUnixMs:   entry.At.UnixMilli(),
+		})
+	}
+	writeMessage(w, r, http.StatusOK, response, nil)
+}
+
+func apiKeyToProto(key *storage.APIKey) *models.APIKey {
+	message := &models.APIKey{
+		Id:              key.ID,
+		Name:            key.Name,
+		Scopes:          key.Scopes,
+		CreatedAtUnixMs: key.CreatedAt.UnixMilli(),
+	}
+	if !key.RevokedAt.IsZero() {
+		message.RevokedAtUnixMs = key.RevokedAt.UnixMilli()
+	}
+	if !key.LastUsedAt.IsZero() {
+		message.LastUsedAtUnixMs = key.LastUsedAt.UnixMilli()
+	}
+	return message
+}
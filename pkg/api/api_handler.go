@@ -3,14 +3,40 @@ package api_handlers
 import (
 	"DeathfireArsenal/internal/errormanagement"
 	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/idempotency"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/ratelimit"
+	"DeathfireArsenal/pkg/webhooks"
 	"encoding/json"
-	"github.com/go-playground/validator/v10"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type APIHandlers struct {
-	Logic *logic.BusinessLogic
+	Logic    *logic.BusinessLogic
+	Events   *events.RedisBus
+	Sessions *auth.Sessions
+	APIKeys  *auth.APIKeys
+	Webhooks *webhooks.Registry
+	// Reject requests to the /api routes acting on behalf of a player without a
+	// session token. The /v2 routes always reject them.
+	RequireAuth bool
+
+	// Budget of each route class; classes without one are not limited.
+	Limiter    ratelimit.Limiter
+	RateLimits map[string]ratelimit.Limit
+	// Take the client address from X-Forwarded-For, set by a proxy in front.
+	TrustForwardedFor bool
+
+	// Responses to replay for requests carrying an Idempotency-Key.
+	Idempotency *idempotency.Store
 }
 
 func (a *APIHandlers) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
@@ -18,129 +44,100 @@ func (a *APIHandlers) ServeHTTP(writer http.ResponseWriter, request *http.Reques
 }
 
 func (a *APIHandlers) CreatePlayerHandler(w http.ResponseWriter, r *http.Request) {
-	var requestData struct {
-		PlayerId string `json:"player_id" validate:"required"`
-		Region   string `json:"region" validate:"required,len=3"`
-	}
-	err := json.NewDecoder(r.Body).Decode(&requestData)
+	requestData, err := readCreatePlayerRequest(r)
 	if err != nil {
-		http.Error(w, "Fix the request bruh...", http.StatusBadRequest)
-		return
-	}
-
-	validate := validator.New()
-	if err := validate.Struct(requestData); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	// Create Player via Business
-	err = a.Logic.CreatePlayer(requestData.PlayerId, requestData.Region)
+	secret, err := a.Logic.CreatePlayer(r.Context(), requestData.PlayerId, requestData.Region)
 
 	if err != nil {
-		if err == errormanagement.PlayerIdAlreadyExists {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
+	// The secret is only ever shown here, so answer with it even if no session could be started.
+	session, err := a.newSession(r, requestData.PlayerId)
+	if err != nil {
+		log.Println("Failed to start a session for", requestData.PlayerId+":", err)
+	}
+	response := &models.CreatePlayerResponse{
+		Player:  &models.Player{Id: requestData.PlayerId, Region: regions.Normalize(requestData.Region)},
+		Secret:  secret,
+		Session: session,
+	}
+	writeMessage(w, r, http.StatusCreated, response, nil)
 }
 
 func (a *APIHandlers) CreateRoomHandler(w http.ResponseWriter, r *http.Request) {
-	var requestData struct {
-		PlayerID string `json:"player_id" validate:"required"`
-		Mode     string `json:"mode" validate:"required"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&requestData)
+	requestData, err := readCreateRoomRequest(r)
 	if err != nil {
-		http.Error(w, "Fix the request bruh...", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	// Create Room via Business
-	room_id, err := a.Logic.CreateRoom(requestData.PlayerID, strings.ToLower(requestData.Mode))
+	room_id, err := a.Logic.CreateRoom(r.Context(), requestData.PlayerId, strings.ToLower(requestData.Mode), requestData.Private)
 	if err != nil {
-		if err == errormanagement.InvalidMode ||
-			err == errormanagement.PlayerOccupied {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(room_id))
+	writeMessage(w, r, http.StatusCreated, &models.CreateRoomResponse{RoomId: room_id}, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(room_id))
+	})
 }
 
 func (a *APIHandlers) GetRoomsHandler(w http.ResponseWriter, r *http.Request) {
-	mode := strings.ToLower(r.URL.Query().Get("mode"))
-	if mode == "" {
-		http.Error(w, "At least type something...", http.StatusBadRequest)
+	query, err := parseRoomQuery(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
-	// Get list of Room Ids via Business
-	rooms, err := a.Logic.GetRoomsByMode(mode)
+	// Get a page of Rooms via Business
+	page, err := a.Logic.ListRooms(query)
 	if err != nil {
-		if err == errormanagement.InvalidMode {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	jsonData, _ := json.Marshal(rooms)
-	w.Write(jsonData)
+	rooms := make([]string, len(page.Rooms))
+	for i := range page.Rooms {
+		rooms[i] = page.Rooms[i].ID
+	}
+	setNextLink(w, r, page.NextCursor)
+	writeMessage(w, r, http.StatusOK, &models.GetRoomsResponse{RoomIds: rooms, NextCursor: page.NextCursor}, func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		jsonData, _ := json.Marshal(rooms)
+		w.Write(jsonData)
+	})
 }
 
 func (a *APIHandlers) JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
-	var requestData struct {
-		PlayerID string `json:"player_id" validate:"required"`
-		RoomID   string `json:"room_id" validate:"required,len=7"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&requestData)
+	requestData, err := readJoinRoomRequest(r)
 	if err != nil {
-		http.Error(w, "Fix the request bruh...", http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	// Add Player to room via Business
-	err = a.Logic.JoinRoom(requestData.PlayerID, requestData.RoomID)
+	err = a.Logic.JoinRoom(r.Context(), requestData.PlayerId, requestData.RoomId)
 
 	if err != nil {
-		if err == errormanagement.PlayerNotFound ||
-			err == errormanagement.RoomNotFound ||
-			err == errormanagement.RoomIsFull ||
-			err == errormanagement.PlayerOccupied {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	writeMessage(w, r, http.StatusOK, &models.JoinRoomResponse{}, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+	})
 }
 
 func (a *APIHandlers) LeaveRoomHandler(w http.ResponseWriter, r *http.Request) {
-	var requestData struct {
-		PlayerId string `json:"player_id" validate:"required"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&requestData)
+	requestData, err := readLeaveRoomRequest(r)
 	if err != nil {
-		http.Error(w, "Fix the request bruh...", http.StatusBadRequest)
-		return
-	}
-
-	validate := validator.New()
-	if err := validate.Struct(requestData); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
@@ -148,52 +145,166 @@ func (a *APIHandlers) LeaveRoomHandler(w http.ResponseWriter, r *http.Request) {
 	err = a.Logic.LeaveRoom(r.Context(), requestData.PlayerId)
 
 	if err != nil {
-		if err == errormanagement.PlayerIdle ||
-			err == errormanagement.PlayerNotFound {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	writeMessage(w, r, http.StatusOK, &models.LeaveRoomResponse{}, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusOK)
+	})
 }
 
 func (a *APIHandlers) GetModeTrendsByRegion(w http.ResponseWriter, r *http.Request) {
 	region := r.URL.Query().Get("region")
 	if region == "" {
-		http.Error(w, "At least type something...", http.StatusBadRequest)
+		writeError(w, r, errormanagement.MissingParameter)
 		return
 	}
+	query, err := parseTrendQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	query.Region = region
 
-	// Get List of Mode via Business
-	modes, _ := a.Logic.GetModeTrendsByRegion(region)
-	jsonData, _ := json.Marshal(modes)
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	// Get ranked List of Mode via Business
+	modes, err := a.Logic.GetModeTrendsByRegion(query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeTrends(w, r, modes)
 }
 
 func (a *APIHandlers) GetModeTrendsByRegionV2(w http.ResponseWriter, r *http.Request) {
-	var requestData struct {
-		PlayerID string `json:"player_id"`
+	var requestData models.GetModeTrendsByPlayerRegionRequest
+	err := decodeRequest(r, &requestData)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	query, err := parseTrendQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	// The body may carry the mode and limit as well; the query string wins.
+	if query.Mode == "" {
+		query.Mode = strings.ToLower(requestData.Mode)
+	}
+	if r.URL.Query().Get("limit") == "" && requestData.Limit != 0 {
+		if requestData.Limit < 1 || requestData.Limit > logic.MaxTrendLimit {
+			writeError(w, r, invalidLimit())
+			return
+		}
+		query.Limit = int(requestData.Limit)
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&requestData)
+	// Get ranked List of Mode via Business
+	modes, err := a.Logic.GetModeTrendsByPlayerRegion(requestData.PlayerId, query)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
+	writeTrends(w, r, modes)
+}
 
-	// Get List of Mode via Business
-	modes, err := a.Logic.GetModeTrendsByPlayerRegion(requestData.PlayerID)
+func (a *APIHandlers) GetTrendBreakdown(w http.ResponseWriter, r *http.Request) {
+	// Get region x mode counts via Business
+	breakdown, err := a.Logic.GetTrendBreakdown()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
 	}
-	jsonData, _ := json.Marshal(modes)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(jsonData)
+	writeMessage(w, r, http.StatusOK, logic.BreakdownToProto(breakdown), func(w http.ResponseWriter) {
+		jsonData, _ := json.Marshal(breakdown)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonData)
+	})
+}
+
+// Helper function to write a ranked trend list, as a bare JSON array for legacy clients.
+func writeTrends(w http.ResponseWriter, r *http.Request, modes []logic.ModeTrend) {
+	writeMessage(w, r, http.StatusOK, logic.TrendsToProto(modes), func(w http.ResponseWriter) {
+		jsonData, _ := json.Marshal(modes)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonData)
+	})
+}
+
+// Helper function to read the optional limit and mode parameters of the trend endpoints.
+func parseTrendQuery(r *http.Request) (logic.TrendQuery, error) {
+	query := logic.TrendQuery{
+		Mode:  strings.ToLower(r.URL.Query().Get("mode")),
+		Limit: logic.DefaultTrendLimit,
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > logic.MaxTrendLimit {
+			return query, invalidLimit()
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+// Helper function to read the mode, filter, sort and paging parameters of the room listings.
+func parseRoomQuery(r *http.Request) (logic.RoomQuery, error) {
+	params := r.URL.Query()
+	query := logic.RoomQuery{
+		Mode:       strings.ToLower(params.Get("mode")),
+		State:      strings.ToLower(params.Get("state")),
+		Region:     params.Get("region"),
+		Visibility: strings.ToLower(params.Get("visibility")),
+		Sort:       strings.ToLower(params.Get("sort")),
+		Cursor:     params.Get("cursor"),
+	}
+	if query.Mode == "" {
+		return query, errormanagement.MissingParameter
+	}
+	if freeSlots := params.Get("free_slots"); freeSlots != "" {
+		value, err := strconv.ParseBool(freeSlots)
+		if err != nil {
+			return query, fmt.Errorf("%w: free_slots must be true or false", errormanagement.InvalidParameter)
+		}
+		query.FreeSlots = value
+	}
+	if createdAfter := params.Get("created_after"); createdAfter != "" {
+		value, err := time.Parse(time.RFC3339, createdAfter)
+		if err != nil {
+			return query, fmt.Errorf("%w: created_after must be an RFC 3339 timestamp", errormanagement.InvalidParameter)
+		}
+		query.CreatedAfter = value
+	}
+	switch strings.ToLower(params.Get("order")) {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("%w: order must be asc or desc", errormanagement.InvalidParameter)
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return query, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, logic.MaxRoomLimit)
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+// Helper function to link to the page following the current one of a listing.
+func setNextLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	if cursor == "" {
+		return
+	}
+	params := r.URL.Query()
+	params.Set("cursor", cursor)
+	w.Header().Add("Link", "<"+r.URL.Path+"?"+params.Encode()+">; rel=\"next\"")
+}
+
+func invalidLimit() error {
+	return fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, logic.MaxTrendLimit)
 }
@@ -0,0 +1,150 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/models"
+	"net/http"
+	"strings"
+)
+
+// Authenticate resolves the session token of a request, if it carries one, to
+// the acting player. Requests without a token pass through; routes acting on
+// behalf of a player decide whether they need one with requirePlayer.
+func (a *APIHandlers) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		claims, err := a.Sessions.Verify(r.Context(), token)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+	})
+}
+
+// requirePlayer rejects anonymous requests once RequireAuth is set. Until then
+// the player named in the request is trusted, as it always was.
+func (a *APIHandlers) requirePlayer(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.FromContext(r.Context()); !ok && a.RequireAuth {
+			writeError(w, r, errormanagement.Unauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// requireSession rejects every request without a valid token.
+func requireSession(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.FromContext(r.Context()); !ok {
+			writeError(w, r, errormanagement.Unauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// actingPlayer returns the player a request acts for: the one of its token, or
+// the one it names when it has no token. Naming someone else is forbidden.
+func actingPlayer(r *http.Request, named string) (string, error) {
+	claims, ok := auth.FromContext(r.Context())
+	if !ok {
+		return named, nil
+	}
+	if named != "" && named != claims.Subject {
+		return "", errormanagement.Forbidden
+	}
+	return claims.Subject, nil
+}
+
+// Helper function to read the token from the Authorization header, or from the
+// access_token parameter for WebSocket and EventSource clients that cannot set
+// headers.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// Helper function to start a session for the player.
+func (a *APIHandlers) newSession(r *http.Request, playerID string) (*models.Session, error) {
+	token, claims, err := a.Sessions.Issue(r.Context(), playerID)
+	if err != nil {
+		return nil, err
+	}
+	return claims.Proto(token), nil
+}
+
+// LoginHandler exchanges the secret handed out when the player was created for
+// a session token.
+func (a *APIHandlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.LoginRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	requestData := struct {
+		PlayerId string `json:"player_id" validate:"required"`
+		Secret   string `json:"secret" validate:"required"`
+	}{request.PlayerId, request.Secret}
+	if err := validate.Struct(requestData); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Check the secret via Business
+	if err := a.Logic.CheckPlayerSecret(request.PlayerId, request.Secret); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	session, err := a.newSession(r, request.PlayerId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusCreated, &models.SessionResponse{Data: session}, nil)
+}
+
+// RefreshSessionHandler replaces the token of the request with a new one signed
+// with the active key, and revokes the old one.
+func (a *APIHandlers) RefreshSessionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.FromContext(r.Context())
+	session, err := a.newSession(r, claims.Subject)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := a.Sessions.Revoke(r.Context(), claims); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusCreated, &models.SessionResponse{Data: session}, nil)
+}
+
+// LogoutHandler revokes the token of the request.
+func (a *APIHandlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.FromContext(r.Context())
+	if err := a.Sessions.Revoke(r.Context(), claims); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutEverywhereHandler revokes every token of the player of the request.
+func (a *APIHandlers) LogoutEverywhereHandler(w http.ResponseWriter, r *http.Request) {
+	claims, _ := auth.FromContext(r.Context())
+	if err := a.Sessions.RevokePlayer(r.Context(), claims.Subject); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,43 @@
+package api_handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestV2RoomRoutesNeedASession(t *testing.T) {
+	router := mux.NewRouter()
+	(&APIHandlers{}).RegisterRoutes(router)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/v2/rooms"},
+		{http.MethodPut, "/v2/rooms/dfjlnas/players/Furious"},
+		{http.MethodDelete, "/v2/rooms/dfjlnas/players/Furious"},
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(route.method, route.path, nil))
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a token = %d, want 401", route.method, route.path, recorder.Code)
+		}
+	}
+}
+
+func TestRequirePlayer(t *testing.T) {
+	for _, requireAuth := range []bool{false, true} {
+		served := false
+		handler := (&APIHandlers{RequireAuth: requireAuth}).requirePlayer(func(w http.ResponseWriter, r *http.Request) {
+			served = true
+		})
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodPost, "/api/createRoom", nil))
+
+		if served == requireAuth {
+			t.Errorf("with RequireAuth %t, an anonymous request was served: %t", requireAuth, served)
+		}
+		if requireAuth && recorder.Code != http.StatusUnauthorized {
+			t.Errorf("with RequireAuth, an anonymous request = %d, want 401", recorder.Code)
+		}
+	}
+}
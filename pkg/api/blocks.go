@@ -0,0 +1,58 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/logic"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// The block routes live under /v2/players/{id} and, like the friend routes,
+// always act for the player of the session token.
+
+func (a *APIHandlers) ListBlockedPlayersHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Get Block List via Business
+	blocked, err := a.Logic.ListBlockedPlayers(r.Context(), playerID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, logic.BlockedPlayersToProto(blocked), nil)
+}
+
+// BlockPlayerHandler adds the player in the path to the block list. Blocking
+// a player twice succeeds.
+func (a *APIHandlers) BlockPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Block Player via Business
+	if err := a.Logic.BlockPlayer(r.Context(), playerID, mux.Vars(r)["blockedId"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) UnblockPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Unblock Player via Business
+	if err := a.Logic.UnblockPlayer(r.Context(), playerID, mux.Vars(r)["blockedId"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
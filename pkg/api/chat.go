@@ -0,0 +1,59 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/models"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// The chat routes act for the player of the session token, who must be in
+// the room.
+
+func (a *APIHandlers) PostChatMessageHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, "")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var request models.PostChatMessageRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Post Chat Message via Business
+	message, err := a.Logic.PostChatMessage(r.Context(), playerID, mux.Vars(r)["id"], request.Text)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusCreated, events.ChatMessageProto(message), nil)
+}
+
+func (a *APIHandlers) ChatHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, "")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			writeError(w, r, fmt.Errorf("%w: limit must be a number", errormanagement.InvalidParameter))
+			return
+		}
+	}
+
+	// Get Chat History via Business
+	messages, err := a.Logic.ChatHistory(r.Context(), playerID, mux.Vars(r)["id"], limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, logic.ChatHistoryToProto(messages), nil)
+}
@@ -0,0 +1,63 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/models"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (a *APIHandlers) ListDomainEventsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := logic.DomainEventQuery{
+		PlayerID: params.Get("player_id"),
+		RoomID:   params.Get("room_id"),
+		Cursor:   params.Get("cursor"),
+	}
+	var err error
+	if query.From, err = timeParam(r, "from"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if query.To, err = timeParam(r, "to"); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			writeError(w, r, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, logic.MaxDomainEventLimit))
+			return
+		}
+		query.Limit = n
+	}
+
+	// Get a page of the Domain Event log via Business
+	page, err := a.Logic.ListDomainEvents(r.Context(), query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response := &models.DomainEventListResponse{Data: []*models.DomainEvent{}, NextCursor: page.NextCursor}
+	for i := range page.Events {
+		response.Data = append(response.Data, logic.DomainEventToProto(&page.Events[i]))
+	}
+	setNextLink(w, r, page.NextCursor)
+	writeMessage(w, r, http.StatusOK, response, nil)
+}
+
+// Helper function to read an optional RFC 3339 time from the query string.
+func timeParam(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 timestamp", errormanagement.InvalidParameter, name)
+	}
+	return at, nil
+}
@@ -0,0 +1,147 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/models"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// The friend routes live under /v2/players/{id} and always act for the player
+// of the session token, who must be the player in the path.
+
+func (a *APIHandlers) ListFriendsHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Get Friends via Business
+	friends, err := a.Logic.ListFriends(r.Context(), playerID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, logic.FriendsToProto(friends), nil)
+}
+
+func (a *APIHandlers) ListFriendRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Get Friend Requests via Business
+	requests, err := a.Logic.ListFriendRequests(r.Context(), playerID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, logic.FriendRequestsToProto(requests), nil)
+}
+
+// SendFriendRequestHandler asks the player in the path to be friends, or
+// accepts their request if they asked first.
+func (a *APIHandlers) SendFriendRequestHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Send Friend Request via Business
+	friends, err := a.Logic.SendFriendRequest(r.Context(), playerID, mux.Vars(r)["friendId"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.FriendRequestResponse{Friends: friends}, nil)
+}
+
+func (a *APIHandlers) AcceptFriendRequestHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Accept Friend Request via Business
+	if err := a.Logic.AcceptFriendRequest(r.Context(), playerID, mux.Vars(r)["friendId"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.FriendRequestResponse{Friends: true}, nil)
+}
+
+// DeclineFriendRequestHandler declines a request received from the player in
+// the path, or withdraws one sent to them.
+func (a *APIHandlers) DeclineFriendRequestHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Decline Friend Request via Business
+	if err := a.Logic.DeclineFriendRequest(r.Context(), playerID, mux.Vars(r)["friendId"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) RemoveFriendHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Remove Friend via Business
+	if err := a.Logic.RemoveFriend(r.Context(), playerID, mux.Vars(r)["friendId"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JoinFriendHandler drops the player into the room of a friend, if it has
+// space left, and answers with the room.
+func (a *APIHandlers) JoinFriendHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Join the Friend's Room via Business
+	roomID, err := a.Logic.JoinFriend(r.Context(), playerID, mux.Vars(r)["friendId"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	room, err := a.Logic.GetRoom(roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.RoomResponse{Data: logic.RoomToProto(room)}, nil)
+}
+
+// HeartbeatHandler keeps the player online for its friends.
+func (a *APIHandlers) HeartbeatHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Record Heartbeat via Business
+	if err := a.Logic.Heartbeat(r.Context(), playerID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
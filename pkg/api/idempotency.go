@@ -0,0 +1,169 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/idempotency"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// idempotent lets clients retry a request safely by sending an Idempotency-Key
+// header. The first response for a key is stored and replayed to every retry
+// carrying the same key and request; a retry arriving while the first request
+// is still being served is turned down. Server errors are not stored, so a
+// request that failed that way can be retried for real.
+func (a *APIHandlers) idempotent(handler http.HandlerFunc) http.HandlerFunc {
+	return a.withIdempotencyKey(handler, false)
+}
+
+// idempotentSecret is idempotent for handlers whose successful responses hold
+// a secret, such as creating a player. Their bodies are never stored; a retry
+// learns that the request went through, but not the secret.
+func (a *APIHandlers) idempotentSecret(handler http.HandlerFunc) http.HandlerFunc {
+	return a.withIdempotencyKey(handler, true)
+}
+
+func (a *APIHandlers) withIdempotencyKey(handler http.HandlerFunc, withholdSuccess bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || a.Idempotency == nil {
+			handler(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, r, fmt.Errorf("%w: Idempotency-Key must be at most %d characters long", errormanagement.InvalidParameter, maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, errormanagement.MalformedRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are only unique per client, so they are scoped to the acting player or address.
+		scope := a.clientIP(r) + ":"
+		if claims, ok := auth.FromContext(r.Context()); ok {
+			scope = idempotency.PlayerScope(claims.Subject)
+		}
+		storeKey := scope + r.Method + ":" + r.URL.Path + ":" + key
+		fingerprint := requestFingerprint(r, body)
+
+		record, err := a.Idempotency.Begin(r.Context(), storeKey, fingerprint)
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			writeError(w, r, errormanagement.IdempotencyInProgress)
+			return
+		case errors.Is(err, idempotency.ErrMismatch):
+			writeError(w, r, errormanagement.IdempotencyKeyReused)
+			return
+		case err != nil:
+			writeError(w, r, err)
+			return
+		case record != nil && record.Withheld:
+			writeError(w, r, errormanagement.IdempotencyResponseWithheld)
+			return
+		case record != nil:
+			for name, values := range record.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.Status)
+			w.Write(record.Body)
+			return
+		}
+
+		// Only the headers of the handler are stored; those of the middleware are set anew on replay.
+		before := w.Header().Clone()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+
+		// The outcome must be stored even if the client has gone away in the meantime.
+		if recorder.status >= http.StatusInternalServerError {
+			err = a.Idempotency.Abort(context.Background(), storeKey)
+		} else if withholdSuccess && recorder.status < http.StatusBadRequest {
+			err = a.Idempotency.Complete(context.Background(), storeKey, idempotency.Record{
+				Fingerprint: fingerprint,
+				Status:      recorder.status,
+				Withheld:    true,
+			})
+		} else {
+			err = a.Idempotency.Complete(context.Background(), storeKey, idempotency.Record{
+				Fingerprint: fingerprint,
+				Status:      recorder.status,
+				Header:      headerChanges(before, w.Header()),
+				Body:        recorder.body.Bytes(),
+			})
+		}
+		if err != nil {
+			log.Println("Failed to store the outcome of idempotent request", storeKey+":", err)
+		}
+	}
+}
+
+// requestFingerprint identifies a request by what the handlers read from it,
+// so that a key reused for another request is noticed.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("Content-Type"), r.Header.Get("Accept")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func headerChanges(before http.Header, after http.Header) map[string][]string {
+	changes := make(map[string][]string)
+	for name, values := range after {
+		if !equalValues(before[name], values) {
+			changes[name] = values
+		}
+	}
+	return changes
+}
+
+func equalValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder keeps a copy of the status and body of a response while it
+// is written.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
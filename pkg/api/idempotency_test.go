@@ -0,0 +1,142 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/idempotency"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper function to return handlers keeping idempotent requests on a Redis of
+// the test's own.
+func newTestIdempotency(t *testing.T) *APIHandlers {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return &APIHandlers{Idempotency: idempotency.NewStore(client, "test", time.Hour, time.Minute)}
+}
+
+// Helper function to serve a room creation with an Idempotency-Key.
+func serveIdempotent(handler http.HandlerFunc, key string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/api/createRoom", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Idempotency-Key", key)
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+	return recorder
+}
+
+func TestIdempotentReplaysTheFirstResponse(t *testing.T) {
+	a := newTestIdempotency(t)
+	calls := 0
+	handler := a.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/v2/rooms/dfjlnas")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"room_id":"dfjlnas"}`))
+	})
+
+	first := serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	retry := serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	if calls != 1 {
+		t.Fatalf("the handler served %d requests, want 1", calls)
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if location := retry.Header().Get("Location"); location != "/v2/rooms/dfjlnas" {
+		t.Errorf("replayed Location = %q, want the handler's", location)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("the retry isn't marked as replayed")
+	}
+
+	// Another key is another request.
+	serveIdempotent(handler, "k2", `{"player_id":"Furious"}`)
+	if calls != 2 {
+		t.Fatalf("the handler served %d requests, want 2", calls)
+	}
+}
+
+func TestIdempotentRejectsAReusedKey(t *testing.T) {
+	a := newTestIdempotency(t)
+	calls := 0
+	handler := a.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	recorder := serveIdempotent(handler, "k1", `{"player_id":"Valiant"}`)
+	if want := errormanagement.StatusOf(errormanagement.IdempotencyKeyReused).HTTP; recorder.Code != want {
+		t.Fatalf("request reusing a key = %d, want %d", recorder.Code, want)
+	}
+	if calls != 1 {
+		t.Fatalf("the handler served %d requests, want 1", calls)
+	}
+}
+
+func TestIdempotentRejectsARetryInProgress(t *testing.T) {
+	a := newTestIdempotency(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := a.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	served := make(chan *httptest.ResponseRecorder)
+	go func() {
+		served <- serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	}()
+	<-started
+	recorder := serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	close(release)
+	if want := errormanagement.StatusOf(errormanagement.IdempotencyInProgress).HTTP; recorder.Code != want {
+		t.Fatalf("retry while the first request is served = %d, want %d", recorder.Code, want)
+	}
+	if first := <-served; first.Code != http.StatusCreated {
+		t.Fatalf("first request = %d, want 201", first.Code)
+	}
+}
+
+func TestIdempotentRetriesServerErrors(t *testing.T) {
+	a := newTestIdempotency(t)
+	statuses := []int{http.StatusInternalServerError, http.StatusCreated}
+	calls := 0
+	handler := a.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[calls])
+		calls++
+	})
+
+	serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	if recorder := serveIdempotent(handler, "k1", `{"player_id":"Furious"}`); recorder.Code != http.StatusCreated {
+		t.Fatalf("retry after a server error = %d, want 201", recorder.Code)
+	}
+	if calls != 2 {
+		t.Fatalf("the handler served %d requests, want 2", calls)
+	}
+}
+
+func TestIdempotentSecretWithholdsTheResponse(t *testing.T) {
+	a := newTestIdempotency(t)
+	handler := a.idempotentSecret(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"secret":"hunter2"}`))
+	})
+
+	serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	recorder := serveIdempotent(handler, "k1", `{"player_id":"Furious"}`)
+	if want := errormanagement.StatusOf(errormanagement.IdempotencyResponseWithheld).HTTP; recorder.Code != want {
+		t.Fatalf("retry of a request answered with a secret = %d, want %d", recorder.Code, want)
+	}
+	if strings.Contains(recorder.Body.String(), "hunter2") {
+		t.Fatalf("the retry got the secret: %s", recorder.Body)
+	}
+}
@@ -0,0 +1,169 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/models"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportPlayerHandler files a report about a player the player in the path
+// is in a room with, or played with lately.
+func (a *APIHandlers) ReportPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var request models.ReportPlayerRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if request.ReportedId == "" {
+		writeError(w, r, fmt.Errorf("%w: reported_id is required", errormanagement.MissingParameter))
+		return
+	}
+
+	// Report Player via Business
+	report, err := a.Logic.ReportPlayer(r.Context(), playerID, request.ReportedId, request.Category, request.Text)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusCreated, logic.ReportReceiptToProto(report), nil)
+}
+
+func (a *APIHandlers) ListReportsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := logic.ReportQuery{
+		State:    strings.ToLower(params.Get("state")),
+		Reported: params.Get("player"),
+		Cursor:   params.Get("cursor"),
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			writeError(w, r, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, logic.MaxReportLimit))
+			return
+		}
+		query.Limit = n
+	}
+
+	// Get a page of Reports via Business
+	page, err := a.Logic.ListReports(r.Context(), query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response := &models.ReportListResponse{Data: []*models.Report{}, NextCursor: page.NextCursor}
+	for i := range page.Reports {
+		response.Data = append(response.Data, logic.ReportToProto(&page.Reports[i]))
+	}
+	setNextLink(w, r, page.NextCursor)
+	writeMessage(w, r, http.StatusOK, response, nil)
+}
+
+func (a *APIHandlers) GetReportHandler(w http.ResponseWriter, r *http.Request) {
+	// Get Report via Business
+	report, err := a.Logic.GetReport(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, reportContextToProto(report), nil)
+}
+
+func (a *APIHandlers) UpdateReportHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.UpdateReportRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Review Report via Business
+	id := mux.Vars(r)["id"]
+	if _, err := a.Logic.ReviewReport(r.Context(), id, strings.ToLower(request.State), moderatorOf(r), request.Note); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	report, err := a.Logic.GetReport(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, reportContextToProto(report), nil)
+}
+
+func (a *APIHandlers) BanPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.BanPlayerRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Ban Player via Business
+	playerID := mux.Vars(r)["id"]
+	ban, err := a.Logic.BanPlayer(r.Context(), logic.BanRequest{
+		PlayerID:  playerID,
+		Reason:    request.Reason,
+		Duration:  time.Duration(request.DurationSeconds) * time.Second,
+		Permanent: request.Permanent,
+		ReportIDs: request.ReportIds,
+		IssuedBy:  moderatorOf(r),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/admin/players/"+playerID+"/bans/"+ban.ID)
+	writeMessage(w, r, http.StatusCreated, &models.BanResponse{Data: logic.BanToProto(ban)}, nil)
+}
+
+func (a *APIHandlers) ListBansHandler(w http.ResponseWriter, r *http.Request) {
+	// Get Bans via Business
+	bans, err := a.Logic.ListBans(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response := &models.BanListResponse{Data: []*models.Ban{}}
+	for i := range bans {
+		response.Data = append(response.Data, logic.BanToProto(&bans[i]))
+	}
+	writeMessage(w, r, http.StatusOK, response, nil)
+}
+
+func (a *APIHandlers) LiftBanHandler(w http.ResponseWriter, r *http.Request) {
+	// Lift Ban via Business
+	if _, err := a.Logic.LiftBan(r.Context(), mux.Vars(r)["id"], mux.Vars(r)["banId"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Helper function to name the moderator behind a request by its API key.
+func moderatorOf(r *http.Request) string {
+	if key, ok := auth.APIKeyFromContext(r.Context()); ok {
+		return key.ID
+	}
+	return ""
+}
+
+func reportContextToProto(report *logic.ReportContext) *models.ReportResponse {
+	response := &models.ReportResponse{Data: logic.ReportToProto(&report.Report)}
+	if report.Room != nil {
+		response.CurrentRoom = logic.RoomToProto(report.Room)
+	}
+	if report.ActiveBan != nil {
+		response.ActiveBan = logic.BanToProto(report.ActiveBan)
+	}
+	return response
+}
@@ -0,0 +1,112 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentTypeJSON     = "application/json"
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// Field names on the wire are the snake_case names of the .proto files, which
+// are also the names the JSON API has always used.
+var (
+	jsonMarshaler   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	jsonUnmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// decodeRequest reads the body into message, as binary protobuf or as JSON
+// depending on the Content-Type. A missing Content-Type is read as JSON.
+func decodeRequest(r *http.Request, message proto.Message) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errormanagement.MalformedRequest, err)
+	}
+
+	switch requestContentType(r) {
+	case contentTypeProtobuf:
+		err = proto.Unmarshal(body, message)
+	case contentTypeJSON, "":
+		err = jsonUnmarshaler.Unmarshal(body, message)
+	default:
+		return errormanagement.UnsupportedContentType
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errormanagement.MalformedRequest, err)
+	}
+	return nil
+}
+
+// writeMessage answers with message in the representation the client accepts.
+// Clients that don't ask for either representation explicitly get legacy, the
+// body the endpoint returned before content negotiation, when there is one.
+func writeMessage(w http.ResponseWriter, r *http.Request, status int, message proto.Message, legacy func(http.ResponseWriter)) {
+	contentType := negotiateContentType(r)
+	if contentType == "" && legacy != nil {
+		legacy(w)
+		return
+	}
+
+	var body []byte
+	var err error
+	if contentType == contentTypeProtobuf {
+		body, err = proto.Marshal(message)
+	} else {
+		contentType = contentTypeJSON
+		body, err = jsonMarshaler.Marshal(message)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func requestContentType(r *http.Request) string {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return header
+	}
+	return mediaType
+}
+
+// negotiateContentType picks the representation with the highest quality in
+// the Accept header, or "" when neither JSON nor protobuf is named explicitly.
+func negotiateContentType(r *http.Request) string {
+	best, bestQuality := "", 0.0
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		if mediaType != contentTypeJSON && mediaType != contentTypeProtobuf {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = mediaType, quality
+		}
+	}
+	return best
+}
@@ -0,0 +1,141 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"*/*", ""},
+		{"text/html", ""},
+		{"application/json", contentTypeJSON},
+		{"application/x-protobuf", contentTypeProtobuf},
+		{"application/json;q=0.5, application/x-protobuf", contentTypeProtobuf},
+		{"application/x-protobuf;q=0.2, application/json;q=0.8", contentTypeJSON},
+		{"text/html, application/json;q=0.1", contentTypeJSON},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/api/getRooms", nil)
+		request.Header.Set("Accept", test.accept)
+		if got := negotiateContentType(request); got != test.want {
+			t.Errorf("negotiateContentType(%q) = %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	want := &models.CreatePlayerRequest{PlayerId: "Furious", Region: "BLR"}
+	binary, err := proto.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"protobuf", "application/x-protobuf", binary},
+		{"json", "application/json; charset=utf-8", []byte(`{"player_id": "Furious", "region": "BLR", "extra": 1}`)},
+		{"no content type", "", []byte(`{"player_id": "Furious", "region": "BLR"}`)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/createPlayer", bytes.NewReader(test.body))
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+			var got models.CreatePlayerRequest
+			if err := decodeRequest(request, &got); err != nil {
+				t.Fatalf("decodeRequest: %v", err)
+			}
+			if !proto.Equal(&got, want) {
+				t.Fatalf("decoded %v, want %v", &got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeRequestRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        error
+	}{
+		{"another content type", "text/plain", "Furious", errormanagement.UnsupportedContentType},
+		{"malformed json", "application/json", `{"player_id": `, errormanagement.MalformedRequest},
+		{"malformed protobuf", "application/x-protobuf", "\xff\xff", errormanagement.MalformedRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/createPlayer", strings.NewReader(test.body))
+			request.Header.Set("Content-Type", test.contentType)
+			var message models.CreatePlayerRequest
+			if err := decodeRequest(request, &message); !errors.Is(err, test.want) {
+				t.Fatalf("decodeRequest = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	room := &models.Room{Id: "dfjlnas", PlayerIds: []string{"Furious"}, Mode: "mayhem", PlayerCount: 1}
+	legacy := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"legacy"`))
+	}
+	serve := func(accept string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/api/getRooms", nil)
+		request.Header.Set("Accept", accept)
+		recorder := httptest.NewRecorder()
+		writeMessage(recorder, request, http.StatusOK, room, legacy)
+		return recorder
+	}
+
+	if recorder := serve(""); recorder.Body.String() != `"legacy"` {
+		t.Errorf("without an Accept header, the body is %q, want the legacy one", recorder.Body.String())
+	}
+
+	recorder := serve("application/x-protobuf")
+	if contentType := recorder.Header().Get("Content-Type"); contentType != contentTypeProtobuf {
+		t.Errorf("Content-Type = %q, want %q", contentType, contentTypeProtobuf)
+	}
+	var got models.Room
+	if err := proto.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !proto.Equal(&got, room) {
+		t.Errorf("protobuf body = %v, want %v", &got, room)
+	}
+
+	// JSON bodies carry every field, under its .proto name.
+	recorder = serve("application/json")
+	if contentType := recorder.Header().Get("Content-Type"); contentType != contentTypeJSON {
+		t.Errorf("Content-Type = %q, want %q", contentType, contentTypeJSON)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &fields); err != nil {
+		t.Fatalf("JSON body %q: %v", recorder.Body.String(), err)
+	}
+	for _, field := range []string{"id", "playerIds", "mode", "private", "playerCount"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("JSON body %s has no %s", recorder.Body.String(), field)
+		}
+	}
+	if vary := recorder.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("Vary = %q, want Accept", vary)
+	}
+}
@@ -0,0 +1,112 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const contentTypeProblem = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code is the stable identifier
+// of the error and Errors lists the offending fields of a validation error.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// validate reports the JSON names of the fields it rejects.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// writeError answers with the problem details of err.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := newProblem(r.URL.Path, err)
+
+	jsonData, _ := json.Marshal(problem)
+	w.Header().Set("Content-Type", contentTypeProblem)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if problem.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(problem.Status)
+	w.Write(jsonData)
+}
+
+// newProblem builds the problem details of err. A business error wrapped with
+// more context keeps that context as the detail. Errors that are not business
+// errors are logged and reported without their message.
+func newProblem(instance string, err error) Problem {
+	problem := Problem{Instance: instance}
+
+	var validationErrors validator.ValidationErrors
+	var businessError *errormanagement.Error
+	switch {
+	case errors.As(err, &validationErrors):
+		businessError = errormanagement.ValidationFailed
+		for _, fieldError := range validationErrors {
+			problem.Errors = append(problem.Errors, FieldError{
+				Field:   fieldError.Field(),
+				Rule:    fieldError.Tag(),
+				Param:   fieldError.Param(),
+				Message: fieldMessage(fieldError),
+			})
+		}
+	case errors.As(err, &businessError):
+	default:
+		log.Println("Unexpected error while serving", instance+":", err)
+		problem.Status = http.StatusInternalServerError
+		problem.Code = "internal_error"
+		problem.Title = http.StatusText(http.StatusInternalServerError)
+	}
+
+	if businessError != nil {
+		problem.Code = businessError.Code
+		problem.Title = businessError.Message
+		problem.Status = errormanagement.StatusOf(businessError).HTTP
+		if len(problem.Errors) == 0 && err.Error() != businessError.Message {
+			problem.Detail = err.Error()
+		}
+	}
+	problem.Type = "/problems/" + problem.Code
+	return problem
+}
+
+func fieldMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return fieldError.Field() + " is required"
+	case "len":
+		return fieldError.Field() + " must be " + fieldError.Param() + " characters long"
+	default:
+		return fieldError.Field() + " failed the " + fieldError.Tag() + " rule"
+	}
+}
@@ -0,0 +1,167 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/ratelimit"
+	"context"
+	"github.com/gorilla/mux"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route classes, each with a budget of its own in RateLimits.
+const (
+	RouteClassRead    = "read"
+	RouteClassWrite   = "write"
+	RouteClassSession = "session"
+	RouteClassAdmin   = "admin"
+	RouteClassChat    = "chat"
+)
+
+// limitAddress turns a request down with 429 when the bucket of the caller's
+// IP address for the class of the route is empty, before anything else works
+// on it. It doesn't take a token itself: rateLimit takes one from every bucket
+// of the request at once. A request turned down before it gets there, such as
+// one with an invalid token, is charged to its address afterwards.
+func (a *APIHandlers) limitAddress(classify func(*http.Request) string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := classify(r)
+			limit, ok := a.RateLimits[class]
+			if a.Limiter == nil || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bucket := class + ":ip:" + a.clientIP(r)
+			result, err := a.Limiter.Peek(r.Context(), []string{bucket}, limit)
+			if err != nil {
+				log.Println("Failed to rate limit", bucket+":", err)
+			} else if !result.Allowed {
+				writeRateLimit(w, r, result)
+				return
+			}
+
+			charged := false
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), chargedKey{}, &charged)))
+			if !charged {
+				if _, err := a.Limiter.Allow(r.Context(), []string{bucket}, limit); err != nil {
+					log.Println("Failed to rate limit", bucket+":", err)
+				}
+			}
+		})
+	}
+}
+
+// chargedKey marks in the context of a request whether rateLimit charged it.
+type chargedKey struct{}
+
+// rateLimit takes a token for the class of the route from the bucket of the
+// caller's IP address and, when it is authenticated, from the bucket of its
+// player or API key. Once any of them is empty the request is turned down
+// with 429, without taking a token from the others, until the bucket refills.
+func (a *APIHandlers) rateLimit(classify func(*http.Request) string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class := classify(r)
+			limit, ok := a.RateLimits[class]
+			if a.Limiter == nil || !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buckets := []string{class + ":ip:" + a.clientIP(r)}
+			if key, ok := auth.APIKeyFromContext(r.Context()); ok {
+				buckets = append(buckets, class+":key:"+key.ID)
+			} else if claims, ok := auth.FromContext(r.Context()); ok {
+				buckets = append(buckets, class+":player:"+claims.Subject)
+			}
+			if charged, ok := r.Context().Value(chargedKey{}).(*bool); ok {
+				*charged = true
+			}
+
+			result, err := a.Limiter.Allow(r.Context(), buckets, limit)
+			if err != nil {
+				log.Println("Failed to rate limit", strings.Join(buckets, ", ")+":", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !writeRateLimit(w, r, result) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeRateLimit reports the state of the bucket closest to turning the caller
+// down, and answers with 429 when it did.
+func writeRateLimit(w http.ResponseWriter, r *http.Request, result ratelimit.Result) bool {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+		writeError(w, r, errormanagement.RateLimited)
+	}
+	return result.Allowed
+}
+
+// allowPlayer takes a token for the class from the bucket of a player, for
+// what players do outside of a request, such as posting over a WebSocket.
+func (a *APIHandlers) allowPlayer(ctx context.Context, class string, playerID string) bool {
+	limit, ok := a.RateLimits[class]
+	if a.Limiter == nil || !ok {
+		return true
+	}
+	result, err := a.Limiter.Allow(ctx, []string{class + ":player:" + playerID}, limit)
+	if err != nil {
+		log.Println("Failed to rate limit", class+":player:"+playerID+":", err)
+		return true
+	}
+	return result.Allowed
+}
+
+// playerRouteClass sorts the routes of the player facing APIs.
+func playerRouteClass(r *http.Request) string {
+	switch {
+	case strings.Contains(r.URL.Path, "/sessions"):
+		return RouteClassSession
+	case strings.HasSuffix(r.URL.Path, "/messages") && r.Method == http.MethodPost:
+		return RouteClassChat
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return RouteClassRead
+	default:
+		return RouteClassWrite
+	}
+}
+
+func adminRouteClass(*http.Request) string {
+	return RouteClassAdmin
+}
+
+// Helper function to find the address of the client, which is only taken from
+// X-Forwarded-For behind a trusted proxy.
+func (a *APIHandlers) clientIP(r *http.Request) string {
+	if a.TrustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
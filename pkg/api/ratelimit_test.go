@@ -0,0 +1,46 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/ratelimit"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInvalidTokensAreChargedToTheAddress(t *testing.T) {
+	keys, err := auth.NewRandomKeyring()
+	if err != nil {
+		t.Fatalf("NewRandomKeyring: %v", err)
+	}
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	router := mux.NewRouter()
+	(&APIHandlers{
+		Sessions:   auth.NewSessions(keys, time.Hour, client, "test"),
+		Limiter:    ratelimit.NewMemoryLimiter(),
+		RateLimits: map[string]ratelimit.Limit{RouteClassWrite: limit},
+	}).RegisterRoutes(router)
+
+	for i := 0; i <= limit.Requests; i++ {
+		request := httptest.NewRequest(http.MethodPost, "/v2/rooms", nil)
+		request.Header.Set("Authorization", "Bearer not-a-token")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		want := http.StatusUnauthorized
+		if i == limit.Requests {
+			want = http.StatusTooManyRequests
+		}
+		if recorder.Code != want {
+			t.Fatalf("request %d with an invalid token = %d, want %d", i, recorder.Code, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/pkg/models"
+	"net/http"
+)
+
+// Helper functions to decode and validate the request bodies shared by the
+// v1 and v2 handlers.
+
+func readCreatePlayerRequest(r *http.Request) (*models.CreatePlayerRequest, error) {
+	var request models.CreatePlayerRequest
+	if err := decodeRequest(r, &request); err != nil {
+		return nil, err
+	}
+	requestData := struct {
+		PlayerId string `json:"player_id" validate:"required"`
+		Region   string `json:"region" validate:"required"`
+	}{request.PlayerId, request.Region}
+	return &request, validate.Struct(requestData)
+}
+
+func readCreateRoomRequest(r *http.Request) (*models.CreateRoomRequest, error) {
+	var request models.CreateRoomRequest
+	if err := decodeRequest(r, &request); err != nil {
+		return nil, err
+	}
+	if err := bindActingPlayer(r, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	requestData := struct {
+		PlayerID string `json:"player_id" validate:"required"`
+		Mode     string `json:"mode" validate:"required"`
+	}{request.PlayerId, request.Mode}
+	return &request, validate.Struct(requestData)
+}
+
+func readJoinRoomRequest(r *http.Request) (*models.JoinRoomRequest, error) {
+	var request models.JoinRoomRequest
+	if err := decodeRequest(r, &request); err != nil {
+		return nil, err
+	}
+	if err := bindActingPlayer(r, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	requestData := struct {
+		PlayerID string `json:"player_id" validate:"required"`
+		RoomID   string `json:"room_id" validate:"required,len=7"`
+	}{request.PlayerId, request.RoomId}
+	return &request, validate.Struct(requestData)
+}
+
+func readLeaveRoomRequest(r *http.Request) (*models.LeaveRoomRequest, error) {
+	var request models.LeaveRoomRequest
+	if err := decodeRequest(r, &request); err != nil {
+		return nil, err
+	}
+	if err := bindActingPlayer(r, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	requestData := struct {
+		PlayerId string `json:"player_id" validate:"required"`
+	}{request.PlayerId}
+	return &request, validate.Struct(requestData)
+}
+
+// Helper function to replace the player named in a request body with the acting
+// player of the session, so that authenticated clients may leave it out.
+func bindActingPlayer(r *http.Request, playerID *string) error {
+	acting, err := actingPlayer(r, *playerID)
+	if err != nil {
+		return err
+	}
+	*playerID = acting
+	return nil
+}
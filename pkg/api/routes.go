@@ -0,0 +1,99 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/pkg/auth"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// RegisterRoutes mounts the RPC-style v1 API under /api and the resource
+// oriented v2 API under /v2. Both share the same business logic and request
+// decoding; v1 routes that have a v2 successor announce their deprecation.
+// Both resolve session tokens to the acting player, but only v2 insists on one
+// whatever RequireAuth says. The /admin API is reserved to services holding an
+// API key with the scope of each route. Every route is rate limited with the
+// budget of its class, and the routes creating players and rooms or moving
+// players in and out of them honour Idempotency-Key.
+func (a *APIHandlers) RegisterRoutes(router *mux.Router) {
+	v1 := router.PathPrefix("/api").Subrouter()
+	v1.Use(a.limitAddress(playerRouteClass), a.Authenticate, a.rateLimit(playerRouteClass))
+	v1.HandleFunc("/createPlayer", deprecated("/v2/players", a.idempotentSecret(a.CreatePlayerHandler))).Methods("POST")
+	v1.HandleFunc("/createRoom", deprecated("/v2/rooms", a.requirePlayer(a.idempotent(a.CreateRoomHandler)))).Methods("POST")
+	v1.HandleFunc("/getRooms", deprecated("/v2/rooms", a.GetRoomsHandler)).Methods("GET")
+	v1.HandleFunc("/joinRoom", deprecated("/v2/rooms/{id}/players/{playerId}", a.requirePlayer(a.idempotent(a.JoinRoomHandler)))).Methods("POST")
+	v1.HandleFunc("/leaveRoom", deprecated("/v2/rooms/{id}/players/{playerId}", a.requirePlayer(a.idempotent(a.LeaveRoomHandler)))).Methods("POST")
+	v1.HandleFunc("/getModeTrendsByRegion", deprecated("/v2/trends", a.GetModeTrendsByRegion)).Methods("GET")
+	v1.HandleFunc("/getModeTrendsByRegionV2", deprecated("/v2/players/{id}/trends", a.GetModeTrendsByRegionV2)).Methods("GET")
+	v1.HandleFunc("/getTrendBreakdown", deprecated("/v2/trends/breakdown", a.GetTrendBreakdown)).Methods("GET")
+	v1.HandleFunc("/roomEvents", a.requirePlayer(a.RoomEventsHandler)).Methods("GET")
+	v1.HandleFunc("/stream", a.StreamHandler).Methods("GET")
+
+	v2 := router.PathPrefix("/v2").Subrouter()
+	v2.Use(a.limitAddress(playerRouteClass), a.Authenticate, a.rateLimit(playerRouteClass))
+	v2.HandleFunc("/sessions", a.LoginHandler).Methods("POST")
+	v2.HandleFunc("/sessions", requireSession(a.LogoutEverywhereHandler)).Methods("DELETE")
+	v2.HandleFunc("/sessions/current", requireSession(a.LogoutHandler)).Methods("DELETE")
+	v2.HandleFunc("/sessions/refresh", requireSession(a.RefreshSessionHandler)).Methods("POST")
+	v2.HandleFunc("/players", a.idempotentSecret(a.CreatePlayerV2Handler)).Methods("POST")
+	v2.HandleFunc("/players/{id}", a.GetPlayerV2Handler).Methods("GET")
+	v2.HandleFunc("/players/{id}", requireSession(a.UpdatePlayerV2Handler)).Methods("PATCH")
+	v2.HandleFunc("/players/{id}", requireSession(a.DeletePlayerV2Handler)).Methods("DELETE")
+	v2.HandleFunc("/players/{id}/trends", a.GetPlayerTrendsV2Handler).Methods("GET")
+	v2.HandleFunc("/players/{id}/heartbeat", requireSession(a.HeartbeatHandler)).Methods("POST")
+	v2.HandleFunc("/players/{id}/friends", requireSession(a.ListFriendsHandler)).Methods("GET")
+	v2.HandleFunc("/players/{id}/friends/{friendId}", requireSession(a.RemoveFriendHandler)).Methods("DELETE")
+	v2.HandleFunc("/players/{id}/friends/{friendId}/join", requireSession(a.idempotent(a.JoinFriendHandler))).Methods("POST")
+	v2.HandleFunc("/players/{id}/friend-requests", requireSession(a.ListFriendRequestsHandler)).Methods("GET")
+	v2.HandleFunc("/players/{id}/friend-requests/{friendId}", requireSession(a.SendFriendRequestHandler)).Methods("PUT")
+	v2.HandleFunc("/players/{id}/friend-requests/{friendId}", requireSession(a.DeclineFriendRequestHandler)).Methods("DELETE")
+	v2.HandleFunc("/players/{id}/friend-requests/{friendId}/accept", requireSession(a.AcceptFriendRequestHandler)).Methods("POST")
+	v2.HandleFunc("/players/{id}/blocks", requireSession(a.ListBlockedPlayersHandler)).Methods("GET")
+	v2.HandleFunc("/players/{id}/blocks/{blockedId}", requireSession(a.BlockPlayerHandler)).Methods("PUT")
+	v2.HandleFunc("/players/{id}/blocks/{blockedId}", requireSession(a.UnblockPlayerHandler)).Methods("DELETE")
+	v2.HandleFunc("/players/{id}/reports", requireSession(a.idempotent(a.ReportPlayerHandler))).Methods("POST")
+	v2.HandleFunc("/rooms", requireSession(a.idempotent(a.CreateRoomV2Handler))).Methods("POST")
+	v2.HandleFunc("/rooms", a.ListRoomsV2Handler).Methods("GET")
+	v2.HandleFunc("/rooms/{id}", a.GetRoomV2Handler).Methods("GET")
+	v2.HandleFunc("/rooms/{id}/players/{playerId}", requireSession(a.idempotent(a.JoinRoomV2Handler))).Methods("PUT")
+	v2.HandleFunc("/rooms/{id}/players/{playerId}", requireSession(a.idempotent(a.LeaveRoomV2Handler))).Methods("DELETE")
+	v2.HandleFunc("/rooms/{id}/messages", requireSession(a.PostChatMessageHandler)).Methods("POST")
+	v2.HandleFunc("/rooms/{id}/messages", requireSession(a.ChatHistoryHandler)).Methods("GET")
+	v2.HandleFunc("/regions", a.ListRegionsV2Handler).Methods("GET")
+	v2.HandleFunc("/trends", a.GetTrendsV2Handler).Methods("GET")
+	v2.HandleFunc("/trends/breakdown", a.GetTrendBreakdownV2Handler).Methods("GET")
+
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(a.limitAddress(adminRouteClass), a.AuthenticateService, a.rateLimit(adminRouteClass))
+	admin.HandleFunc("/rooms/{id}", requireScope(auth.ScopeRoomsAdmin, a.CloseRoomHandler)).Methods("DELETE")
+	admin.HandleFunc("/rooms/{id}/players/{playerId}", requireScope(auth.ScopeRoomsAdmin, a.KickPlayerHandler)).Methods("DELETE")
+	admin.HandleFunc("/players/{id}", requireScope(auth.ScopePlayersRead, a.GetPlayerAdminHandler)).Methods("GET")
+	admin.HandleFunc("/players/{id}", requireScope(auth.ScopePlayersAdmin, a.UpdatePlayerAdminHandler)).Methods("PATCH")
+	admin.HandleFunc("/players/{id}/secret", requireScope(auth.ScopePlayersAdmin, a.IssuePlayerSecretHandler)).Methods("POST")
+	admin.HandleFunc("/players/{id}/bans", requireScope(auth.ScopeModerationAdmin, a.BanPlayerHandler)).Methods("POST")
+	admin.HandleFunc("/players/{id}/bans", requireScope(auth.ScopeModerationRead, a.ListBansHandler)).Methods("GET")
+	admin.HandleFunc("/players/{id}/bans/{banId}", requireScope(auth.ScopeModerationAdmin, a.LiftBanHandler)).Methods("DELETE")
+	admin.HandleFunc("/reports", requireScope(auth.ScopeModerationRead, a.ListReportsHandler)).Methods("GET")
+	admin.HandleFunc("/reports/{id}", requireScope(auth.ScopeModerationRead, a.GetReportHandler)).Methods("GET")
+	admin.HandleFunc("/reports/{id}", requireScope(auth.ScopeModerationAdmin, a.UpdateReportHandler)).Methods("PATCH")
+	admin.HandleFunc("/domain-events", requireScope(auth.ScopeEventsRead, a.ListDomainEventsHandler)).Methods("GET")
+	admin.HandleFunc("/webhooks", requireScope(auth.ScopeWebhooksAdmin, a.CreateWebhookHandler)).Methods("POST")
+	admin.HandleFunc("/webhooks", requireScope(auth.ScopeWebhooksAdmin, a.ListWebhooksHandler)).Methods("GET")
+	admin.HandleFunc("/webhooks/{id}", requireScope(auth.ScopeWebhooksAdmin, a.GetWebhookHandler)).Methods("GET")
+	admin.HandleFunc("/webhooks/{id}", requireScope(auth.ScopeWebhooksAdmin, a.DeleteWebhookHandler)).Methods("DELETE")
+	admin.HandleFunc("/webhooks/{id}/deliveries", requireScope(auth.ScopeWebhooksAdmin, a.ListWebhookDeliveriesHandler)).Methods("GET")
+	admin.HandleFunc("/webhooks/{id}/deliveries/{deliveryId}/redeliver", requireScope(auth.ScopeWebhooksAdmin, a.RedeliverWebhookHandler)).Methods("POST")
+	admin.HandleFunc("/keys", requireScope(auth.ScopeKeysAdmin, a.CreateAPIKeyHandler)).Methods("POST")
+	admin.HandleFunc("/keys", requireScope(auth.ScopeKeysAdmin, a.ListAPIKeysHandler)).Methods("GET")
+	admin.HandleFunc("/keys/{id}", requireScope(auth.ScopeKeysAdmin, a.RevokeAPIKeyHandler)).Methods("DELETE")
+	admin.HandleFunc("/keys/{id}/audit", requireScope(auth.ScopeKeysAdmin, a.GetAuditTrailHandler)).Methods("GET")
+}
+
+// deprecated marks the responses of a v1 route with the Deprecation header
+// and a link to the v2 route replacing it.
+func deprecated(successor string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Add("Link", "<"+successor+">; rel=\"successor-version\"")
+		handler(w, r)
+	}
+}
@@ -0,0 +1,131 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/constants"
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/events"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	trendSnapshotInterval = 10 * time.Second
+	streamKeepAlive       = 15 * time.Second
+)
+
+// StreamHandler is a Server-Sent Events stream for lobbies and dashboards.
+// With mode it streams the room list changes of that mode, resuming after the
+// Last-Event-ID header when the browser reconnects. With region it sends a
+// trend snapshot of that region right away and then periodically.
+func (a *APIHandlers) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	mode := strings.ToLower(r.URL.Query().Get("mode"))
+	region := r.URL.Query().Get("region")
+	if mode == "" && region == "" {
+		writeError(w, r, errormanagement.MissingParameter)
+		return
+	}
+	if mode != "" && constants.ParseMode(mode) == constants.Unknown {
+		writeError(w, r, errormanagement.InvalidMode)
+		return
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID != "" && !events.ValidID(lastEventID) {
+		writeError(w, r, fmt.Errorf("%w: Last-Event-ID is not one of ours", errormanagement.InvalidParameter))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, errors.New("streaming is not supported by the response writer"))
+		return
+	}
+	// The stream outlives the server's write timeout.
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Subscribe before replaying so nothing falls between the log and the live feed.
+	var roomEvents <-chan events.Event
+	if mode != "" {
+		topic := events.LobbyTopic(mode)
+		subscription := a.Events.Subscribe(topic)
+		defer subscription.Close()
+		roomEvents = subscription.C
+
+		if lastEventID != "" {
+			missed, err := a.Events.Since(r.Context(), topic, lastEventID)
+			if errors.Is(err, events.ErrLogTrimmed) {
+				writeSSE(w, "", "reset", struct{}{})
+			} else if err == nil {
+				for _, event := range missed {
+					writeSSE(w, event.ID, "room", event)
+					lastEventID = event.ID
+				}
+			}
+		}
+	}
+
+	var trendTicks <-chan time.Time
+	if region != "" {
+		a.writeTrendSnapshot(w, region)
+		ticker := time.NewTicker(trendSnapshotInterval)
+		defer ticker.Stop()
+		trendTicks = ticker.C
+	}
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-roomEvents:
+			if !ok {
+				return
+			}
+			// Skip what the replay already delivered.
+			if lastEventID != "" && !events.After(event.ID, lastEventID) {
+				continue
+			}
+			writeSSE(w, event.ID, "room", event)
+		case <-trendTicks:
+			a.writeTrendSnapshot(w, region)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+func (a *APIHandlers) writeTrendSnapshot(w http.ResponseWriter, region string) {
+	trends, err := a.Logic.GetModeTrendsByRegion(logic.TrendQuery{Region: region, Limit: logic.MaxTrendLimit})
+	if err != nil {
+		return
+	}
+	writeSSE(w, "", "trends", struct {
+		Region string            `json:"region"`
+		Trends []logic.ModeTrend `json:"trends"`
+	}{Region: region, Trends: trends})
+}
+
+// Helper function to write a single Server-Sent Event.
+func writeSSE(w http.ResponseWriter, id string, event string, data interface{}) {
+	jsonData, _ := json.Marshal(data)
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, jsonData)
+}
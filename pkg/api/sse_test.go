@@ -0,0 +1,184 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/pkg/events"
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is one event read off a Server-Sent Events stream.
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// newTestStream serves the API with an event bus on a Redis of its own, and
+// returns the bus and the Redis client behind it.
+func newTestStream(t *testing.T) (*httptest.Server, *events.RedisBus, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	bus := events.NewRedisBus(client, "events:test", 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go bus.Run(ctx)
+	for deadline := time.Now().Add(time.Second); server.PubSubNumSub("events:test")["events:test"] == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("the event bus didn't subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	router := mux.NewRouter()
+	(&APIHandlers{Events: bus}).RegisterRoutes(router)
+	api := httptest.NewServer(router)
+	t.Cleanup(api.Close)
+	return api, bus, client
+}
+
+// Helper function to publish the creation of rooms of the mayhem lobby and
+// return the IDs the event log gave them.
+func publishRooms(t *testing.T, bus *events.RedisBus, client *redis.Client, roomIDs ...string) []string {
+	t.Helper()
+	ctx := context.Background()
+	for _, roomID := range roomIDs {
+		event := events.Event{Type: events.RoomCreated, RoomID: roomID, Mode: "mayhem", Timestamp: time.Now()}
+		if err := bus.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	logged, err := client.XRange(ctx, "events:test:log", "-", "+").Result()
+	if err != nil {
+		t.Fatalf("XRange: %v", err)
+	}
+	ids := make([]string, 0, len(roomIDs))
+	for _, message := range logged[len(logged)-len(roomIDs):] {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+// Helper function to follow the mayhem lobby from lastEventID.
+func openStream(t *testing.T, api *httptest.Server, lastEventID string) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, api.URL+"/api/stream?mode=mayhem", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := api.Client().Do(request)
+	if err != nil {
+		t.Fatalf("GET /api/stream: %v", err)
+	}
+	t.Cleanup(func() { response.Body.Close() })
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/stream = %d, want 200", response.StatusCode)
+	}
+	return bufio.NewReader(response.Body)
+}
+
+// Helper function to read the next event of a stream, skipping comments.
+func readSSE(t *testing.T, stream *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading the stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.event != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// Helper function to read a room event off a stream.
+func readRoomEvent(t *testing.T, stream *bufio.Reader) (string, events.Event) {
+	t.Helper()
+	sse := readSSE(t, stream)
+	if sse.event != "room" {
+		t.Fatalf("event = %+v, want a room event", sse)
+	}
+	var event events.Event
+	if err := json.Unmarshal([]byte(sse.data), &event); err != nil {
+		t.Fatalf("room event data %q: %v", sse.data, err)
+	}
+	return sse.id, event
+}
+
+func TestStreamReplaysAfterLastEventID(t *testing.T) {
+	api, bus, client := newTestStream(t)
+	ids := publishRooms(t, bus, client, "r1", "r2", "r3")
+
+	stream := openStream(t, api, ids[0])
+	for i, roomID := range []string{"r2", "r3"} {
+		id, event := readRoomEvent(t, stream)
+		if id != ids[i+1] || event.RoomID != roomID {
+			t.Fatalf("replayed event %d = %s %+v, want %s of %s", i, id, event, ids[i+1], roomID)
+		}
+	}
+
+	// Then it carries on with live events.
+	live := publishRooms(t, bus, client, "r4")
+	if id, event := readRoomEvent(t, stream); id != live[0] || event.RoomID != "r4" {
+		t.Fatalf("live event = %s %+v, want %s of r4", id, event, live[0])
+	}
+}
+
+func TestStreamResetsWhenTheLogWasTrimmed(t *testing.T) {
+	api, bus, client := newTestStream(t)
+	ids := publishRooms(t, bus, client, "r1", "r2", "r3")
+	if err := client.XTrimMaxLen(context.Background(), "events:test:log", 1).Err(); err != nil {
+		t.Fatalf("XTrimMaxLen: %v", err)
+	}
+
+	stream := openStream(t, api, ids[0])
+	if event := readSSE(t, stream); event.event != "reset" {
+		t.Fatalf("first event = %+v, want a reset", event)
+	}
+	live := publishRooms(t, bus, client, "r4")
+	if id, event := readRoomEvent(t, stream); id != live[0] || event.RoomID != "r4" {
+		t.Fatalf("event after the reset = %s %+v, want %s of r4", id, event, live[0])
+	}
+}
+
+func TestStreamRejectsForeignLastEventID(t *testing.T) {
+	api, _, _ := newTestStream(t)
+	request, err := http.NewRequest(http.MethodGet, api.URL+"/api/stream?mode=mayhem", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	request.Header.Set("Last-Event-ID", "not-an-id")
+	response, err := api.Client().Do(request)
+	if err != nil {
+		t.Fatalf("GET /api/stream: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("GET /api/stream with a foreign Last-Event-ID = %d, want 400", response.StatusCode)
+	}
+}
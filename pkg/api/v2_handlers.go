@@ -0,0 +1,255 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/idempotency"
+	"DeathfireArsenal/pkg/models"
+	"errors"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// The v2 handlers always answer with full resources wrapped in a "data"
+// envelope, as JSON unless the client accepts protobuf.
+
+func (a *APIHandlers) GetRoomV2Handler(w http.ResponseWriter, r *http.Request) {
+	// Get Room via Business
+	room, err := a.Logic.GetRoom(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.RoomResponse{Data: logic.RoomToProto(room)}, nil)
+}
+
+func (a *APIHandlers) ListRoomsV2Handler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseRoomQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Get a page of Rooms via Business
+	page, err := a.Logic.ListRooms(query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	setNextLink(w, r, page.NextCursor)
+	writeMessage(w, r, http.StatusOK, logic.RoomPageToProto(page), nil)
+}
+
+// ListRegionsV2Handler lists the region catalog, so that clients can offer the
+// regions players can pick.
+func (a *APIHandlers) ListRegionsV2Handler(w http.ResponseWriter, r *http.Request) {
+	writeMessage(w, r, http.StatusOK, logic.RegionsToProto(a.Logic.ListRegions()), nil)
+}
+
+func (a *APIHandlers) GetPlayerV2Handler(w http.ResponseWriter, r *http.Request) {
+	// Get Player via Business
+	player, err := a.Logic.GetPlayer(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.PlayerResponse{Data: logic.PlayerToProto(player)}, nil)
+}
+
+// UpdatePlayerV2Handler lets a player change its region and display name.
+func (a *APIHandlers) UpdatePlayerV2Handler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var request models.UpdatePlayerRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Update Player via Business
+	player, err := a.Logic.UpdateProfile(r.Context(), playerID, logic.ProfileUpdate{
+		Region:      request.Region,
+		DisplayName: request.DisplayName,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.PlayerResponse{Data: logic.PlayerToProto(player)}, nil)
+}
+
+// DeletePlayerV2Handler deletes the account of the player, and then ends all
+// of its sessions and forgets the responses kept for its Idempotency-Keys.
+func (a *APIHandlers) DeletePlayerV2Handler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Delete Player via Business
+	if err := a.Logic.DeletePlayer(r.Context(), playerID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := a.Sessions.RevokePlayer(r.Context(), playerID); err != nil {
+		log.Println("Failed to revoke the sessions of deleted player", playerID+":", err)
+	}
+	if a.Idempotency != nil {
+		if err := a.Idempotency.Forget(r.Context(), idempotency.PlayerScope(playerID)); err != nil {
+			log.Println("Failed to forget the idempotent requests of deleted player", playerID+":", err)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) CreatePlayerV2Handler(w http.ResponseWriter, r *http.Request) {
+	requestData, err := readCreatePlayerRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Create Player via Business
+	secret, err := a.Logic.CreatePlayer(r.Context(), requestData.PlayerId, requestData.Region)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	player, err := a.Logic.GetPlayer(requestData.PlayerId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	// The secret is only ever shown here, so answer with it even if no session could be started.
+	session, err := a.newSession(r, player.Id)
+	if err != nil {
+		log.Println("Failed to start a session for", player.Id+":", err)
+	}
+	w.Header().Set("Location", "/v2/players/"+player.Id)
+	writeMessage(w, r, http.StatusCreated, &models.PlayerResponse{
+		Data:    logic.PlayerToProto(player),
+		Secret:  secret,
+		Session: session,
+	}, nil)
+}
+
+func (a *APIHandlers) CreateRoomV2Handler(w http.ResponseWriter, r *http.Request) {
+	requestData, err := readCreateRoomRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Create Room via Business
+	roomID, err := a.Logic.CreateRoom(r.Context(), requestData.PlayerId, strings.ToLower(requestData.Mode), requestData.Private)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	room, err := a.Logic.GetRoom(roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/v2/rooms/"+room.ID)
+	writeMessage(w, r, http.StatusCreated, &models.RoomResponse{Data: logic.RoomToProto(room)}, nil)
+}
+
+// JoinRoomV2Handler puts the player in the room. Joining a room the player is
+// already in succeeds, so the request can safely be repeated.
+func (a *APIHandlers) JoinRoomV2Handler(w http.ResponseWriter, r *http.Request) {
+	roomID := mux.Vars(r)["id"]
+	playerID, err := actingPlayer(r, mux.Vars(r)["playerId"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Add Player to room via Business
+	err = a.Logic.JoinRoom(r.Context(), playerID, roomID)
+	if errors.Is(err, errormanagement.PlayerOccupied) {
+		if player, playerErr := a.Logic.GetPlayer(playerID); playerErr == nil && player.Room == roomID {
+			err = nil
+		}
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	room, err := a.Logic.GetRoom(roomID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.RoomResponse{Data: logic.RoomToProto(room)}, nil)
+}
+
+func (a *APIHandlers) LeaveRoomV2Handler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, mux.Vars(r)["playerId"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Remove player from Room via Business
+	err = a.Logic.LeaveRoomByID(r.Context(), playerID, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) GetTrendsV2Handler(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		writeError(w, r, errormanagement.MissingParameter)
+		return
+	}
+	query, err := parseTrendQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	query.Region = region
+
+	// Get ranked List of Mode via Business
+	modes, err := a.Logic.GetModeTrendsByRegion(query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.TrendListResponse{Data: logic.TrendsToProto(modes).Trends}, nil)
+}
+
+func (a *APIHandlers) GetPlayerTrendsV2Handler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseTrendQuery(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Get ranked List of Mode via Business
+	modes, err := a.Logic.GetModeTrendsByPlayerRegion(mux.Vars(r)["id"], query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.TrendListResponse{Data: logic.TrendsToProto(modes).Trends}, nil)
+}
+
+func (a *APIHandlers) GetTrendBreakdownV2Handler(w http.ResponseWriter, r *http.Request) {
+	// Get region x mode counts via Business
+	breakdown, err := a.Logic.GetTrendBreakdown()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.TrendBreakdownResponse{Data: logic.BreakdownToProto(breakdown)}, nil)
+}
@@ -0,0 +1,137 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/models"
+	"DeathfireArsenal/pkg/storage"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 500
+)
+
+func (a *APIHandlers) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var request models.CreateWebhookRequest
+	if err := decodeRequest(r, &request); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if request.Url == "" {
+		writeError(w, r, fmt.Errorf("%w: url is required", errormanagement.MissingParameter))
+		return
+	}
+
+	webhook := &storage.Webhook{
+		URL:         request.Url,
+		Events:      request.Events,
+		Secret:      request.Secret,
+		Description: request.Description,
+		CreatedBy:   moderatorOf(r),
+	}
+	if err := a.Webhooks.Create(r.Context(), webhook); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/admin/webhooks/"+webhook.ID)
+	writeMessage(w, r, http.StatusCreated, &models.WebhookResponse{Data: webhookToProto(webhook), Secret: webhook.Secret}, nil)
+}
+
+func (a *APIHandlers) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := a.Webhooks.List(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response := &models.WebhookListResponse{Data: []*models.Webhook{}}
+	for i := range webhooks {
+		response.Data = append(response.Data, webhookToProto(&webhooks[i]))
+	}
+	writeMessage(w, r, http.StatusOK, response, nil)
+}
+
+func (a *APIHandlers) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, err := a.Webhooks.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusOK, &models.WebhookResponse{Data: webhookToProto(webhook)}, nil)
+}
+
+func (a *APIHandlers) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if err := a.Webhooks.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *APIHandlers) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultDeliveryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxDeliveryLimit {
+			writeError(w, r, fmt.Errorf("%w: limit must be a number between 1 and %d", errormanagement.InvalidParameter, MaxDeliveryLimit))
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := a.Webhooks.Deliveries(r.Context(), mux.Vars(r)["id"], r.URL.Query().Get("state"), limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response := &models.WebhookDeliveryListResponse{Data: []*models.WebhookDelivery{}}
+	for i := range deliveries {
+		response.Data = append(response.Data, webhookDeliveryToProto(&deliveries[i]))
+	}
+	writeMessage(w, r, http.StatusOK, response, nil)
+}
+
+func (a *APIHandlers) RedeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	delivery, err := a.Webhooks.Redeliver(r.Context(), mux.Vars(r)["id"], mux.Vars(r)["deliveryId"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeMessage(w, r, http.StatusAccepted, &models.WebhookDeliveryResponse{Data: webhookDeliveryToProto(delivery)}, nil)
+}
+
+func webhookToProto(webhook *storage.Webhook) *models.Webhook {
+	return &models.Webhook{
+		Id:              webhook.ID,
+		Url:             webhook.URL,
+		Events:          webhook.Events,
+		Description:     webhook.Description,
+		CreatedBy:       webhook.CreatedBy,
+		CreatedAtUnixMs: webhook.CreatedAt.UnixMilli(),
+	}
+}
+
+func webhookDeliveryToProto(delivery *storage.WebhookDelivery) *models.WebhookDelivery {
+	message := &models.WebhookDelivery{
+		Id:              delivery.ID,
+		WebhookId:       delivery.WebhookID,
+		EventId:         delivery.EventID,
+		EventType:       delivery.EventType,
+		State:           delivery.State,
+		Attempts:        int32(delivery.Attempts),
+		LastStatus:      int32(delivery.LastStatus),
+		LastError:       delivery.LastError,
+		CreatedAtUnixMs: delivery.CreatedAt.UnixMilli(),
+		Body:            delivery.Body,
+	}
+	if delivery.State == storage.DeliveryPending && !delivery.NextAttemptAt.IsZero() {
+		message.NextAttemptAtUnixMs = delivery.NextAttemptAt.UnixMilli()
+	}
+	if !delivery.LastAttemptAt.IsZero() {
+		message.LastAttemptAtUnixMs = delivery.LastAttemptAt.UnixMilli()
+	}
+	return message
+}
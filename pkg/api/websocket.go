@@ -0,0 +1,169 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/events"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// Time allowed for the client to answer a ping.
+	pongWait = 60 * time.Second
+	// Pings are sent well within pongWait.
+	pingPeriod = 30 * time.Second
+	writeWait  = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// chatFrame is what a client sends over a room WebSocket to post in the chat.
+type chatFrame struct {
+	Text string `json:"text"`
+}
+
+// errorFrame tells a client why a chat frame was turned down.
+type errorFrame struct {
+	Type  string  `json:"type"`
+	Error Problem `json:"error"`
+}
+
+// RoomEventsHandler streams room events over a WebSocket. The player either
+// follows a room it is part of (room_id) or the room list of a mode (mode).
+// Chat messages are only streamed to, and can only be posted by, players
+// following their room with a session token; the stream of a room ends once
+// the player leaves it.
+func (a *APIHandlers) RoomEventsHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, err := actingPlayer(r, r.URL.Query().Get("player_id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	roomID := r.URL.Query().Get("room_id")
+	mode := strings.ToLower(r.URL.Query().Get("mode"))
+	if playerID == "" || (roomID == "") == (mode == "") {
+		writeError(w, r, fmt.Errorf("%w: send a player_id and either a room_id or a mode", errormanagement.MissingParameter))
+		return
+	}
+
+	// Authenticate the player for the requested topic via Business
+	var topic string
+	if roomID != "" {
+		topic = events.RoomTopic(roomID)
+		err = a.Logic.CheckRoomSubscription(playerID, roomID)
+	} else {
+		topic = events.LobbyTopic(mode)
+		err = a.Logic.CheckLobbySubscription(playerID, mode)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	_, hasSession := auth.FromContext(r.Context())
+	chatting := roomID != "" && hasSession
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied to the client.
+		return
+	}
+	defer conn.Close()
+
+	subscription := a.Events.Subscribe(topic)
+	defer subscription.Close()
+
+	// The read loop handles pongs, posts the chat frames and notices the
+	// client going away. Only the loop below writes to the connection; done
+	// tells the read loop it has stopped taking rejections.
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	rejections := make(chan errorFrame, 1)
+	reject := func(err error) bool {
+		select {
+		case rejections <- errorFrame{Type: "error", Error: newProblem(r.URL.Path, err)}:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if !chatting {
+				continue
+			}
+			var frame chatFrame
+			if err := json.Unmarshal(data, &frame); err != nil {
+				err = fmt.Errorf("%w: chat frames look like {\"text\": \"...\"}", errormanagement.MalformedRequest)
+				if !reject(err) {
+					return
+				}
+				continue
+			}
+			if !a.allowPlayer(r.Context(), RouteClassChat, playerID) {
+				if !reject(errormanagement.RateLimited) {
+					return
+				}
+				continue
+			}
+			// Post Chat Message via Business; the message comes back as an event.
+			if _, err := a.Logic.PostChatMessage(r.Context(), playerID, roomID, frame.Text); err != nil {
+				if !reject(err) {
+					return
+				}
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case rejection := <-rejections:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteJSON(rejection); err != nil {
+				return
+			}
+		case event, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+			if event.Type == events.ChatMessage && !chatting {
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+			if roomID != "" && event.EndsRoomStream(playerID) {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "left the room"), time.Now().Add(writeWait))
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
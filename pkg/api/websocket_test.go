@@ -0,0 +1,207 @@
+package api_handlers
+
+import (
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/cache"
+	"DeathfireArsenal/pkg/chat"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/storage"
+	"DeathfireArsenal/pkg/webhooks"
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsFrame is one frame read off a room WebSocket, or the error ending it.
+type wsFrame struct {
+	event events.Event
+	err   error
+}
+
+// newTestLogicAPI serves the API with business logic on a database of the
+// test's own on the MongoDB replica set of MONGODB_TEST_URL, and on a Redis of
+// its own. Tests needing MongoDB are skipped without it.
+func newTestLogicAPI(t *testing.T) (*httptest.Server, *logic.BusinessLogic) {
+	t.Helper()
+	mongoURL := os.Getenv("MONGODB_TEST_URL")
+	if mongoURL == "" {
+		t.Skip("MONGODB_TEST_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURL))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	database := client.Database("test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+
+	server := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	bus := events.NewRedisBus(redisClient, "events:test", 100)
+	runCtx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+	go bus.Run(runCtx)
+	for deadline := time.Now().Add(time.Second); server.PubSubNumSub("events:test")["events:test"] == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("the event bus didn't subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	catalog, err := regions.New([]regions.Region{{Code: "BLR", Name: "Bengaluru", Enabled: true}})
+	if err != nil {
+		t.Fatalf("regions.New: %v", err)
+	}
+	mongoDBStorage := storage.NewMongoDBStorage(database.Collection("rooms"), database.Collection("players"), database.Collection("trends"),
+		database.Collection("retiredplayerids"), database.Collection("friendships"), database.Collection("blocks"))
+	moderationStorage := storage.NewModerationStorage(database.Collection("matches"), database.Collection("reports"), database.Collection("bans"))
+	webhookStorage := storage.NewWebhookStorage(database.Collection("webhooks"), database.Collection("webhookoutbox"), database.Collection("webhookdeliveries"))
+	domainEventStorage := storage.NewDomainEventStorage(database.Collection("domainevents"))
+	for _, ensureIndexes := range []func(context.Context) error{
+		mongoDBStorage.EnsureIndexes,
+		moderationStorage.EnsureIndexes,
+		webhookStorage.EnsureIndexes,
+		domainEventStorage.EnsureIndexes,
+	} {
+		if err := ensureIndexes(ctx); err != nil {
+			t.Fatalf("EnsureIndexes: %v", err)
+		}
+	}
+
+	b := logic.NewBusinessLogic(mongoDBStorage,
+		cache.NewRedisCache(redisClient, "test"), cache.Policy{SoftTTL: time.Minute, HardTTL: time.Minute},
+		bus, logic.ReuseIDsImmediately, catalog, moderationStorage,
+		chat.NewHistory(redisClient, "chat:test", 10), chat.NewFilter(nil),
+		webhooks.NewRegistry(webhookStorage), domainEventStorage)
+	router := mux.NewRouter()
+	(&APIHandlers{Logic: b, Events: bus}).RegisterRoutes(router)
+	api := httptest.NewServer(router)
+	t.Cleanup(api.Close)
+	return api, b
+}
+
+// Helper function to put players of the test region in a new room, the first
+// of them hosting it.
+func createWSTestRoom(t *testing.T, b *logic.BusinessLogic, host string, others ...string) string {
+	t.Helper()
+	ctx := context.Background()
+	for _, id := range append([]string{host}, others...) {
+		if _, err := b.CreatePlayer(ctx, id, "BLR"); err != nil {
+			t.Fatalf("CreatePlayer(%s): %v", id, err)
+		}
+	}
+	roomID, err := b.CreateRoom(ctx, host, "mayhem", false)
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	for _, id := range others {
+		if err := b.JoinRoom(ctx, id, roomID); err != nil {
+			t.Fatalf("JoinRoom(%s): %v", id, err)
+		}
+	}
+	return roomID
+}
+
+// Helper function to follow a room as a player, returning the frames read off
+// the WebSocket once the server is streaming to it.
+func followRoom(t *testing.T, api *httptest.Server, playerID string, roomID string) <-chan wsFrame {
+	t.Helper()
+	query := url.Values{"player_id": {playerID}, "room_id": {roomID}}
+	address := "ws" + strings.TrimPrefix(api.URL, "http") + "/api/roomEvents?" + query.Encode()
+	conn, _, err := websocket.DefaultDialer.Dial(address, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// The server answers pings once it has subscribed to the room.
+	ponged := make(chan struct{})
+	conn.SetPongHandler(func(string) error {
+		close(ponged)
+		return nil
+	})
+	if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	frames := make(chan wsFrame, 10)
+	go func() {
+		defer close(frames)
+		for {
+			var event events.Event
+			err := conn.ReadJSON(&event)
+			frames <- wsFrame{event: event, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-ponged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server didn't answer the ping")
+	}
+	return frames
+}
+
+// Helper function to check the stream sends an event of the given type, then
+// closes normally.
+func expectLastEvent(t *testing.T, frames <-chan wsFrame, eventType events.Type) {
+	t.Helper()
+	next := func() wsFrame {
+		select {
+		case frame := <-frames:
+			return frame
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no frame after 5s, want %s then a close", eventType)
+			return wsFrame{}
+		}
+	}
+	if frame := next(); frame.err != nil || frame.event.Type != eventType {
+		t.Fatalf("frame = %+v, want a %s event", frame, eventType)
+	}
+	frame := next()
+	var closeError *websocket.CloseError
+	if !errors.As(frame.err, &closeError) || closeError.Code != websocket.CloseNormalClosure {
+		t.Fatalf("frame after %s = %+v, want a normal close", eventType, frame)
+	}
+}
+
+func TestRoomStreamEndsWhenThePlayerLeaves(t *testing.T) {
+	api, b := newTestLogicAPI(t)
+	roomID := createWSTestRoom(t, b, "Furious", "Valiant")
+	frames := followRoom(t, api, "Valiant", roomID)
+
+	if err := b.LeaveRoom(context.Background(), "Valiant"); err != nil {
+		t.Fatalf("LeaveRoom: %v", err)
+	}
+	expectLastEvent(t, frames, events.PlayerLeft)
+}
+
+func TestRoomStreamEndsWhenTheRoomIsClosed(t *testing.T) {
+	api, b := newTestLogicAPI(t)
+	roomID := createWSTestRoom(t, b, "Furious", "Valiant")
+	frames := followRoom(t, api, "Valiant", roomID)
+
+	if err := b.CloseRoom(context.Background(), roomID); err != nil {
+		t.Fatalf("CloseRoom: %v", err)
+	}
+	expectLastEvent(t, frames, events.RoomDeleted)
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scopes an API key can be granted.
+const (
+	ScopeRoomsAdmin   = "rooms:admin"
+	ScopePlayersRead  = "players:read"
+	ScopePlayersAdmin = "players:admin"
+	ScopeKeysAdmin    = "keys:admin"
+	// Reading the report queue and the bans of players, and acting on them.
+	ScopeModerationRead  = "moderation:read"
+	ScopeModerationAdmin = "moderation:admin"
+	// Managing webhooks and their deliveries.
+	ScopeWebhooksAdmin = "webhooks:admin"
+	// Reading the domain event log.
+	ScopeEventsRead = "events:read"
+)
+
+var AllScopes = []string{ScopeRoomsAdmin, ScopePlayersRead, ScopePlayersAdmin, ScopeKeysAdmin, ScopeModerationRead, ScopeModerationAdmin, ScopeWebhooksAdmin, ScopeEventsRead}
+
+const apiKeyPrefix = "dfa_"
+
+// APIKeys issues and checks the keys services authenticate with. A key reads
+// "dfa_<id>.<secret>"; the id locates it and only a hash of the secret is stored.
+type APIKeys struct {
+	store *storage.APIKeyStorage
+}
+
+func NewAPIKeys(store *storage.APIKeyStorage) *APIKeys {
+	return &APIKeys{store: store}
+}
+
+// Create stores a new key with the given scopes and returns it. The returned
+// string is the only copy of the full key.
+func (k *APIKeys) Create(ctx context.Context, name string, scopes []string) (string, *storage.APIKey, error) {
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return "", nil, fmt.Errorf("%w: unknown scope %q", errormanagement.InvalidParameter, scope)
+		}
+	}
+	if name == "" || len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: a key needs a name and at least one scope", errormanagement.MissingParameter)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+	secret, hash, err := NewSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	key := &storage.APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := k.store.CreateAPIKey(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return apiKeyPrefix + key.ID + "." + secret, key, nil
+}
+
+// Authenticate returns the stored key matching a full key. Unknown, malformed
+// and revoked keys are reported as errormanagement.Unauthorized.
+func (k *APIKeys) Authenticate(ctx context.Context, fullKey string) (*storage.APIKey, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(fullKey, apiKeyPrefix), ".")
+	if !ok || !strings.HasPrefix(fullKey, apiKeyPrefix) {
+		return nil, fmt.Errorf("%w: API key is malformed", errormanagement.Unauthorized)
+	}
+	key, err := k.store.GetAPIKey(ctx, id)
+	if errors.Is(err, errormanagement.APIKeyNotFound) {
+		return nil, fmt.Errorf("%w: API key is not valid", errormanagement.Unauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CheckSecret(secret, key.Hash) {
+		return nil, fmt.Errorf("%w: API key is not valid", errormanagement.Unauthorized)
+	}
+	if !key.RevokedAt.IsZero() {
+		return nil, fmt.Errorf("%w: API key has been revoked", errormanagement.Unauthorized)
+	}
+	return key, nil
+}
+
+func (k *APIKeys) List(ctx context.Context) ([]storage.APIKey, error) {
+	return k.store.ListAPIKeys(ctx)
+}
+
+func (k *APIKeys) Revoke(ctx context.Context, id string) error {
+	return k.store.RevokeAPIKey(ctx, id)
+}
+
+// RecordUse appends a request made with a key to its audit trail.
+func (k *APIKeys) RecordUse(ctx context.Context, entry storage.AuditEntry) error {
+	return k.store.RecordAPIKeyUse(ctx, entry)
+}
+
+// AuditTrail returns the latest uses of a key, newest first.
+func (k *APIKeys) AuditTrail(ctx context.Context, id string, limit int) ([]storage.AuditEntry, error) {
+	if _, err := k.store.GetAPIKey(ctx, id); err != nil {
+		return nil, err
+	}
+	return k.store.GetAuditTrail(ctx, id, limit)
+}
+
+// HasScope reports whether the key was granted scope.
+func HasScope(key *storage.APIKey, scope string) bool {
+	for _, granted := range key.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func validScope(scope string) bool {
+	for _, known := range AllScopes {
+		if known == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"DeathfireArsenal/pkg/storage"
+	"context"
+)
+
+type claimsKey struct{}
+
+// WithClaims returns a copy of ctx carrying the claims of an authenticated request.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the claims stored by WithClaims, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+type apiKeyKey struct{}
+
+// WithAPIKey returns a copy of ctx carrying the API key a request was made with.
+func WithAPIKey(ctx context.Context, key *storage.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// APIKeyFromContext returns the key stored by WithAPIKey, if any.
+func APIKeyFromContext(ctx context.Context) (*storage.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(*storage.APIKey)
+	return key, ok
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// MinKeyLength is the shortest signing key accepted, in bytes.
+const MinKeyLength = 32
+
+// Keyring holds the keys tokens are signed with. New tokens are signed with
+// the active key; tokens signed with any key of the ring are accepted, so a
+// key can be rotated out once the tokens it signed have expired.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// ParseKeyring reads a comma separated list of id:secret pairs. The active key
+// defaults to the first one of the list.
+func ParseKeyring(spec string, active string) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string][]byte)}
+	for _, pair := range strings.Split(spec, ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("auth: key %q is not of the form id:secret", pair)
+		}
+		if len(secret) < MinKeyLength {
+			return nil, fmt.Errorf("auth: key %q must be at least %d bytes long", id, MinKeyLength)
+		}
+		keyring.keys[id] = []byte(secret)
+		if keyring.active == "" {
+			keyring.active = id
+		}
+	}
+	if active != "" {
+		if _, ok := keyring.keys[active]; !ok {
+			return nil, fmt.Errorf("auth: active key %q is not in the keyring", active)
+		}
+		keyring.active = active
+	}
+	return keyring, nil
+}
+
+// NewRandomKeyring returns a keyring with a single random key. Tokens signed
+// with it do not survive a restart and are not accepted by other replicas.
+func NewRandomKeyring() (*Keyring, error) {
+	secret := make([]byte, MinKeyLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &Keyring{active: "random", keys: map[string][]byte{"random": secret}}, nil
+}
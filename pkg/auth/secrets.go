@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewSecret returns a random secret for a player along with the hash that is
+// stored in its place.
+func NewSecret() (secret string, hash string, err error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", "", err
+	}
+	secret = base64.RawURLEncoding.EncodeToString(data)
+	return secret, HashSecret(secret), nil
+}
+
+// HashSecret returns the stored form of a player secret. Secrets are random and
+// long, so a plain SHA-256 is enough.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckSecret reports whether secret matches the stored hash.
+func CheckSecret(secret string, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(hash)) == 1
+}
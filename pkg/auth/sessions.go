@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const issuer = "deathfire-arsenal"
+
+// Claims are the contents of a session token. Generation is the session
+// generation of the player when the token was issued; revoking every session
+// of a player moves it on.
+type Claims struct {
+	Subject    string `json:"sub"`
+	ID         string `json:"jti"`
+	Issuer     string `json:"iss"`
+	IssuedAt   int64  `json:"iat"`
+	ExpiresAt  int64  `json:"exp"`
+	Generation int64  `json:"gen"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Sessions issues and verifies HS256 JWTs bound to a player. Revocations are
+// kept in Redis so that every replica honours them.
+type Sessions struct {
+	keys   *Keyring
+	ttl    time.Duration
+	client *redis.Client
+	prefix string
+}
+
+func NewSessions(keys *Keyring, ttl time.Duration, client *redis.Client, prefix string) *Sessions {
+	return &Sessions{
+		keys:   keys,
+		ttl:    ttl,
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Issue signs a new token for the player with the active key.
+func (s *Sessions) Issue(ctx context.Context, playerID string) (string, *Claims, error) {
+	generation, err := s.generation(ctx, playerID)
+	if err != nil {
+		return "", nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		Subject:    playerID,
+		ID:         hex.EncodeToString(id),
+		Issuer:     issuer,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  now.Add(s.ttl).Unix(),
+		Generation: generation,
+	}
+	headerData, _ := json.Marshal(header{Algorithm: "HS256", Type: "JWT", KeyID: s.keys.active})
+	claimsData, _ := json.Marshal(claims)
+
+	signingInput := encodeSegment(headerData) + "." + encodeSegment(claimsData)
+	signature := sign(s.keys.keys[s.keys.active], signingInput)
+	return signingInput + "." + encodeSegment(signature), claims, nil
+}
+
+// Verify checks the signature, expiry and revocation of a token and returns
+// its claims. Every failure is reported as errormanagement.Unauthorized.
+func (s *Sessions) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: token is malformed", errormanagement.Unauthorized)
+	}
+
+	var tokenHeader header
+	if err := decodeSegment(parts[0], &tokenHeader); err != nil || tokenHeader.Algorithm != "HS256" {
+		return nil, fmt.Errorf("%w: token is malformed", errormanagement.Unauthorized)
+	}
+	key, ok := s.keys.keys[tokenHeader.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: token was signed with a retired key", errormanagement.Unauthorized)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, sign(key, parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("%w: token signature is invalid", errormanagement.Unauthorized)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.Issuer != issuer || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token is malformed", errormanagement.Unauthorized)
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("%w: token has expired", errormanagement.Unauthorized)
+	}
+
+	revoked, err := s.client.Exists(ctx, s.revokedKey(claims.ID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	generation, err := s.generation(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if revoked > 0 || claims.Generation < generation {
+		return nil, fmt.Errorf("%w: token has been revoked", errormanagement.Unauthorized)
+	}
+	return &claims, nil
+}
+
+// Revoke makes a single token unusable until it would have expired anyway.
+func (s *Sessions) Revoke(ctx context.Context, claims *Claims) error {
+	remaining := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if remaining <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.revokedKey(claims.ID), 1, remaining).Err()
+}
+
+// RevokePlayer makes every token issued to the player so far unusable.
+func (s *Sessions) RevokePlayer(ctx context.Context, playerID string) error {
+	return s.client.Incr(ctx, s.generationKey(playerID)).Err()
+}
+
+func (s *Sessions) generation(ctx context.Context, playerID string) (int64, error) {
+	value, err := s.client.Get(ctx, s.generationKey(playerID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (s *Sessions) revokedKey(tokenID string) string {
+	return s.prefix + ":revoked:" + tokenID
+}
+
+func (s *Sessions) generationKey(playerID string) string {
+	return s.prefix + ":generation:" + playerID
+}
+
+func sign(key []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Proto returns the protobuf message handed to the client for a token with these claims.
+func (c *Claims) Proto(token string) *models.Session {
+	return &models.Session{
+		Token:           token,
+		PlayerId:        c.Subject,
+		ExpiresAtUnixMs: c.ExpiresAt * 1000,
+	}
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	oldKey = "old:0123456789abcdef0123456789abcdef"
+	newKey = "new:fedcba9876543210fedcba9876543210"
+)
+
+func newTestSessions(t *testing.T, spec string, active string, ttl time.Duration) *Sessions {
+	t.Helper()
+	keys, err := ParseKeyring(spec, active)
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewSessions(keys, ttl, client, "test")
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	sessions := newTestSessions(t, oldKey, "", time.Hour)
+	ctx := context.Background()
+
+	token, issued, err := sessions.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	claims, err := sessions.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "Furious" || claims.ID != issued.ID {
+		t.Fatalf("Verify = %+v, want the claims of %+v", claims, issued)
+	}
+}
+
+func TestVerifyRejectsBadTokens(t *testing.T) {
+	sessions := newTestSessions(t, oldKey, "", time.Hour)
+	expiring := newTestSessions(t, oldKey, "", -time.Minute)
+	ctx := context.Background()
+
+	token, _, err := sessions.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	expired, _, err := expiring.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	other, _, err := sessions.Issue(ctx, "Valiant")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"expired", expired},
+		{"claims of another token", parts[0] + "." + strings.Split(other, ".")[1] + "." + parts[2]},
+		{"truncated signature", token[:len(token)-2]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := sessions.Verify(ctx, test.token); !errors.Is(err, errormanagement.Unauthorized) {
+				t.Fatalf("Verify = %v, want Unauthorized", err)
+			}
+		})
+	}
+}
+
+func TestRotateKeys(t *testing.T) {
+	before := newTestSessions(t, oldKey, "", time.Hour)
+	ctx := context.Background()
+	token, _, err := before.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	// Once the new key is active, tokens of the old one are still accepted.
+	keys, err := ParseKeyring(oldKey+","+newKey, "new")
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	rotated := NewSessions(keys, time.Hour, before.client, "test")
+	if _, err := rotated.Verify(ctx, token); err != nil {
+		t.Fatalf("Verify of a token of the old key: %v", err)
+	}
+	fresh, _, err := rotated.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := before.Verify(ctx, fresh); !errors.Is(err, errormanagement.Unauthorized) {
+		t.Fatalf("Verify of a token of a key not in the ring = %v, want Unauthorized", err)
+	}
+
+	// Until the old key is retired.
+	keys, err = ParseKeyring(newKey, "")
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	retired := NewSessions(keys, time.Hour, before.client, "test")
+	if _, err := retired.Verify(ctx, token); !errors.Is(err, errormanagement.Unauthorized) {
+		t.Fatalf("Verify of a token of a retired key = %v, want Unauthorized", err)
+	}
+	if _, err := retired.Verify(ctx, fresh); err != nil {
+		t.Fatalf("Verify of a token of the new key: %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	sessions := newTestSessions(t, oldKey, "", time.Hour)
+	ctx := context.Background()
+
+	revoked, claims, err := sessions.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	kept, _, err := sessions.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if err := sessions.Revoke(ctx, claims); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := sessions.Verify(ctx, revoked); !errors.Is(err, errormanagement.Unauthorized) {
+		t.Fatalf("Verify of a revoked token = %v, want Unauthorized", err)
+	}
+	if _, err := sessions.Verify(ctx, kept); err != nil {
+		t.Fatalf("Verify of another token of the player: %v", err)
+	}
+}
+
+func TestRevokePlayer(t *testing.T) {
+	sessions := newTestSessions(t, oldKey, "", time.Hour)
+	ctx := context.Background()
+
+	token, _, err := sessions.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	other, _, err := sessions.Issue(ctx, "Valiant")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if err := sessions.RevokePlayer(ctx, "Furious"); err != nil {
+		t.Fatalf("RevokePlayer: %v", err)
+	}
+	if _, err := sessions.Verify(ctx, token); !errors.Is(err, errormanagement.Unauthorized) {
+		t.Fatalf("Verify of a token of a revoked player = %v, want Unauthorized", err)
+	}
+	if _, err := sessions.Verify(ctx, other); err != nil {
+		t.Fatalf("Verify of a token of another player: %v", err)
+	}
+
+	// Tokens issued afterwards are accepted.
+	token, _, err = sessions.Issue(ctx, "Furious")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := sessions.Verify(ctx, token); err != nil {
+		t.Fatalf("Verify of a token issued after the revocation: %v", err)
+	}
+}
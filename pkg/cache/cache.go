@@ -6,33 +6,65 @@ import (
 	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 	"time"
 )
 
 var ErrCacheMiss = errors.New("cache miss")
 
 type Cache interface {
-	Set(ctx context.Context, key string, data interface{}, expiration time.Duration) error
+	Set(ctx context.Context, key string, data interface{}, expiration time.Duration, tags ...string) error
 	Get(ctx context.Context, key string, data interface{}) error
+	GetOrLoad(ctx context.Context, key string, data interface{}, policy Policy, load LoadFunc, tags ...string) error
+	Invalidate(ctx context.Context, tags ...string) error
 }
 
+// tagScript adds an entry to a tag set. A tag set has to outlive every entry
+// it points to, so its TTL is only ever extended, and a set that already lives
+// without one keeps living without one.
+var tagScript = redis.NewScript(`
+local existed = redis.call("EXISTS", KEYS[1])
+local ttl = redis.call("PTTL", KEYS[1])
+redis.call("SADD", KEYS[1], ARGV[1])
+local expiration = tonumber(ARGV[2])
+if expiration <= 0 then
+	redis.call("PERSIST", KEYS[1])
+elseif existed == 0 or (ttl >= 0 and ttl < expiration) then
+	redis.call("PEXPIRE", KEYS[1], expiration)
+end
+return 1
+`)
+
+// RedisCache stores every entry under its own namespace so that it can share a
+// Redis instance with other data. Entries can be tagged, and invalidation only
+// drops the entries carrying the given tags.
 type RedisCache struct {
-	client *redis.Client
+	client    *redis.Client
+	namespace string
+	group     singleflight.Group
 }
 
-func NewRedisCache(client *redis.Client) *RedisCache {
+func NewRedisCache(client *redis.Client, namespace string) *RedisCache {
 	return &RedisCache{
-		client: client,
+		client:    client,
+		namespace: namespace,
 	}
 }
 
-func (rc *RedisCache) Set(ctx context.Context, key string, data interface{}, expiration time.Duration) error {
+func (rc *RedisCache) Set(ctx context.Context, key string, data interface{}, expiration time.Duration, tags ...string) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data for cache: %w", err)
 	}
 
-	_, err = rc.client.Set(ctx, key, jsonData, expiration).Result()
+	namespacedKey := rc.key(key)
+	_, err = rc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, namespacedKey, jsonData, expiration)
+		for _, tag := range tags {
+			tagScript.Eval(ctx, pipe, []string{rc.tagKey(tag)}, namespacedKey, expiration.Milliseconds())
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to set data in cache: %w", err)
 	}
@@ -41,7 +73,7 @@ func (rc *RedisCache) Set(ctx context.Context, key string, data interface{}, exp
 }
 
 func (rc *RedisCache) Get(ctx context.Context, key string, data interface{}) error {
-	val, err := rc.client.Get(ctx, key).Result()
+	val, err := rc.client.Get(ctx, rc.key(key)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return ErrCacheMiss
@@ -57,6 +89,49 @@ func (rc *RedisCache) Get(ctx context.Context, key string, data interface{}) err
 	return nil
 }
 
-func (rc *RedisCache) Invalidate(ctx context.Context) error {
-	return rc.client.FlushAll(ctx).Err()
+// Invalidate drops every entry tagged with one of the given tags, along with
+// the tag sets themselves. Entries without any of those tags are left alone.
+func (rc *RedisCache) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		tagKey := rc.tagKey(tag)
+		keys, err := rc.client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read cache tag %s: %w", tag, err)
+		}
+		keys = append(keys, tagKey)
+		if err := rc.client.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to invalidate cache tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// Purge removes every key of the namespace, leaving the rest of Redis untouched.
+func (rc *RedisCache) Purge(ctx context.Context) error {
+	iter := rc.client.Scan(ctx, 0, rc.namespace+":*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 100 {
+			if err := rc.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return rc.client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
+
+func (rc *RedisCache) key(key string) string {
+	return rc.namespace + ":" + key
+}
+
+func (rc *RedisCache) tagKey(tag string) string {
+	return rc.namespace + ":tag:" + tag
 }
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCache(client, "test"), server
+}
+
+func TestSetWithTags(t *testing.T) {
+	rc, server := newTestCache(t)
+	ctx := context.Background()
+
+	if err := rc.Set(ctx, "rooms:solo", []string{"a", "b"}, time.Minute, "rooms", "mode:solo"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	var got []string
+	if err := rc.Get(ctx, "rooms:solo", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("Get = %v, want [a b]", got)
+	}
+	for _, tagKey := range []string{"test:tag:rooms", "test:tag:mode:solo"} {
+		members, err := server.SMembers(tagKey)
+		if err != nil {
+			t.Fatalf("SMembers(%s): %v", tagKey, err)
+		}
+		if len(members) != 1 || members[0] != "test:rooms:solo" {
+			t.Fatalf("%s = %v, want [test:rooms:solo]", tagKey, members)
+		}
+	}
+}
+
+func TestInvalidateLeavesUnrelatedKeys(t *testing.T) {
+	rc, server := newTestCache(t)
+	ctx := context.Background()
+
+	if err := rc.Set(ctx, "rooms:solo", "rooms", time.Minute, "rooms"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := rc.Set(ctx, "trends:BLR", "trends", time.Minute, "trends"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := rc.Set(ctx, "untagged", "untagged", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := rc.Invalidate(ctx, "rooms"); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+
+	var value string
+	if err := rc.Get(ctx, "rooms:solo", &value); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Get of an invalidated key = %v, want ErrCacheMiss", err)
+	}
+	if server.Exists("test:tag:rooms") {
+		t.Fatal("the invalidated tag set is still there")
+	}
+	for _, key := range []string{"trends:BLR", "untagged"} {
+		if err := rc.Get(ctx, key, &value); err != nil {
+			t.Fatalf("Get(%s) after an unrelated invalidation: %v", key, err)
+		}
+	}
+}
+
+func TestTagSetTTLIsOnlyExtended(t *testing.T) {
+	rc, server := newTestCache(t)
+	ctx := context.Background()
+
+	if err := rc.Set(ctx, "long", 1, time.Hour, "rooms"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := rc.Set(ctx, "short", 2, time.Minute, "rooms"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl := server.TTL("test:tag:rooms"); ttl != time.Hour {
+		t.Fatalf("tag set TTL = %v after a shorter entry, want %v", ttl, time.Hour)
+	}
+
+	if err := rc.Set(ctx, "longer", 3, 2*time.Hour, "rooms"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl := server.TTL("test:tag:rooms"); ttl != 2*time.Hour {
+		t.Fatalf("tag set TTL = %v after a longer entry, want %v", ttl, 2*time.Hour)
+	}
+
+	// The tag set still points to the long entry once the short one is gone.
+	server.FastForward(90 * time.Minute)
+	if err := rc.Invalidate(ctx, "rooms"); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	var value int
+	if err := rc.Get(ctx, "longer", &value); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Get of an invalidated key = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestTagSetWithoutTTLKeepsNone(t *testing.T) {
+	rc, server := newTestCache(t)
+	ctx := context.Background()
+
+	if err := rc.Set(ctx, "forever", 1, 0, "rooms"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := rc.Set(ctx, "short", 2, time.Minute, "rooms"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl := server.TTL("test:tag:rooms"); ttl != 0 {
+		t.Fatalf("tag set TTL = %v, want none", ttl)
+	}
+}
+
+func TestGetOrLoadOutlivesACallerGivingUp(t *testing.T) {
+	rc, _ := newTestCache(t)
+	policy := Policy{SoftTTL: time.Minute, HardTTL: time.Hour}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	load := func(ctx context.Context) (interface{}, error) {
+		once.Do(func() { close(started) })
+		select {
+		case <-release:
+			return "loaded", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		var value string
+		firstDone <- rc.GetOrLoad(first, "key", &value, policy, load)
+	}()
+	<-started
+
+	secondDone := make(chan error, 1)
+	var second string
+	go func() {
+		secondDone <- rc.GetOrLoad(context.Background(), "key", &second, policy, load)
+	}()
+
+	cancel()
+	if err := <-firstDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetOrLoad of the cancelled caller = %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-secondDone; err != nil {
+		t.Fatalf("GetOrLoad of the waiting caller: %v", err)
+	}
+	if second != "loaded" {
+		t.Fatalf("GetOrLoad = %q, want %q", second, "loaded")
+	}
+}
+
+func TestGetOrLoadReleasesOnlyItsOwnLock(t *testing.T) {
+	rc, server := newTestCache(t)
+	policy := Policy{SoftTTL: time.Minute, HardTTL: time.Hour, LockTTL: time.Second}
+	ctx := context.Background()
+
+	var value string
+	if err := rc.GetOrLoad(ctx, "released", &value, policy, func(ctx context.Context) (interface{}, error) {
+		return "loaded", nil
+	}); err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if server.Exists("test:lock:released") {
+		t.Fatal("the lock is still held after the load")
+	}
+
+	// The lock expires during a slow load and another replica takes it.
+	if err := rc.GetOrLoad(ctx, "slow", &value, policy, func(ctx context.Context) (interface{}, error) {
+		server.FastForward(policy.LockTTL)
+		if err := server.Set("test:lock:slow", "another replica"); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		return "loaded", nil
+	}); err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if got, err := server.Get("test:lock:slow"); err != nil || got != "another replica" {
+		t.Fatalf("lock = %q, %v, want the lock of the other replica", got, err)
+	}
+}
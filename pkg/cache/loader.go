@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Policy controls how GetOrLoad keeps an entry around.
+//
+// An entry is served as-is until SoftTTL. Between SoftTTL and HardTTL it is
+// still served, but the first read triggers a background refresh. After
+// HardTTL it is gone and the next read loads it synchronously.
+type Policy struct {
+	SoftTTL time.Duration
+	HardTTL time.Duration
+	// LockTTL enables a Redis lock around loads so that only one replica
+	// queries the storage for a key at a time. Zero disables the lock.
+	LockTTL time.Duration
+}
+
+// LoadFunc produces the value of a key when it is missing or stale.
+type LoadFunc func(ctx context.Context) (interface{}, error)
+
+// entry wraps a cached value with the moment it turns stale.
+type entry struct {
+	Value      json.RawMessage `json:"value"`
+	FreshUntil int64           `json:"fresh_until"`
+}
+
+const lockPollInterval = 25 * time.Millisecond
+
+// releaseLock deletes a load lock only while it still holds the token of the
+// replica releasing it. A load that outlives LockTTL would otherwise delete
+// the lock another replica has taken since.
+var releaseLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// loadTimeout bounds a load shared by several callers or run in the background,
+// since no single caller's context governs it.
+const loadTimeout = 10 * time.Second
+
+// GetOrLoad reads key into data. Concurrent misses for the same key within this
+// process share a single call to load, and stale entries are returned while a
+// single background refresh runs. The shared load runs on its own context, so
+// a caller giving up only stops waiting for it.
+func (rc *RedisCache) GetOrLoad(ctx context.Context, key string, data interface{}, policy Policy, load LoadFunc, tags ...string) error {
+	raw, err := rc.getOrLoadRaw(ctx, key, policy, load, tags)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, data)
+}
+
+func (rc *RedisCache) getOrLoadRaw(ctx context.Context, key string, policy Policy, load LoadFunc, tags []string) ([]byte, error) {
+	var cached entry
+	err := rc.Get(ctx, key, &cached)
+	if err == nil {
+		if time.Now().UnixNano() >= cached.FreshUntil {
+			rc.refreshInBackground(key, policy, load, tags)
+		}
+		return cached.Value, nil
+	} else if !errors.Is(err, ErrCacheMiss) {
+		return nil, err
+	}
+
+	results := rc.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+		return rc.load(ctx, key, policy, load, tags, true)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		if result.Val == nil {
+			return nil, ErrCacheMiss
+		}
+		return result.Val.([]byte), nil
+	}
+}
+
+func (rc *RedisCache) refreshInBackground(key string, policy Policy, load LoadFunc, tags []string) {
+	go rc.group.Do("refresh:"+key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+		return rc.load(ctx, key, policy, load, tags, false)
+	})
+}
+
+// load runs the loader and stores its result. When the Redis lock is held by
+// another replica, it either waits for that replica's result or, for
+// background refreshes, leaves the work to it and returns nil.
+func (rc *RedisCache) load(ctx context.Context, key string, policy Policy, load LoadFunc, tags []string, wait bool) ([]byte, error) {
+	if policy.LockTTL > 0 {
+		token, err := lockToken()
+		if err != nil {
+			return nil, err
+		}
+		lockKey := rc.key("lock:" + key)
+		acquired, err := rc.client.SetNX(ctx, lockKey, token, policy.LockTTL).Result()
+		if err == nil && !acquired {
+			if !wait {
+				return nil, nil
+			}
+			if value, ok := rc.waitForEntry(ctx, key, policy.LockTTL); ok {
+				return value, nil
+			}
+		} else if acquired {
+			defer releaseLock.Run(context.Background(), rc.client, []string{lockKey}, token)
+		}
+	}
+
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data for cache: %w", err)
+	}
+
+	fresh := entry{Value: raw, FreshUntil: time.Now().Add(policy.SoftTTL).UnixNano()}
+	hardTTL := policy.HardTTL
+	if hardTTL < policy.SoftTTL {
+		hardTTL = policy.SoftTTL
+	}
+	rc.Set(ctx, key, fresh, hardTTL, tags...)
+	return raw, nil
+}
+
+// waitForEntry polls for the entry another replica is loading, for at most timeout.
+func (rc *RedisCache) waitForEntry(ctx context.Context, key string, timeout time.Duration) ([]byte, bool) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-deadline.C:
+			return nil, false
+		case <-ticker.C:
+			var cached entry
+			if err := rc.Get(ctx, key, &cached); err == nil {
+				return cached.Value, true
+			}
+		}
+	}
+}
+
+// Helper function to tell the locks of different loads apart.
+func lockToken() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate a cache lock token: %w", err)
+	}
+	return hex.EncodeToString(data), nil
+}
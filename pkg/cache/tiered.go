@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// flushAllTag is published when a replica wipes the whole namespace.
+const flushAllTag = "*"
+
+const generationCheckInterval = 5 * time.Second
+
+// publishInvalidation bumps the generation counter and publishes the tags with
+// the new generation in one step, so messages go out in generation order.
+var publishInvalidation = redis.NewScript(`
+local generation = redis.call("INCR", KEYS[1])
+redis.call("PUBLISH", ARGV[1], generation .. "|" .. ARGV[2])
+return generation
+`)
+
+// TieredCache keeps an in-process L1 copy of the entries loaded through the
+// Redis L2. Invalidations are published on a Redis channel that every replica
+// subscribes to, and a generation counter lets a replica notice messages it
+// missed while disconnected, in which case it drops its whole L1.
+type TieredCache struct {
+	remote   *RedisCache
+	localTTL time.Duration
+
+	mu         sync.Mutex
+	entries    map[string]localEntry
+	tagIndex   map[string]map[string]struct{}
+	generation int64
+	// epoch counts the invalidations applied to L1, whether they came from
+	// this replica or another one. A load that overlaps one may have read
+	// what was invalidated, so it doesn't make it into L1.
+	epoch int64
+}
+
+type localEntry struct {
+	value     []byte
+	tags      []string
+	expiresAt time.Time
+}
+
+func NewTieredCache(remote *RedisCache, localTTL time.Duration) *TieredCache {
+	return &TieredCache{
+		remote:   remote,
+		localTTL: localTTL,
+		entries:  make(map[string]localEntry),
+		tagIndex: make(map[string]map[string]struct{}),
+	}
+}
+
+func (tc *TieredCache) Set(ctx context.Context, key string, data interface{}, expiration time.Duration, tags ...string) error {
+	return tc.remote.Set(ctx, key, data, expiration, tags...)
+}
+
+func (tc *TieredCache) Get(ctx context.Context, key string, data interface{}) error {
+	return tc.remote.Get(ctx, key, data)
+}
+
+// GetOrLoad serves key from L1 when present, and otherwise from Redis (or the
+// loader) through RedisCache.GetOrLoad, keeping a copy in L1 for localTTL.
+func (tc *TieredCache) GetOrLoad(ctx context.Context, key string, data interface{}, policy Policy, load LoadFunc, tags ...string) error {
+	if raw, ok := tc.getLocal(key); ok {
+		return json.Unmarshal(raw, data)
+	}
+
+	tc.mu.Lock()
+	epoch := tc.epoch
+	tc.mu.Unlock()
+
+	raw, err := tc.remote.getOrLoadRaw(ctx, key, policy, load, tags)
+	if err != nil {
+		return err
+	}
+	tc.setLocal(key, raw, tags, epoch)
+	return json.Unmarshal(raw, data)
+}
+
+// Invalidate drops the tags from Redis and from this replica's L1, then tells
+// every other replica to do the same.
+func (tc *TieredCache) Invalidate(ctx context.Context, tags ...string) error {
+	if err := tc.remote.Invalidate(ctx, tags...); err != nil {
+		return err
+	}
+	tc.dropLocal(tags)
+	return tc.publish(ctx, tags)
+}
+
+// Purge clears the Redis namespace and every replica's L1.
+func (tc *TieredCache) Purge(ctx context.Context) error {
+	if err := tc.remote.Purge(ctx); err != nil {
+		return err
+	}
+	tc.dropLocal([]string{flushAllTag})
+	return tc.publish(ctx, []string{flushAllTag})
+}
+
+// Run listens for invalidations published by the replicas until ctx is
+// cancelled. It also compares the generation counter periodically, which
+// catches messages lost while the subscription was reconnecting.
+func (tc *TieredCache) Run(ctx context.Context) {
+	pubsub := tc.remote.client.Subscribe(ctx, tc.channel())
+	defer pubsub.Close()
+
+	tc.checkGeneration(ctx)
+	messages := pubsub.Channel()
+	ticker := time.NewTicker(generationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			tc.handleMessage(message.Payload)
+		case <-ticker.C:
+			tc.checkGeneration(ctx)
+			tc.sweepLocal()
+		}
+	}
+}
+
+func (tc *TieredCache) publish(ctx context.Context, tags []string) error {
+	payload, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	err = publishInvalidation.Run(ctx, tc.remote.client, []string{tc.generationKey()}, tc.channel(), string(payload)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to publish cache invalidation: %w", err)
+	}
+	return nil
+}
+
+func (tc *TieredCache) handleMessage(payload string) {
+	generationPart, tagsPart, found := strings.Cut(payload, "|")
+	generation, err := strconv.ParseInt(generationPart, 10, 64)
+	var tags []string
+	if found && err == nil {
+		err = json.Unmarshal([]byte(tagsPart), &tags)
+	}
+	if err != nil {
+		log.Println("Dropping malformed cache invalidation:", payload)
+		return
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if generation != tc.generation+1 {
+		// We missed at least one message, so nothing in L1 can be trusted.
+		tc.clearLocked()
+	} else {
+		tc.dropLocked(tags)
+	}
+	tc.generation = generation
+}
+
+func (tc *TieredCache) checkGeneration(ctx context.Context) {
+	value, err := tc.remote.client.Get(ctx, tc.generationKey()).Int64()
+	if err != nil && err != redis.Nil {
+		log.Println("Failed to read cache generation:", err)
+		return
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if value != tc.generation {
+		tc.clearLocked()
+		tc.generation = value
+	}
+}
+
+func (tc *TieredCache) getLocal(key string) ([]byte, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	cached, ok := tc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(cached.expiresAt) {
+		tc.deleteLocked(key)
+		return nil, false
+	}
+	return cached.value, true
+}
+
+// setLocal stores an entry unless an invalidation arrived while it was being
+// loaded, in which case the loaded value may already be outdated.
+func (tc *TieredCache) setLocal(key string, value []byte, tags []string, loadedAt int64) {
+	if tc.localTTL <= 0 {
+		return
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.epoch != loadedAt {
+		return
+	}
+	tc.deleteLocked(key)
+	tc.entries[key] = localEntry{value: value, tags: tags, expiresAt: time.Now().Add(tc.localTTL)}
+	for _, tag := range tags {
+		if tc.tagIndex[tag] == nil {
+			tc.tagIndex[tag] = make(map[string]struct{})
+		}
+		tc.tagIndex[tag][key] = struct{}{}
+	}
+}
+
+func (tc *TieredCache) dropLocal(tags []string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.dropLocked(tags)
+}
+
+func (tc *TieredCache) sweepLocal() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	now := time.Now()
+	for key, cached := range tc.entries {
+		if now.After(cached.expiresAt) {
+			tc.deleteLocked(key)
+		}
+	}
+}
+
+func (tc *TieredCache) dropLocked(tags []string) {
+	tc.epoch++
+	for _, tag := range tags {
+		if tag == flushAllTag {
+			tc.clearLocked()
+			return
+		}
+		for key := range tc.tagIndex[tag] {
+			tc.deleteLocked(key)
+		}
+		delete(tc.tagIndex, tag)
+	}
+}
+
+func (tc *TieredCache) deleteLocked(key string) {
+	cached, ok := tc.entries[key]
+	if !ok {
+		return
+	}
+	delete(tc.entries, key)
+	for _, tag := range cached.tags {
+		delete(tc.tagIndex[tag], key)
+		if len(tc.tagIndex[tag]) == 0 {
+			delete(tc.tagIndex, tag)
+		}
+	}
+}
+
+func (tc *TieredCache) clearLocked() {
+	tc.epoch++
+	tc.entries = make(map[string]localEntry)
+	tc.tagIndex = make(map[string]map[string]struct{})
+}
+
+func (tc *TieredCache) channel() string {
+	return tc.remote.key("invalidations")
+}
+
+func (tc *TieredCache) generationKey() string {
+	return tc.remote.key("generation")
+}
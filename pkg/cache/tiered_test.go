@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+var tieredPolicy = Policy{SoftTTL: time.Minute, HardTTL: time.Hour}
+
+// newTestReplicas returns count tiered caches sharing one Redis namespace,
+// like replicas of the service do.
+func newTestReplicas(t *testing.T, count int) ([]*TieredCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	replicas := make([]*TieredCache, count)
+	for i := range replicas {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		replicas[i] = NewTieredCache(NewRedisCache(client, "test"), time.Minute)
+	}
+	return replicas, server
+}
+
+// Helper function to load key into the L1 of tc.
+func loadLocal(t *testing.T, tc *TieredCache, key string, value string, tags ...string) {
+	t.Helper()
+	var got string
+	err := tc.GetOrLoad(context.Background(), key, &got, tieredPolicy, func(ctx context.Context) (interface{}, error) {
+		return value, nil
+	}, tags...)
+	if err != nil {
+		t.Fatalf("GetOrLoad(%s): %v", key, err)
+	}
+	if _, ok := tc.getLocal(key); !ok {
+		t.Fatalf("%s is not in L1 after GetOrLoad", key)
+	}
+}
+
+func TestInvalidateReachesOtherReplicas(t *testing.T) {
+	replicas, server := newTestReplicas(t, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, replica := range replicas {
+		go replica.Run(ctx)
+	}
+	channel := replicas[0].channel()
+	for deadline := time.Now().Add(time.Second); server.PubSubNumSub(channel)[channel] < len(replicas); {
+		if time.Now().After(deadline) {
+			t.Fatal("the replicas didn't subscribe to invalidations")
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	for _, replica := range replicas {
+		loadLocal(t, replica, "rooms:solo", "rooms", "rooms")
+		loadLocal(t, replica, "trends:BLR", "trends", "trends")
+	}
+	if err := replicas[0].Invalidate(ctx, "rooms"); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(lockPollInterval) {
+		if _, ok := replicas[1].getLocal("rooms:solo"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the other replica still serves the invalidated entry")
+		}
+	}
+	for i, replica := range replicas {
+		if _, ok := replica.getLocal("rooms:solo"); ok {
+			t.Fatalf("replica %d still serves the invalidated entry", i)
+		}
+		if _, ok := replica.getLocal("trends:BLR"); !ok {
+			t.Fatalf("replica %d dropped an entry of another tag", i)
+		}
+	}
+}
+
+func TestLoadOverlappingAnInvalidationStaysOutOfL1(t *testing.T) {
+	replicas, _ := newTestReplicas(t, 1)
+	tc := replicas[0]
+	ctx := context.Background()
+
+	var got string
+	err := tc.GetOrLoad(ctx, "rooms:solo", &got, tieredPolicy, func(ctx context.Context) (interface{}, error) {
+		// The rooms change after the loader read them.
+		if err := tc.Invalidate(ctx, "rooms"); err != nil {
+			return nil, err
+		}
+		return "stale", nil
+	}, "rooms")
+	if err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if _, ok := tc.getLocal("rooms:solo"); ok {
+		t.Fatal("a value loaded before the invalidation made it into L1")
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	replicas, _ := newTestReplicas(t, 1)
+	tc := replicas[0]
+	loadLocal(t, tc, "rooms:solo", "rooms", "rooms")
+	loadLocal(t, tc, "trends:BLR", "trends", "trends")
+
+	tc.handleMessage(`1|["rooms"]`)
+	if _, ok := tc.getLocal("rooms:solo"); ok {
+		t.Fatal("the invalidated entry is still in L1")
+	}
+	if _, ok := tc.getLocal("trends:BLR"); !ok {
+		t.Fatal("an entry of another tag was dropped")
+	}
+
+	// Generation 2 got lost, so L1 can't tell what it missed.
+	tc.handleMessage(`3|["rooms"]`)
+	if _, ok := tc.getLocal("trends:BLR"); ok {
+		t.Fatal("L1 survived a gap in the generations")
+	}
+	if tc.generation != 3 {
+		t.Fatalf("generation = %d, want 3", tc.generation)
+	}
+
+	loadLocal(t, tc, "trends:BLR", "trends", "trends")
+	tc.handleMessage("not a message")
+	if _, ok := tc.getLocal("trends:BLR"); !ok || tc.generation != 3 {
+		t.Fatal("a malformed message changed L1")
+	}
+}
+
+func TestCheckGenerationResyncs(t *testing.T) {
+	replicas, server := newTestReplicas(t, 1)
+	tc := replicas[0]
+	ctx := context.Background()
+	loadLocal(t, tc, "rooms:solo", "rooms", "rooms")
+
+	tc.checkGeneration(ctx)
+	if _, ok := tc.getLocal("rooms:solo"); !ok {
+		t.Fatal("L1 was dropped although no invalidation was missed")
+	}
+
+	// Another replica published invalidations while this one was disconnected.
+	if err := server.Set(tc.generationKey(), "4"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	tc.checkGeneration(ctx)
+	if _, ok := tc.getLocal("rooms:solo"); ok {
+		t.Fatal("L1 survived missed invalidations")
+	}
+	if tc.generation != 4 {
+		t.Fatalf("generation = %d, want 4", tc.generation)
+	}
+
+	// The next message continues from the generation it caught up with.
+	loadLocal(t, tc, "rooms:solo", "rooms", "rooms")
+	loadLocal(t, tc, "trends:BLR", "trends", "trends")
+	tc.handleMessage(`5|["rooms"]`)
+	if _, ok := tc.getLocal("trends:BLR"); !ok {
+		t.Fatal("L1 was dropped after the resync")
+	}
+}
@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Filter masks the words of a word list in chat messages. Words match whole
+// and regardless of case, so that "Class" survives a filter holding "ass".
+type Filter struct {
+	words map[string]struct{}
+}
+
+// NewFilter builds a filter out of a word list. Blank entries are ignored.
+func NewFilter(words []string) *Filter {
+	filter := &Filter{words: make(map[string]struct{}, len(words))}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			filter.words[word] = struct{}{}
+		}
+	}
+	return filter
+}
+
+// LoadFilter reads a word list with one word per line. Lines starting with #
+// are comments.
+func LoadFilter(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewFilter(words), nil
+}
+
+// Clean replaces every listed word of text with as many asterisks, and
+// reports whether it replaced any.
+func (f *Filter) Clean(text string) (string, bool) {
+	if f == nil || len(f.words) == 0 {
+		return text, false
+	}
+	runes := []rune(text)
+	filtered := false
+	for start := 0; start < len(runes); {
+		if !isWordRune(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		if _, listed := f.words[strings.ToLower(string(runes[start:end]))]; listed {
+			for i := start; i < end; i++ {
+				runes[i] = '*'
+			}
+			filtered = true
+		}
+		start = end
+	}
+	if !filtered {
+		return text, false
+	}
+	return string(runes), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
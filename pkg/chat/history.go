@@ -0,0 +1,125 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"time"
+)
+
+// DefaultHistorySize is the number of messages kept per room.
+const DefaultHistorySize = 100
+
+// historyTTL drops the history of a room that went quiet, in case it
+// outlives its room.
+const historyTTL = 24 * time.Hour
+
+// Message is a chat message posted in a room. IDs grow with every message of
+// a room, so clients can merge the history with the live feed.
+type Message struct {
+	ID       int64     `json:"id"`
+	RoomID   string    `json:"room_id"`
+	PlayerID string    `json:"player_id"`
+	Text     string    `json:"text"`
+	Filtered bool      `json:"filtered,omitempty"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// History keeps the latest messages of each room in a Redis list.
+type History struct {
+	client *redis.Client
+	prefix string
+	size   int64
+}
+
+func NewHistory(client *redis.Client, prefix string, size int64) *History {
+	return &History{
+		client: client,
+		prefix: prefix,
+		size:   size,
+	}
+}
+
+// Append numbers a message and stores it, dropping the oldest messages of
+// the room beyond the size of the history.
+func (h *History) Append(ctx context.Context, message *Message) error {
+	id, err := h.client.Incr(ctx, h.sequenceKey(message.RoomID)).Result()
+	if err != nil {
+		return err
+	}
+	message.ID = id
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, h.messagesKey(message.RoomID), data)
+		pipe.LTrim(ctx, h.messagesKey(message.RoomID), -h.size, -1)
+		pipe.Expire(ctx, h.messagesKey(message.RoomID), historyTTL)
+		pipe.Expire(ctx, h.sequenceKey(message.RoomID), historyTTL)
+		return nil
+	})
+	return err
+}
+
+// Latest returns up to limit of the latest messages of a room, oldest first.
+func (h *History) Latest(ctx context.Context, roomID string, limit int) ([]Message, error) {
+	entries, err := h.client.LRange(ctx, h.messagesKey(roomID), -int64(limit), -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		var message Message
+		if err := json.Unmarshal([]byte(entry), &message); err != nil {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// Size is the number of messages kept per room.
+func (h *History) Size() int {
+	return int(h.size)
+}
+
+// Delete drops the history of a room.
+func (h *History) Delete(ctx context.Context, roomID string) error {
+	return h.client.Del(ctx, h.messagesKey(roomID), h.sequenceKey(roomID)).Err()
+}
+
+// Forget deletes the messages a player posted from the history of every room.
+func (h *History) Forget(ctx context.Context, playerID string) error {
+	iter := h.client.Scan(ctx, 0, h.prefix+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasSuffix(key, ":seq") {
+			continue
+		}
+		entries, err := h.client.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			var message Message
+			if err := json.Unmarshal([]byte(entry), &message); err != nil || message.PlayerID != playerID {
+				continue
+			}
+			if err := h.client.LRem(ctx, key, 0, entry).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return iter.Err()
+}
+
+func (h *History) messagesKey(roomID string) string {
+	return h.prefix + ":" + roomID
+}
+
+func (h *History) sequenceKey(roomID string) string {
+	return h.prefix + ":" + roomID + ":seq"
+}
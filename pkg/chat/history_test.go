@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+)
+
+func TestForgetDeletesOnlyThePlayersMessages(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	history := NewHistory(client, "chat", DefaultHistorySize)
+	ctx := context.Background()
+
+	for _, message := range []Message{
+		{RoomID: "room1", PlayerID: "alice", Text: "hi"},
+		{RoomID: "room1", PlayerID: "bob", Text: "hey"},
+		{RoomID: "room1", PlayerID: "alice", Text: "gg"},
+		{RoomID: "room2", PlayerID: "alice", Text: "again"},
+		{RoomID: "room2", PlayerID: "carol", Text: "yo"},
+	} {
+		message := message
+		if err := history.Append(ctx, &message); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	if err := history.Forget(ctx, "alice"); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+
+	for roomID, want := range map[string][]string{"room1": {"bob"}, "room2": {"carol"}} {
+		messages, err := history.Latest(ctx, roomID, DefaultHistorySize)
+		if err != nil {
+			t.Fatalf("Latest(%s): %v", roomID, err)
+		}
+		if len(messages) != len(want) {
+			t.Fatalf("%s has %d messages, want %d", roomID, len(messages), len(want))
+		}
+		for i, message := range messages {
+			if message.PlayerID != want[i] {
+				t.Fatalf("%s message %d is from %s, want %s", roomID, i, message.PlayerID, want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const subscriberBuffer = 32
+
+// DefaultLogSize is the approximate number of events kept for replay.
+const DefaultLogSize = 1000
+
+// ErrLogTrimmed is returned when the events following an ID are no longer
+// all in the log.
+var ErrLogTrimmed = errors.New("event log no longer holds the requested events")
+
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+	// Forget removes the logged events naming a player.
+	Forget(ctx context.Context, playerID string) error
+}
+
+// RedisBus publishes events on a Redis channel shared by every replica and
+// fans the events it receives out to the local subscribers of their topics.
+// Every logged event is also appended to a bounded Redis stream, whose entry
+// IDs become the event IDs, so that clients can catch up after reconnecting.
+type RedisBus struct {
+	client  *redis.Client
+	channel string
+	logKey  string
+	logSize int64
+
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+// Subscription receives the events of one topic on C until it is closed.
+type Subscription struct {
+	C     <-chan Event
+	c     chan Event
+	topic string
+	bus   *RedisBus
+}
+
+func NewRedisBus(client *redis.Client, channel string, logSize int64) *RedisBus {
+	return &RedisBus{
+		client:      client,
+		channel:     channel,
+		logKey:      channel + ":log",
+		logSize:     logSize,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func (rb *RedisBus) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if event.Logged() {
+		event.ID, err = rb.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: rb.logKey,
+			MaxLen: rb.logSize,
+			Approx: true,
+			Values: map[string]interface{}{"event": payload},
+		}).Result()
+		if err != nil {
+			return fmt.Errorf("failed to append event to log: %w", err)
+		}
+
+		payload, err = json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+	}
+	if err := rb.client.Publish(ctx, rb.channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// Since returns the logged events of a topic that came after lastID, oldest
+// first. It fails with ErrLogTrimmed when lastID has already been trimmed away.
+func (rb *RedisBus) Since(ctx context.Context, topic string, lastID string) ([]Event, error) {
+	oldest, err := rb.client.XRangeN(ctx, rb.logKey, "-", "+", 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+	if len(oldest) > 0 && compareIDs(oldest[0].ID, lastID) > 0 {
+		return nil, ErrLogTrimmed
+	}
+
+	messages, err := rb.client.XRange(ctx, rb.logKey, "("+lastID, "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+
+	var logged []Event
+	for _, message := range messages {
+		payload, ok := message.Values["event"].(string)
+		if !ok {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			continue
+		}
+		event.ID = message.ID
+		for _, eventTopic := range event.Topics() {
+			if eventTopic == topic {
+				logged = append(logged, event)
+				break
+			}
+		}
+	}
+	return logged, nil
+}
+
+// Forget deletes the entries of the event log that name a player, as the
+// subject or the host of a room. Clients catching up afterwards skip them.
+func (rb *RedisBus) Forget(ctx context.Context, playerID string) error {
+	const batchSize = 500
+	start := "-"
+	for {
+		messages, err := rb.client.XRangeN(ctx, rb.logKey, start, "+", batchSize).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read event log: %w", err)
+		}
+
+		var naming []string
+		for _, message := range messages {
+			payload, ok := message.Values["event"].(string)
+			if !ok {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				continue
+			}
+			if event.PlayerID == playerID || event.Host == playerID {
+				naming = append(naming, message.ID)
+			}
+		}
+		if len(naming) > 0 {
+			if err := rb.client.XDel(ctx, rb.logKey, naming...).Err(); err != nil {
+				return fmt.Errorf("failed to delete events: %w", err)
+			}
+		}
+		if len(messages) < batchSize {
+			return nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// Subscribe registers a local subscriber for a topic.
+func (rb *RedisBus) Subscribe(topic string) *Subscription {
+	c := make(chan Event, subscriberBuffer)
+	subscription := &Subscription{C: c, c: c, topic: topic, bus: rb}
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.subscribers[topic] == nil {
+		rb.subscribers[topic] = make(map[*Subscription]struct{})
+	}
+	rb.subscribers[topic][subscription] = struct{}{}
+	return subscription
+}
+
+// Close stops the delivery of events and closes C.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subscribers[s.topic][s]; !ok {
+		return
+	}
+	delete(s.bus.subscribers[s.topic], s)
+	if len(s.bus.subscribers[s.topic]) == 0 {
+		delete(s.bus.subscribers, s.topic)
+	}
+	close(s.c)
+}
+
+// Run receives the events published by every replica until ctx is cancelled.
+func (rb *RedisBus) Run(ctx context.Context) {
+	pubsub := rb.client.Subscribe(ctx, rb.channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event Event
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				log.Println("Dropping malformed event:", err)
+				continue
+			}
+			rb.dispatch(event)
+		}
+	}
+}
+
+// dispatch hands an event to the local subscribers. A subscriber that can't
+// keep up loses the event rather than blocking everyone else.
+func (rb *RedisBus) dispatch(event Event) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for _, topic := range event.Topics() {
+		for subscription := range rb.subscribers[topic] {
+			select {
+			case subscription.c <- event:
+			default:
+			}
+		}
+	}
+}
+
+// compareIDs orders two stream entry IDs ("<milliseconds>-<sequence>").
+func compareIDs(a string, b string) int {
+	aTime, aSeq := splitID(a)
+	bTime, bSeq := splitID(b)
+	switch {
+	case aTime != bTime:
+		if aTime < bTime {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func splitID(id string) (uint64, uint64) {
+	timePart, seqPart, _ := strings.Cut(id, "-")
+	t, _ := strconv.ParseUint(timePart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return t, seq
+}
+
+// After reports whether the event ID a comes after b.
+func After(a string, b string) bool {
+	return compareIDs(a, b) > 0
+}
+
+// ValidID reports whether id looks like an event ID.
+func ValidID(id string) bool {
+	timePart, seqPart, found := strings.Cut(id, "-")
+	if !found {
+		return false
+	}
+	if _, err := strconv.ParseUint(timePart, 10, 64); err != nil {
+		return false
+	}
+	_, err := strconv.ParseUint(seqPart, 10, 64)
+	return err == nil
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"DeathfireArsenal/pkg/chat"
+	"DeathfireArsenal/pkg/models"
+	"time"
+)
+
+type Type string
+
+const (
+	RoomCreated  Type = "room_created"
+	RoomDeleted  Type = "room_deleted"
+	PlayerJoined Type = "player_joined"
+	PlayerLeft   Type = "player_left"
+	HostChanged  Type = "host_changed"
+	StateChanged Type = "state_changed"
+	// ChatMessage carries a message posted in the room. It only goes to the
+	// members of the room, and the room fields are left out.
+	ChatMessage Type = "chat_message"
+)
+
+// Event describes a change to a room. PlayerCount, Capacity, Host and State
+// always reflect the room right after the change.
+type Event struct {
+	// ID is assigned when the event is appended to the event log.
+	ID          string    `json:"id,omitempty"`
+	Type        Type      `json:"type"`
+	RoomID      string    `json:"room_id"`
+	Mode        string    `json:"mode"`
+	PlayerID    string    `json:"player_id,omitempty"`
+	Host        string    `json:"host,omitempty"`
+	State       string    `json:"state,omitempty"`
+	PlayerCount int       `json:"player_count"`
+	Capacity    int       `json:"capacity"`
+	Timestamp   time.Time `json:"timestamp"`
+	// Only set on chat messages.
+	Chat *chat.Message `json:"chat,omitempty"`
+}
+
+// RoomTopic is the topic carrying every event of a single room.
+func RoomTopic(roomID string) string {
+	return "room:" + roomID
+}
+
+// LobbyTopic is the topic carrying the events of every room of a mode.
+func LobbyTopic(mode string) string {
+	return "lobby:" + mode
+}
+
+// Topics lists the topics an event is delivered on.
+func (e Event) Topics() []string {
+	if e.Type == ChatMessage {
+		return []string{RoomTopic(e.RoomID)}
+	}
+	return []string{RoomTopic(e.RoomID), LobbyTopic(e.Mode)}
+}
+
+// EndsRoomStream reports whether the event ends the stream of its room for a
+// player following it: the room is gone, or the player left it.
+func (e Event) EndsRoomStream(playerID string) bool {
+	return e.Type == RoomDeleted || (e.Type == PlayerLeft && e.PlayerID == playerID)
+}
+
+// Logged reports whether the event is appended to the event log. Chat
+// messages have a history of their own, kept per room.
+func (e Event) Logged() bool {
+	return e.Type != ChatMessage
+}
+
+// Proto converts the event to its protobuf message.
+func (e Event) Proto() *models.RoomEvent {
+	event := &models.RoomEvent{
+		Id:              e.ID,
+		Type:            string(e.Type),
+		RoomId:          e.RoomID,
+		Mode:            e.Mode,
+		PlayerId:        e.PlayerID,
+		Host:            e.Host,
+		State:           e.State,
+		PlayerCount:     int32(e.PlayerCount),
+		Capacity:        int32(e.Capacity),
+		TimestampUnixMs: e.Timestamp.UnixMilli(),
+	}
+	if e.Chat != nil {
+		event.Chat = ChatMessageProto(e.Chat)
+	}
+	return event
+}
+
+// ChatMessageProto converts a chat message to its protobuf message.
+func ChatMessageProto(message *chat.Message) *models.ChatMessage {
+	return &models.ChatMessage{
+		Id:           message.ID,
+		RoomId:       message.RoomID,
+		PlayerId:     message.PlayerID,
+		Text:         message.Text,
+		Filtered:     message.Filtered,
+		SentAtUnixMs: message.SentAt.UnixMilli(),
+	}
+}
@@ -0,0 +1,25 @@
+package events
+
+import "testing"
+
+func TestEndsRoomStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		ends  bool
+	}{
+		{"the player leaving", Event{Type: PlayerLeft, PlayerID: "Furious"}, true},
+		{"someone else leaving", Event{Type: PlayerLeft, PlayerID: "Valiant"}, false},
+		{"the room closing", Event{Type: RoomDeleted, PlayerID: "Valiant"}, true},
+		{"someone joining", Event{Type: PlayerJoined, PlayerID: "Valiant"}, false},
+		{"the player becoming host", Event{Type: HostChanged, Host: "Furious"}, false},
+		{"a chat message", Event{Type: ChatMessage, PlayerID: "Furious"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if ends := test.event.EndsRoomStream("Furious"); ends != test.ends {
+				t.Fatalf("EndsRoomStream = %t, want %t", ends, test.ends)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package grpc_handlers
+
+import (
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The block calls always act for the player of the session token.
+
+func (s *Server) ListBlockedPlayers(ctx context.Context, request *models.BlockTarget) (*models.BlockListResponse, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+
+	// Get Block List via Business
+	blocked, err := s.Logic.ListBlockedPlayers(ctx, request.PlayerId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return logic.BlockedPlayersToProto(blocked), nil
+}
+
+func (s *Server) BlockPlayer(ctx context.Context, request *models.BlockTarget) (*models.BlockResponse, error) {
+	if err := s.blockTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	// Block Player via Business
+	if err := s.Logic.BlockPlayer(ctx, request.PlayerId, request.BlockedId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.BlockResponse{}, nil
+}
+
+func (s *Server) UnblockPlayer(ctx context.Context, request *models.BlockTarget) (*models.BlockResponse, error) {
+	if err := s.blockTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	// Unblock Player via Business
+	if err := s.Logic.UnblockPlayer(ctx, request.PlayerId, request.BlockedId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.BlockResponse{}, nil
+}
+
+// Helper function to bind the acting player of a call naming the player to block.
+func (s *Server) blockTarget(ctx context.Context, request *models.BlockTarget) error {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return err
+	}
+	if request.BlockedId == "" {
+		return status.Error(codes.InvalidArgument, "blocked_id is required")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package grpc_handlers
+
+import (
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The chat calls act for the player of the session token, who must be in the
+// room.
+
+func (s *Server) PostChatMessage(ctx context.Context, request *models.PostChatMessageRequest) (*models.ChatMessage, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	if request.RoomId == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+
+	// Post Chat Message via Business
+	message, err := s.Logic.PostChatMessage(ctx, request.PlayerId, request.RoomId, request.Text)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return events.ChatMessageProto(message), nil
+}
+
+func (s *Server) GetChatHistory(ctx context.Context, request *models.ChatHistoryRequest) (*models.ChatHistoryResponse, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	if request.RoomId == "" {
+		return nil, status.Error(codes.InvalidArgument, "room_id is required")
+	}
+
+	// Get Chat History via Business
+	messages, err := s.Logic.ChatHistory(ctx, request.PlayerId, request.RoomId, int(request.Limit))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return logic.ChatHistoryToProto(messages), nil
+}
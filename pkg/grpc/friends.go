@@ -0,0 +1,126 @@
+package grpc_handlers
+
+import (
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The friend calls always act for the player of the session token.
+
+func (s *Server) ListFriends(ctx context.Context, request *models.FriendTarget) (*models.FriendListResponse, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+
+	// Get Friends via Business
+	friends, err := s.Logic.ListFriends(ctx, request.PlayerId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return logic.FriendsToProto(friends), nil
+}
+
+func (s *Server) ListFriendRequests(ctx context.Context, request *models.FriendTarget) (*models.FriendRequestListResponse, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+
+	// Get Friend Requests via Business
+	requests, err := s.Logic.ListFriendRequests(ctx, request.PlayerId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return logic.FriendRequestsToProto(requests), nil
+}
+
+func (s *Server) SendFriendRequest(ctx context.Context, request *models.FriendTarget) (*models.FriendRequestResponse, error) {
+	if err := s.friendTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	// Send Friend Request via Business
+	friends, err := s.Logic.SendFriendRequest(ctx, request.PlayerId, request.FriendId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.FriendRequestResponse{Friends: friends}, nil
+}
+
+func (s *Server) AcceptFriendRequest(ctx context.Context, request *models.FriendTarget) (*models.FriendRequestResponse, error) {
+	if err := s.friendTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	// Accept Friend Request via Business
+	if err := s.Logic.AcceptFriendRequest(ctx, request.PlayerId, request.FriendId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.FriendRequestResponse{Friends: true}, nil
+}
+
+func (s *Server) DeclineFriendRequest(ctx context.Context, request *models.FriendTarget) (*models.FriendRequestResponse, error) {
+	if err := s.friendTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	// Decline Friend Request via Business
+	if err := s.Logic.DeclineFriendRequest(ctx, request.PlayerId, request.FriendId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.FriendRequestResponse{Friends: false}, nil
+}
+
+func (s *Server) RemoveFriend(ctx context.Context, request *models.FriendTarget) (*models.FriendRequestResponse, error) {
+	if err := s.friendTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	// Remove Friend via Business
+	if err := s.Logic.RemoveFriend(ctx, request.PlayerId, request.FriendId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.FriendRequestResponse{Friends: false}, nil
+}
+
+func (s *Server) JoinFriend(ctx context.Context, request *models.FriendTarget) (*models.RoomResponse, error) {
+	if err := s.friendTarget(ctx, request); err != nil {
+		return nil, err
+	}
+
+	// Join the Friend's Room via Business
+	roomID, err := s.Logic.JoinFriend(ctx, request.PlayerId, request.FriendId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	room, err := s.Logic.GetRoom(roomID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.RoomResponse{Data: logic.RoomToProto(room)}, nil
+}
+
+func (s *Server) Heartbeat(ctx context.Context, request *models.HeartbeatRequest) (*models.HeartbeatResponse, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+
+	// Record Heartbeat via Business
+	if err := s.Logic.Heartbeat(ctx, request.PlayerId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.HeartbeatResponse{}, nil
+}
+
+// Helper function to bind the acting player of a call naming another player.
+func (s *Server) friendTarget(ctx context.Context, request *models.FriendTarget) error {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return err
+	}
+	if request.FriendId == "" {
+		return status.Error(codes.InvalidArgument, "friend_id is required")
+	}
+	return nil
+}
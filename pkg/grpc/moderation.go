@@ -0,0 +1,25 @@
+package grpc_handlers
+
+import (
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ReportPlayer(ctx context.Context, request *models.ReportPlayerRequest) (*models.ReportReceipt, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	if request.ReportedId == "" {
+		return nil, status.Error(codes.InvalidArgument, "reported_id is required")
+	}
+
+	// Report Player via Business
+	report, err := s.Logic.ReportPlayer(ctx, request.PlayerId, request.ReportedId, request.Category, request.Text)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return logic.ReportReceiptToProto(report), nil
+}
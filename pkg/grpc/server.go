@@ -0,0 +1,402 @@
+package grpc_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/internal/logic"
+	"DeathfireArsenal/internal/regions"
+	"DeathfireArsenal/pkg/auth"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/idempotency"
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"strings"
+	"time"
+)
+
+// Server implements the gRPC service on top of the same BusinessLogic as the
+// HTTP handlers.
+type Server struct {
+	models.UnimplementedDeathfireArsenalServer
+
+	Logic    *logic.BusinessLogic
+	Events   *events.RedisBus
+	Sessions *auth.Sessions
+	// Reject calls acting on behalf of a player without a session token.
+	RequireAuth bool
+	// Holds the responses of the HTTP API to forget when a player is deleted.
+	Idempotency *idempotency.Store
+}
+
+func (s *Server) CreatePlayer(ctx context.Context, request *models.CreatePlayerRequest) (*models.CreatePlayerResponse, error) {
+	if request.PlayerId == "" || request.Region == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id and region are required")
+	}
+
+	// Create Player via Business
+	secret, err := s.Logic.CreatePlayer(ctx, request.PlayerId, request.Region)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	// The secret is only ever shown here, so answer with it even if no session could be started.
+	var session *models.Session
+	if token, claims, err := s.Sessions.Issue(ctx, request.PlayerId); err == nil {
+		session = claims.Proto(token)
+	} else {
+		log.Println("Failed to start a session for", request.PlayerId+":", err)
+	}
+	return &models.CreatePlayerResponse{
+		Player:  &models.Player{Id: request.PlayerId, Region: regions.Normalize(request.Region)},
+		Secret:  secret,
+		Session: session,
+	}, nil
+}
+
+func (s *Server) Login(ctx context.Context, request *models.LoginRequest) (*models.Session, error) {
+	if request.PlayerId == "" || request.Secret == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id and secret are required")
+	}
+
+	// Check the secret via Business
+	if err := s.Logic.CheckPlayerSecret(request.PlayerId, request.Secret); err != nil {
+		return nil, toStatus(err)
+	}
+	token, claims, err := s.Sessions.Issue(ctx, request.PlayerId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return claims.Proto(token), nil
+}
+
+func (s *Server) GetPlayer(ctx context.Context, request *models.GetPlayerRequest) (*models.PlayerResponse, error) {
+	if request.PlayerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+
+	// Get Player via Business
+	player, err := s.Logic.GetPlayer(request.PlayerId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.PlayerResponse{Data: logic.PlayerToProto(player)}, nil
+}
+
+func (s *Server) UpdatePlayer(ctx context.Context, request *models.UpdatePlayerRequest) (*models.PlayerResponse, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+
+	// Update Player via Business
+	player, err := s.Logic.UpdateProfile(ctx, request.PlayerId, logic.ProfileUpdate{
+		Region:      request.Region,
+		DisplayName: request.DisplayName,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.PlayerResponse{Data: logic.PlayerToProto(player)}, nil
+}
+
+// DeletePlayer deletes the account of the player and ends all of its sessions.
+func (s *Server) DeletePlayer(ctx context.Context, request *models.DeletePlayerRequest) (*models.DeletePlayerResponse, error) {
+	if err := s.requireSession(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+
+	// Delete Player via Business
+	if err := s.Logic.DeletePlayer(ctx, request.PlayerId); err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.Sessions.RevokePlayer(ctx, request.PlayerId); err != nil {
+		log.Println("Failed to revoke the sessions of deleted player", request.PlayerId+":", err)
+	}
+	if s.Idempotency != nil {
+		if err := s.Idempotency.Forget(ctx, idempotency.PlayerScope(request.PlayerId)); err != nil {
+			log.Println("Failed to forget the idempotent requests of deleted player", request.PlayerId+":", err)
+		}
+	}
+	return &models.DeletePlayerResponse{}, nil
+}
+
+func (s *Server) ListRegions(ctx context.Context, request *models.ListRegionsRequest) (*models.RegionListResponse, error) {
+	return logic.RegionsToProto(s.Logic.ListRegions()), nil
+}
+
+func (s *Server) CreateRoom(ctx context.Context, request *models.CreateRoomRequest) (*models.CreateRoomResponse, error) {
+	if err := s.bindActingPlayer(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	if request.PlayerId == "" || request.Mode == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id and mode are required")
+	}
+
+	// Create Room via Business
+	roomID, err := s.Logic.CreateRoom(ctx, request.PlayerId, strings.ToLower(request.Mode), request.Private)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.CreateRoomResponse{RoomId: roomID}, nil
+}
+
+func (s *Server) GetRooms(ctx context.Context, request *models.GetRoomsRequest) (*models.GetRoomsResponse, error) {
+	if request.Mode == "" {
+		return nil, status.Error(codes.InvalidArgument, "mode is required")
+	}
+
+	query := logic.RoomQuery{
+		Mode:       strings.ToLower(request.Mode),
+		FreeSlots:  request.FreeSlots,
+		State:      strings.ToLower(request.State),
+		Region:     request.Region,
+		Visibility: strings.ToLower(request.Visibility),
+		Sort:       strings.ToLower(request.Sort),
+		Descending: request.Descending,
+		Limit:      int(request.Limit),
+		Cursor:     request.Cursor,
+	}
+	if request.CreatedAfterUnixMs > 0 {
+		query.CreatedAfter = time.UnixMilli(request.CreatedAfterUnixMs)
+	}
+
+	// Get a page of Rooms via Business
+	page, err := s.Logic.ListRooms(query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &models.GetRoomsResponse{RoomIds: []string{}, NextCursor: page.NextCursor}
+	for _, room := range page.Rooms {
+		response.RoomIds = append(response.RoomIds, room.ID)
+	}
+	return response, nil
+}
+
+func (s *Server) JoinRoom(ctx context.Context, request *models.JoinRoomRequest) (*models.JoinRoomResponse, error) {
+	if err := s.bindActingPlayer(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	if request.PlayerId == "" || len(request.RoomId) != 7 {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required and room_id must be 7 characters long")
+	}
+
+	// Add Player to room via Business
+	err := s.Logic.JoinRoom(ctx, request.PlayerId, request.RoomId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.JoinRoomResponse{}, nil
+}
+
+func (s *Server) LeaveRoom(ctx context.Context, request *models.LeaveRoomRequest) (*models.LeaveRoomResponse, error) {
+	if err := s.bindActingPlayer(ctx, &request.PlayerId); err != nil {
+		return nil, err
+	}
+	if request.PlayerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+
+	// Remove player from Room via Business
+	err := s.Logic.LeaveRoom(ctx, request.PlayerId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &models.LeaveRoomResponse{}, nil
+}
+
+func (s *Server) GetModeTrendsByRegion(ctx context.Context, request *models.GetModeTrendsByRegionRequest) (*models.ModeTrendsResponse, error) {
+	if request.Region == "" {
+		return nil, status.Error(codes.InvalidArgument, "region is required")
+	}
+	query, err := trendQuery(request.Mode, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+	query.Region = request.Region
+
+	// Get ranked List of Mode via Business
+	trends, err := s.Logic.GetModeTrendsByRegion(query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return logic.TrendsToProto(trends), nil
+}
+
+func (s *Server) GetModeTrendsByPlayerRegion(ctx context.Context, request *models.GetModeTrendsByPlayerRegionRequest) (*models.ModeTrendsResponse, error) {
+	if request.PlayerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+	query, err := trendQuery(request.Mode, request.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get ranked List of Mode via Business
+	trends, err := s.Logic.GetModeTrendsByPlayerRegion(request.PlayerId, query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return logic.TrendsToProto(trends), nil
+}
+
+func (s *Server) GetTrendBreakdown(ctx context.Context, request *models.GetTrendBreakdownRequest) (*models.TrendBreakdown, error) {
+	// Get region x mode counts via Business
+	breakdown, err := s.Logic.GetTrendBreakdown()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return logic.BreakdownToProto(breakdown), nil
+}
+
+func (s *Server) StreamRoomEvents(request *models.StreamRoomEventsRequest, stream models.DeathfireArsenal_StreamRoomEventsServer) error {
+	if err := s.bindActingPlayer(stream.Context(), &request.PlayerId); err != nil {
+		return err
+	}
+	if request.PlayerId == "" {
+		return status.Error(codes.InvalidArgument, "player_id is required")
+	}
+
+	// Authenticate the player for the requested topic via Business
+	var topic string
+	var err error
+	switch target := request.Target.(type) {
+	case *models.StreamRoomEventsRequest_RoomId:
+		topic = events.RoomTopic(target.RoomId)
+		err = s.Logic.CheckRoomSubscription(request.PlayerId, target.RoomId)
+	case *models.StreamRoomEventsRequest_Mode:
+		mode := strings.ToLower(target.Mode)
+		topic = events.LobbyTopic(mode)
+		err = s.Logic.CheckLobbySubscription(request.PlayerId, mode)
+	default:
+		return status.Error(codes.InvalidArgument, "either room_id or mode is required")
+	}
+	if err != nil {
+		return toStatus(err)
+	}
+
+	// Chat is only streamed to players following their room with a session
+	// token, and the stream of a room ends once the player leaves it.
+	roomID := request.GetRoomId()
+	claims, err := s.sessionClaims(stream.Context())
+	if err != nil {
+		return err
+	}
+	chatting := roomID != "" && claims != nil
+
+	subscription := s.Events.Subscribe(topic)
+	defer subscription.Close()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-subscription.C:
+			if !ok {
+				return nil
+			}
+			if event.Type == events.ChatMessage && !chatting {
+				continue
+			}
+			if err := stream.Send(event.Proto()); err != nil {
+				return err
+			}
+			if roomID != "" && event.EndsRoomStream(request.PlayerId) {
+				return nil
+			}
+		}
+	}
+}
+
+// bindActingPlayer replaces the player named in a request with the player of
+// the session token in the "authorization" metadata, if there is one. Naming
+// someone else is forbidden, and so are anonymous calls once RequireAuth is set.
+func (s *Server) bindActingPlayer(ctx context.Context, playerID *string) error {
+	claims, err := s.sessionClaims(ctx)
+	if err != nil {
+		return err
+	}
+	if claims == nil {
+		if s.RequireAuth {
+			return toStatus(errormanagement.Unauthorized)
+		}
+		return nil
+	}
+	if *playerID != "" && *playerID != claims.Subject {
+		return toStatus(errormanagement.Forbidden)
+	}
+	*playerID = claims.Subject
+	return nil
+}
+
+// requireSession is bindActingPlayer for calls that always need a session
+// token, whatever RequireAuth says.
+func (s *Server) requireSession(ctx context.Context, playerID *string) error {
+	claims, err := s.sessionClaims(ctx)
+	if err != nil {
+		return err
+	}
+	if claims == nil {
+		return toStatus(errormanagement.Unauthorized)
+	}
+	if *playerID != "" && *playerID != claims.Subject {
+		return toStatus(errormanagement.Forbidden)
+	}
+	*playerID = claims.Subject
+	return nil
+}
+
+// Helper function to verify the session token in the "authorization" metadata.
+// It returns nil claims when there is no token.
+func (s *Server) sessionClaims(ctx context.Context) (*auth.Claims, error) {
+	token := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			scheme, value, found := strings.Cut(values[0], " ")
+			if found && strings.EqualFold(scheme, "Bearer") {
+				token = strings.TrimSpace(value)
+			}
+		}
+	}
+	if token == "" {
+		return nil, nil
+	}
+
+	claims, err := s.Sessions.Verify(ctx, token)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return claims, nil
+}
+
+// toStatus converts an error of the business layer to a gRPC status that
+// carries the stable error code as an ErrorInfo reason.
+func toStatus(err error) error {
+	var businessError *errormanagement.Error
+	if !errors.As(err, &businessError) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := errormanagement.StatusOf(businessError).GRPC
+	st, detailErr := status.New(code, err.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason: businessError.Code,
+		Domain: "deathfire-arsenal",
+	})
+	if detailErr != nil {
+		return status.Error(code, err.Error())
+	}
+	return st.Err()
+}
+
+func trendQuery(mode string, limit int32) (logic.TrendQuery, error) {
+	query := logic.TrendQuery{Mode: strings.ToLower(mode), Limit: int(limit)}
+	if query.Limit == 0 {
+		query.Limit = logic.DefaultTrendLimit
+	}
+	if query.Limit < 1 || query.Limit > logic.MaxTrendLimit {
+		return query, status.Errorf(codes.InvalidArgument, "limit must be a number between 1 and %d", logic.MaxTrendLimit)
+	}
+	return query, nil
+}
@@ -0,0 +1,50 @@
+package grpc_handlers
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"errors"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{"a missing player", errormanagement.PlayerNotFound, codes.NotFound, "player_not_found"},
+		{"a refused join", errormanagement.RoomUnavailable, codes.FailedPrecondition, "room_unavailable"},
+		{"a spent budget", errormanagement.RateLimited, codes.ResourceExhausted, "rate_limited"},
+		{"a wrapped error", fmt.Errorf("%w: send a player_id", errormanagement.MissingParameter), codes.InvalidArgument, "missing_parameter"},
+		{"a failure", errors.New("connection refused"), codes.Internal, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st, ok := status.FromError(toStatus(test.err))
+			if !ok {
+				t.Fatal("toStatus didn't return a status")
+			}
+			if st.Code() != test.code {
+				t.Errorf("code = %s, want %s", st.Code(), test.code)
+			}
+			if st.Message() != test.err.Error() {
+				t.Errorf("message = %q, want %q", st.Message(), test.err.Error())
+			}
+
+			var reason string
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.ErrorInfo); ok {
+					reason = info.Reason
+				}
+			}
+			if reason != test.reason {
+				t.Errorf("ErrorInfo reason = %q, want %q", reason, test.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInProgress is returned while the first request with a key is still being served.
+	ErrInProgress = errors.New("idempotency: a request with this key is in progress")
+	// ErrMismatch is returned when a key is reused for a different request.
+	ErrMismatch = errors.New("idempotency: the key was used for a different request")
+)
+
+// Record is the stored outcome of the first request made with a key. While
+// that request is being served only Pending and Fingerprint are set.
+type Record struct {
+	Pending     bool                `json:"pending,omitempty"`
+	Fingerprint string              `json:"fingerprint"`
+	Status      int                 `json:"status,omitempty"`
+	Header      map[string][]string `json:"header,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+	// Withheld marks a successful response that held a secret. Only its status
+	// is kept, and retries are told the response can't be replayed.
+	Withheld bool `json:"withheld,omitempty"`
+}
+
+// Store keeps the records in Redis. A pending record expires after lockTTL,
+// so that a replica dying mid-request does not block the key forever, and a
+// completed one after ttl.
+type Store struct {
+	client  *redis.Client
+	prefix  string
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+func NewStore(client *redis.Client, prefix string, ttl time.Duration, lockTTL time.Duration) *Store {
+	return &Store{
+		client:  client,
+		prefix:  prefix,
+		ttl:     ttl,
+		lockTTL: lockTTL,
+	}
+}
+
+// Begin claims key for a request. It returns nil when the caller should serve
+// the request and then Complete or Abort it, or the record of the first
+// request to replay. Concurrent and mismatching requests get ErrInProgress
+// and ErrMismatch.
+func (s *Store) Begin(ctx context.Context, key string, fingerprint string) (*Record, error) {
+	pending, _ := json.Marshal(Record{Pending: true, Fingerprint: fingerprint})
+	claimed, err := s.client.SetNX(ctx, s.prefix+":"+key, pending, s.lockTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	data, err := s.client.Get(ctx, s.prefix+":"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The record expired in between; let the client try again.
+		return nil, ErrInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+	if record.Pending {
+		return nil, ErrInProgress
+	}
+	return &record, nil
+}
+
+// Complete stores the outcome of the request that claimed key.
+func (s *Store) Complete(ctx context.Context, key string, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+":"+key, data, s.ttl).Err()
+}
+
+// Abort releases key without storing an outcome, so that the request can be retried.
+func (s *Store) Abort(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+":"+key).Err()
+}
+
+// PlayerScope is the prefix of the keys of the requests a player makes.
+func PlayerScope(playerID string) string {
+	return "player:" + playerID + ":"
+}
+
+// Forget deletes every record whose key starts with prefix, such as the
+// records of a client that is going away.
+func (s *Store) Forget(ctx context.Context, prefix string) error {
+	pattern := s.prefix + ":" + globEscaper.Replace(prefix) + "*"
+	iter := s.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		if err := s.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
@@ -0,0 +1,1156 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.23.4
+// source: service.proto
+
+package models
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	DeathfireArsenal_CreatePlayer_FullMethodName                = "/model.DeathfireArsenal/CreatePlayer"
+	DeathfireArsenal_CreateRoom_FullMethodName                  = "/model.DeathfireArsenal/CreateRoom"
+	DeathfireArsenal_GetRooms_FullMethodName                    = "/model.DeathfireArsenal/GetRooms"
+	DeathfireArsenal_JoinRoom_FullMethodName                    = "/model.DeathfireArsenal/JoinRoom"
+	DeathfireArsenal_LeaveRoom_FullMethodName                   = "/model.DeathfireArsenal/LeaveRoom"
+	DeathfireArsenal_GetModeTrendsByRegion_FullMethodName       = "/model.DeathfireArsenal/GetModeTrendsByRegion"
+	DeathfireArsenal_GetModeTrendsByPlayerRegion_FullMethodName = "/model.DeathfireArsenal/GetModeTrendsByPlayerRegion"
+	DeathfireArsenal_GetTrendBreakdown_FullMethodName           = "/model.DeathfireArsenal/GetTrendBreakdown"
+	DeathfireArsenal_GetPlayer_FullMethodName                   = "/model.DeathfireArsenal/GetPlayer"
+	DeathfireArsenal_UpdatePlayer_FullMethodName                = "/model.DeathfireArsenal/UpdatePlayer"
+	DeathfireArsenal_DeletePlayer_FullMethodName                = "/model.DeathfireArsenal/DeletePlayer"
+	DeathfireArsenal_ListFriends_FullMethodName                 = "/model.DeathfireArsenal/ListFriends"
+	DeathfireArsenal_ListFriendRequests_FullMethodName          = "/model.DeathfireArsenal/ListFriendRequests"
+	DeathfireArsenal_SendFriendRequest_FullMethodName           = "/model.DeathfireArsenal/SendFriendRequest"
+	DeathfireArsenal_AcceptFriendRequest_FullMethodName         = "/model.DeathfireArsenal/AcceptFriendRequest"
+	DeathfireArsenal_DeclineFriendRequest_FullMethodName        = "/model.DeathfireArsenal/DeclineFriendRequest"
+	DeathfireArsenal_RemoveFriend_FullMethodName                = "/model.DeathfireArsenal/RemoveFriend"
+	DeathfireArsenal_JoinFriend_FullMethodName                  = "/model.DeathfireArsenal/JoinFriend"
+	DeathfireArsenal_Heartbeat_FullMethodName                   = "/model.DeathfireArsenal/Heartbeat"
+	DeathfireArsenal_ListBlockedPlayers_FullMethodName          = "/model.DeathfireArsenal/ListBlockedPlayers"
+	DeathfireArsenal_BlockPlayer_FullMethodName                 = "/model.DeathfireArsenal/BlockPlayer"
+	DeathfireArsenal_UnblockPlayer_FullMethodName               = "/model.DeathfireArsenal/UnblockPlayer"
+	DeathfireArsenal_ReportPlayer_FullMethodName                = "/model.DeathfireArsenal/ReportPlayer"
+	DeathfireArsenal_ListRegions_FullMethodName                 = "/model.DeathfireArsenal/ListRegions"
+	DeathfireArsenal_Login_FullMethodName                       = "/model.DeathfireArsenal/Login"
+	DeathfireArsenal_StreamRoomEvents_FullMethodName            = "/model.DeathfireArsenal/StreamRoomEvents"
+	DeathfireArsenal_PostChatMessage_FullMethodName             = "/model.DeathfireArsenal/PostChatMessage"
+	DeathfireArsenal_GetChatHistory_FullMethodName              = "/model.DeathfireArsenal/GetChatHistory"
+)
+
+// DeathfireArsenalClient is the client API for DeathfireArsenal service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeathfireArsenalClient interface {
+	CreatePlayer(ctx context.Context, in *CreatePlayerRequest, opts ...grpc.CallOption) (*CreatePlayerResponse, error)
+	CreateRoom(ctx context.Context, in *CreateRoomRequest, opts ...grpc.CallOption) (*CreateRoomResponse, error)
+	GetRooms(ctx context.Context, in *GetRoomsRequest, opts ...grpc.CallOption) (*GetRoomsResponse, error)
+	JoinRoom(ctx context.Context, in *JoinRoomRequest, opts ...grpc.CallOption) (*JoinRoomResponse, error)
+	LeaveRoom(ctx context.Context, in *LeaveRoomRequest, opts ...grpc.CallOption) (*LeaveRoomResponse, error)
+	GetModeTrendsByRegion(ctx context.Context, in *GetModeTrendsByRegionRequest, opts ...grpc.CallOption) (*ModeTrendsResponse, error)
+	GetModeTrendsByPlayerRegion(ctx context.Context, in *GetModeTrendsByPlayerRegionRequest, opts ...grpc.CallOption) (*ModeTrendsResponse, error)
+	GetTrendBreakdown(ctx context.Context, in *GetTrendBreakdownRequest, opts ...grpc.CallOption) (*TrendBreakdown, error)
+	// Profiles. Changing and deleting one takes the player's session token.
+	GetPlayer(ctx context.Context, in *GetPlayerRequest, opts ...grpc.CallOption) (*PlayerResponse, error)
+	UpdatePlayer(ctx context.Context, in *UpdatePlayerRequest, opts ...grpc.CallOption) (*PlayerResponse, error)
+	DeletePlayer(ctx context.Context, in *DeletePlayerRequest, opts ...grpc.CallOption) (*DeletePlayerResponse, error)
+	// Friends and presence, acting for the player of the session token.
+	ListFriends(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendListResponse, error)
+	ListFriendRequests(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestListResponse, error)
+	SendFriendRequest(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error)
+	AcceptFriendRequest(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error)
+	DeclineFriendRequest(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error)
+	RemoveFriend(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error)
+	JoinFriend(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*RoomResponse, error)
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	// Block lists, private to the player of the session token.
+	ListBlockedPlayers(ctx context.Context, in *BlockTarget, opts ...grpc.CallOption) (*BlockListResponse, error)
+	BlockPlayer(ctx context.Context, in *BlockTarget, opts ...grpc.CallOption) (*BlockResponse, error)
+	UnblockPlayer(ctx context.Context, in *BlockTarget, opts ...grpc.CallOption) (*BlockResponse, error)
+	// Reports a player met in a room for the moderators to review.
+	ReportPlayer(ctx context.Context, in *ReportPlayerRequest, opts ...grpc.CallOption) (*ReportReceipt, error)
+	// Lists the regions players can belong to.
+	ListRegions(ctx context.Context, in *ListRegionsRequest, opts ...grpc.CallOption) (*RegionListResponse, error)
+	// Exchanges the secret handed out by CreatePlayer for a session token. Send
+	// the token as "authorization: Bearer <token>" metadata to act as the player.
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Session, error)
+	// Streams the events of a room the player is part of, or of every room of a mode.
+	StreamRoomEvents(ctx context.Context, in *StreamRoomEventsRequest, opts ...grpc.CallOption) (DeathfireArsenal_StreamRoomEventsClient, error)
+	// Room chat, open to the members of the room. New messages are streamed
+	// by StreamRoomEvents as chat_message events.
+	PostChatMessage(ctx context.Context, in *PostChatMessageRequest, opts ...grpc.CallOption) (*ChatMessage, error)
+	GetChatHistory(ctx context.Context, in *ChatHistoryRequest, opts ...grpc.CallOption) (*ChatHistoryResponse, error)
+}
+
+type deathfireArsenalClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeathfireArsenalClient(cc grpc.ClientConnInterface) DeathfireArsenalClient {
+	return &deathfireArsenalClient{cc}
+}
+
+func (c *deathfireArsenalClient) CreatePlayer(ctx context.Context, in *CreatePlayerRequest, opts ...grpc.CallOption) (*CreatePlayerResponse, error) {
+	out := new(CreatePlayerResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_CreatePlayer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) CreateRoom(ctx context.Context, in *CreateRoomRequest, opts ...grpc.CallOption) (*CreateRoomResponse, error) {
+	out := new(CreateRoomResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_CreateRoom_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) GetRooms(ctx context.Context, in *GetRoomsRequest, opts ...grpc.CallOption) (*GetRoomsResponse, error) {
+	out := new(GetRoomsResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_GetRooms_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) JoinRoom(ctx context.Context, in *JoinRoomRequest, opts ...grpc.CallOption) (*JoinRoomResponse, error) {
+	out := new(JoinRoomResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_JoinRoom_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) LeaveRoom(ctx context.Context, in *LeaveRoomRequest, opts ...grpc.CallOption) (*LeaveRoomResponse, error) {
+	out := new(LeaveRoomResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_LeaveRoom_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) GetModeTrendsByRegion(ctx context.Context, in *GetModeTrendsByRegionRequest, opts ...grpc.CallOption) (*ModeTrendsResponse, error) {
+	out := new(ModeTrendsResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_GetModeTrendsByRegion_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) GetModeTrendsByPlayerRegion(ctx context.Context, in *GetModeTrendsByPlayerRegionRequest, opts ...grpc.CallOption) (*ModeTrendsResponse, error) {
+	out := new(ModeTrendsResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_GetModeTrendsByPlayerRegion_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) GetTrendBreakdown(ctx context.Context, in *GetTrendBreakdownRequest, opts ...grpc.CallOption) (*TrendBreakdown, error) {
+	out := new(TrendBreakdown)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_GetTrendBreakdown_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) GetPlayer(ctx context.Context, in *GetPlayerRequest, opts ...grpc.CallOption) (*PlayerResponse, error) {
+	out := new(PlayerResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_GetPlayer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) UpdatePlayer(ctx context.Context, in *UpdatePlayerRequest, opts ...grpc.CallOption) (*PlayerResponse, error) {
+	out := new(PlayerResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_UpdatePlayer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) DeletePlayer(ctx context.Context, in *DeletePlayerRequest, opts ...grpc.CallOption) (*DeletePlayerResponse, error) {
+	out := new(DeletePlayerResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_DeletePlayer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) ListFriends(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendListResponse, error) {
+	out := new(FriendListResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_ListFriends_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) ListFriendRequests(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestListResponse, error) {
+	out := new(FriendRequestListResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_ListFriendRequests_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) SendFriendRequest(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error) {
+	out := new(FriendRequestResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_SendFriendRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) AcceptFriendRequest(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error) {
+	out := new(FriendRequestResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_AcceptFriendRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) DeclineFriendRequest(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error) {
+	out := new(FriendRequestResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_DeclineFriendRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) RemoveFriend(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*FriendRequestResponse, error) {
+	out := new(FriendRequestResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_RemoveFriend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) JoinFriend(ctx context.Context, in *FriendTarget, opts ...grpc.CallOption) (*RoomResponse, error) {
+	out := new(RoomResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_JoinFriend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_Heartbeat_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) ListBlockedPlayers(ctx context.Context, in *BlockTarget, opts ...grpc.CallOption) (*BlockListResponse, error) {
+	out := new(BlockListResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_ListBlockedPlayers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) BlockPlayer(ctx context.Context, in *BlockTarget, opts ...grpc.CallOption) (*BlockResponse, error) {
+	out := new(BlockResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_BlockPlayer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) UnblockPlayer(ctx context.Context, in *BlockTarget, opts ...grpc.CallOption) (*BlockResponse, error) {
+	out := new(BlockResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_UnblockPlayer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) ReportPlayer(ctx context.Context, in *ReportPlayerRequest, opts ...grpc.CallOption) (*ReportReceipt, error) {
+	out := new(ReportReceipt)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_ReportPlayer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) ListRegions(ctx context.Context, in *ListRegionsRequest, opts ...grpc.CallOption) (*RegionListResponse, error) {
+	out := new(RegionListResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_ListRegions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*Session, error) {
+	out := new(Session)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) StreamRoomEvents(ctx context.Context, in *StreamRoomEventsRequest, opts ...grpc.CallOption) (DeathfireArsenal_StreamRoomEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DeathfireArsenal_ServiceDesc.Streams[0], DeathfireArsenal_StreamRoomEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &deathfireArsenalStreamRoomEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DeathfireArsenal_StreamRoomEventsClient interface {
+	Recv() (*RoomEvent, error)
+	grpc.ClientStream
+}
+
+type deathfireArsenalStreamRoomEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *deathfireArsenalStreamRoomEventsClient) Recv() (*RoomEvent, error) {
+	m := new(RoomEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *deathfireArsenalClient) PostChatMessage(ctx context.Context, in *PostChatMessageRequest, opts ...grpc.CallOption) (*ChatMessage, error) {
+	out := new(ChatMessage)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_PostChatMessage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deathfireArsenalClient) GetChatHistory(ctx context.Context, in *ChatHistoryRequest, opts ...grpc.CallOption) (*ChatHistoryResponse, error) {
+	out := new(ChatHistoryResponse)
+	err := c.cc.Invoke(ctx, DeathfireArsenal_GetChatHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeathfireArsenalServer is the server API for DeathfireArsenal service.
+// All implementations must embed UnimplementedDeathfireArsenalServer
+// for forward compatibility
+type DeathfireArsenalServer interface {
+	CreatePlayer(context.Context, *CreatePlayerRequest) (*CreatePlayerResponse, error)
+	CreateRoom(context.Context, *CreateRoomRequest) (*CreateRoomResponse, error)
+	GetRooms(context.Context, *GetRoomsRequest) (*GetRoomsResponse, error)
+	JoinRoom(context.Context, *JoinRoomRequest) (*JoinRoomResponse, error)
+	LeaveRoom(context.Context, *LeaveRoomRequest) (*LeaveRoomResponse, error)
+	GetModeTrendsByRegion(context.Context, *GetModeTrendsByRegionRequest) (*ModeTrendsResponse, error)
+	GetModeTrendsByPlayerRegion(context.Context, *GetModeTrendsByPlayerRegionRequest) (*ModeTrendsResponse, error)
+	GetTrendBreakdown(context.Context, *GetTrendBreakdownRequest) (*TrendBreakdown, error)
+	// Profiles. Changing and deleting one takes the player's session token.
+	GetPlayer(context.Context, *GetPlayerRequest) (*PlayerResponse, error)
+	UpdatePlayer(context.Context, *UpdatePlayerRequest) (*PlayerResponse, error)
+	DeletePlayer(context.Context, *DeletePlayerRequest) (*DeletePlayerResponse, error)
+	// Friends and presence, acting for the player of the session token.
+	ListFriends(context.Context, *FriendTarget) (*FriendListResponse, error)
+	ListFriendRequests(context.Context, *FriendTarget) (*FriendRequestListResponse, error)
+	SendFriendRequest(context.Context, *FriendTarget) (*FriendRequestResponse, error)
+	AcceptFriendRequest(context.Context, *FriendTarget) (*FriendRequestResponse, error)
+	DeclineFriendRequest(context.Context, *FriendTarget) (*FriendRequestResponse, error)
+	RemoveFriend(context.Context, *FriendTarget) (*FriendRequestResponse, error)
+	JoinFriend(context.Context, *FriendTarget) (*RoomResponse, error)
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	// Block lists, private to the player of the session token.
+	ListBlockedPlayers(context.Context, *BlockTarget) (*BlockListResponse, error)
+	BlockPlayer(context.Context, *BlockTarget) (*BlockResponse, error)
+	UnblockPlayer(context.Context, *BlockTarget) (*BlockResponse, error)
+	// Reports a player met in a room for the moderators to review.
+	ReportPlayer(context.Context, *ReportPlayerRequest) (*ReportReceipt, error)
+	// Lists the regions players can belong to.
+	ListRegions(context.Context, *ListRegionsRequest) (*RegionListResponse, error)
+	// Exchanges the secret handed out by CreatePlayer for a session token. Send
+	// the token as "authorization: Bearer <token>" metadata to act as the player.
+	Login(context.Context, *LoginRequest) (*Session, error)
+	// Streams the events of a room the player is part of, or of every room of a mode.
+	StreamRoomEvents(*StreamRoomEventsRequest, DeathfireArsenal_StreamRoomEventsServer) error
+	// Room chat, open to the members of the room. New messages are streamed
+	// by StreamRoomEvents as chat_message events.
+	PostChatMessage(context.Context, *PostChatMessageRequest) (*ChatMessage, error)
+	GetChatHistory(context.Context, *ChatHistoryRequest) (*ChatHistoryResponse, error)
+	mustEmbedUnimplementedDeathfireArsenalServer()
+}
+
+// UnimplementedDeathfireArsenalServer must be embedded to have forward compatible implementations.
+type UnimplementedDeathfireArsenalServer struct {
+}
+
+func (UnimplementedDeathfireArsenalServer) CreatePlayer(context.Context, *CreatePlayerRequest) (*CreatePlayerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePlayer not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) CreateRoom(context.Context, *CreateRoomRequest) (*CreateRoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRoom not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) GetRooms(context.Context, *GetRoomsRequest) (*GetRoomsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRooms not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) JoinRoom(context.Context, *JoinRoomRequest) (*JoinRoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinRoom not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) LeaveRoom(context.Context, *LeaveRoomRequest) (*LeaveRoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveRoom not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) GetModeTrendsByRegion(context.Context, *GetModeTrendsByRegionRequest) (*ModeTrendsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetModeTrendsByRegion not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) GetModeTrendsByPlayerRegion(context.Context, *GetModeTrendsByPlayerRegionRequest) (*ModeTrendsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetModeTrendsByPlayerRegion not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) GetTrendBreakdown(context.Context, *GetTrendBreakdownRequest) (*TrendBreakdown, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrendBreakdown not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) GetPlayer(context.Context, *GetPlayerRequest) (*PlayerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlayer not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) UpdatePlayer(context.Context, *UpdatePlayerRequest) (*PlayerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePlayer not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) DeletePlayer(context.Context, *DeletePlayerRequest) (*DeletePlayerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePlayer not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) ListFriends(context.Context, *FriendTarget) (*FriendListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFriends not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) ListFriendRequests(context.Context, *FriendTarget) (*FriendRequestListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFriendRequests not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) SendFriendRequest(context.Context, *FriendTarget) (*FriendRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendFriendRequest not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) AcceptFriendRequest(context.Context, *FriendTarget) (*FriendRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptFriendRequest not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) DeclineFriendRequest(context.Context, *FriendTarget) (*FriendRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeclineFriendRequest not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) RemoveFriend(context.Context, *FriendTarget) (*FriendRequestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFriend not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) JoinFriend(context.Context, *FriendTarget) (*RoomResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinFriend not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) ListBlockedPlayers(context.Context, *BlockTarget) (*BlockListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBlockedPlayers not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) BlockPlayer(context.Context, *BlockTarget) (*BlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockPlayer not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) UnblockPlayer(context.Context, *BlockTarget) (*BlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockPlayer not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) ReportPlayer(context.Context, *ReportPlayerRequest) (*ReportReceipt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportPlayer not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) ListRegions(context.Context, *ListRegionsRequest) (*RegionListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRegions not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) Login(context.Context, *LoginRequest) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) StreamRoomEvents(*StreamRoomEventsRequest, DeathfireArsenal_StreamRoomEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamRoomEvents not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) PostChatMessage(context.Context, *PostChatMessageRequest) (*ChatMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PostChatMessage not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) GetChatHistory(context.Context, *ChatHistoryRequest) (*ChatHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChatHistory not implemented")
+}
+func (UnimplementedDeathfireArsenalServer) mustEmbedUnimplementedDeathfireArsenalServer() {}
+
+// UnsafeDeathfireArsenalServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeathfireArsenalServer will
+// result in compilation errors.
+type UnsafeDeathfireArsenalServer interface {
+	mustEmbedUnimplementedDeathfireArsenalServer()
+}
+
+func RegisterDeathfireArsenalServer(s grpc.ServiceRegistrar, srv DeathfireArsenalServer) {
+	s.RegisterService(&DeathfireArsenal_ServiceDesc, srv)
+}
+
+func _DeathfireArsenal_CreatePlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).CreatePlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_CreatePlayer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).CreatePlayer(ctx, req.(*CreatePlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_CreateRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).CreateRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_CreateRoom_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).CreateRoom(ctx, req.(*CreateRoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_GetRooms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRoomsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).GetRooms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_GetRooms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).GetRooms(ctx, req.(*GetRoomsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_JoinRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinRoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).JoinRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_JoinRoom_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).JoinRoom(ctx, req.(*JoinRoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_LeaveRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaveRoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).LeaveRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_LeaveRoom_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).LeaveRoom(ctx, req.(*LeaveRoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_GetModeTrendsByRegion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetModeTrendsByRegionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).GetModeTrendsByRegion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_GetModeTrendsByRegion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).GetModeTrendsByRegion(ctx, req.(*GetModeTrendsByRegionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_GetModeTrendsByPlayerRegion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetModeTrendsByPlayerRegionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).GetModeTrendsByPlayerRegion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_GetModeTrendsByPlayerRegion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).GetModeTrendsByPlayerRegion(ctx, req.(*GetModeTrendsByPlayerRegionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_GetTrendBreakdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTrendBreakdownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).GetTrendBreakdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_GetTrendBreakdown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).GetTrendBreakdown(ctx, req.(*GetTrendBreakdownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_GetPlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).GetPlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_GetPlayer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).GetPlayer(ctx, req.(*GetPlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_UpdatePlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).UpdatePlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_UpdatePlayer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).UpdatePlayer(ctx, req.(*UpdatePlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_DeletePlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).DeletePlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_DeletePlayer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).DeletePlayer(ctx, req.(*DeletePlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_ListFriends_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).ListFriends(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_ListFriends_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).ListFriends(ctx, req.(*FriendTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_ListFriendRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).ListFriendRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_ListFriendRequests_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).ListFriendRequests(ctx, req.(*FriendTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_SendFriendRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).SendFriendRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_SendFriendRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).SendFriendRequest(ctx, req.(*FriendTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_AcceptFriendRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).AcceptFriendRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_AcceptFriendRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).AcceptFriendRequest(ctx, req.(*FriendTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_DeclineFriendRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).DeclineFriendRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_DeclineFriendRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).DeclineFriendRequest(ctx, req.(*FriendTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_RemoveFriend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).RemoveFriend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_RemoveFriend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).RemoveFriend(ctx, req.(*FriendTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_JoinFriend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FriendTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).JoinFriend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_JoinFriend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).JoinFriend(ctx, req.(*FriendTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_ListBlockedPlayers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).ListBlockedPlayers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_ListBlockedPlayers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).ListBlockedPlayers(ctx, req.(*BlockTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_BlockPlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).BlockPlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_BlockPlayer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).BlockPlayer(ctx, req.(*BlockTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_UnblockPlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockTarget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).UnblockPlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_UnblockPlayer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).UnblockPlayer(ctx, req.(*BlockTarget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_ReportPlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportPlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).ReportPlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_ReportPlayer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).ReportPlayer(ctx, req.(*ReportPlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_ListRegions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRegionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).ListRegions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_ListRegions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).ListRegions(ctx, req.(*ListRegionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_StreamRoomEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRoomEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeathfireArsenalServer).StreamRoomEvents(m, &deathfireArsenalStreamRoomEventsServer{stream})
+}
+
+type DeathfireArsenal_StreamRoomEventsServer interface {
+	Send(*RoomEvent) error
+	grpc.ServerStream
+}
+
+type deathfireArsenalStreamRoomEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *deathfireArsenalStreamRoomEventsServer) Send(m *RoomEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DeathfireArsenal_PostChatMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostChatMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).PostChatMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_PostChatMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).PostChatMessage(ctx, req.(*PostChatMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeathfireArsenal_GetChatHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeathfireArsenalServer).GetChatHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeathfireArsenal_GetChatHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeathfireArsenalServer).GetChatHistory(ctx, req.(*ChatHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DeathfireArsenal_ServiceDesc is the grpc.ServiceDesc for DeathfireArsenal service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DeathfireArsenal_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "model.DeathfireArsenal",
+	HandlerType: (*DeathfireArsenalServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePlayer",
+			Handler:    _DeathfireArsenal_CreatePlayer_Handler,
+		},
+		{
+			MethodName: "CreateRoom",
+			Handler:    _DeathfireArsenal_CreateRoom_Handler,
+		},
+		{
+			MethodName: "GetRooms",
+			Handler:    _DeathfireArsenal_GetRooms_Handler,
+		},
+		{
+			MethodName: "JoinRoom",
+			Handler:    _DeathfireArsenal_JoinRoom_Handler,
+		},
+		{
+			MethodName: "LeaveRoom",
+			Handler:    _DeathfireArsenal_LeaveRoom_Handler,
+		},
+		{
+			MethodName: "GetModeTrendsByRegion",
+			Handler:    _DeathfireArsenal_GetModeTrendsByRegion_Handler,
+		},
+		{
+			MethodName: "GetModeTrendsByPlayerRegion",
+			Handler:    _DeathfireArsenal_GetModeTrendsByPlayerRegion_Handler,
+		},
+		{
+			MethodName: "GetTrendBreakdown",
+			Handler:    _DeathfireArsenal_GetTrendBreakdown_Handler,
+		},
+		{
+			MethodName: "GetPlayer",
+			Handler:    _DeathfireArsenal_GetPlayer_Handler,
+		},
+		{
+			MethodName: "UpdatePlayer",
+			Handler:    _DeathfireArsenal_UpdatePlayer_Handler,
+		},
+		{
+			MethodName: "DeletePlayer",
+			Handler:    _DeathfireArsenal_DeletePlayer_Handler,
+		},
+		{
+			MethodName: "ListFriends",
+			Handler:    _DeathfireArsenal_ListFriends_Handler,
+		},
+		{
+			MethodName: "ListFriendRequests",
+			Handler:    _DeathfireArsenal_ListFriendRequests_Handler,
+		},
+		{
+			MethodName: "SendFriendRequest",
+			Handler:    _DeathfireArsenal_SendFriendRequest_Handler,
+		},
+		{
+			MethodName: "AcceptFriendRequest",
+			Handler:    _DeathfireArsenal_AcceptFriendRequest_Handler,
+		},
+		{
+			MethodName: "DeclineFriendRequest",
+			Handler:    _DeathfireArsenal_DeclineFriendRequest_Handler,
+		},
+		{
+			MethodName: "RemoveFriend",
+			Handler:    _DeathfireArsenal_RemoveFriend_Handler,
+		},
+		{
+			MethodName: "JoinFriend",
+			Handler:    _DeathfireArsenal_JoinFriend_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _DeathfireArsenal_Heartbeat_Handler,
+		},
+		{
+			MethodName: "ListBlockedPlayers",
+			Handler:    _DeathfireArsenal_ListBlockedPlayers_Handler,
+		},
+		{
+			MethodName: "BlockPlayer",
+			Handler:    _DeathfireArsenal_BlockPlayer_Handler,
+		},
+		{
+			MethodName: "UnblockPlayer",
+			Handler:    _DeathfireArsenal_UnblockPlayer_Handler,
+		},
+		{
+			MethodName: "ReportPlayer",
+			Handler:    _DeathfireArsenal_ReportPlayer_Handler,
+		},
+		{
+			MethodName: "ListRegions",
+			Handler:    _DeathfireArsenal_ListRegions_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _DeathfireArsenal_Login_Handler,
+		},
+		{
+			MethodName: "PostChatMessage",
+			Handler:    _DeathfireArsenal_PostChatMessage_Handler,
+		},
+		{
+			MethodName: "GetChatHistory",
+			Handler:    _DeathfireArsenal_GetChatHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamRoomEvents",
+			Handler:       _DeathfireArsenal_StreamRoomEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
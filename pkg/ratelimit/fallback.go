@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// FallbackLimiter uses its primary limiter and switches to the fallback for
+// the requests the primary fails on, so that an outage of Redis neither
+// blocks every request nor lifts the limits.
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	// Unix time of the last warning, to log an outage once a minute.
+	lastWarning atomic.Int64
+}
+
+func NewFallbackLimiter(primary Limiter, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback}
+}
+
+func (l *FallbackLimiter) Allow(ctx context.Context, keys []string, limit Limit) (Result, error) {
+	res, err := l.primary.Allow(ctx, keys, limit)
+	if err == nil {
+		return res, nil
+	}
+	l.warn(err)
+	return l.fallback.Allow(ctx, keys, limit)
+}
+
+func (l *FallbackLimiter) Peek(ctx context.Context, keys []string, limit Limit) (Result, error) {
+	res, err := l.primary.Peek(ctx, keys, limit)
+	if err == nil {
+		return res, nil
+	}
+	l.warn(err)
+	return l.fallback.Peek(ctx, keys, limit)
+}
+
+func (l *FallbackLimiter) warn(err error) {
+	if now := time.Now().Unix(); now-l.lastWarning.Load() >= 60 {
+		l.lastWarning.Store(now)
+		log.Println("Rate limiter failed, falling back to in-memory buckets:", err)
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+// Helper function to run a test against every limiter.
+func forEachLimiter(t *testing.T, test func(t *testing.T, limiter Limiter)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryLimiter())
+	})
+	t.Run("redis", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		test(t, NewRedisLimiter(client, "test"))
+	})
+}
+
+func TestAllowChargesEveryBucketOrNone(t *testing.T) {
+	forEachLimiter(t, func(t *testing.T, limiter Limiter) {
+		limit := Limit{Requests: 3, Period: time.Minute}
+		ctx := context.Background()
+
+		// The player has spent their budget from another address.
+		for i := 0; i < limit.Requests; i++ {
+			if _, err := limiter.Allow(ctx, []string{"ip:1", "player:Furious"}, limit); err != nil {
+				t.Fatalf("Allow: %v", err)
+			}
+		}
+		res, err := limiter.Allow(ctx, []string{"ip:2", "player:Furious"}, limit)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if res.Allowed || res.Remaining != 0 || res.RetryAfter <= 0 {
+			t.Fatalf("request over the player's budget = %+v, want denied by their bucket", res)
+		}
+
+		// Which cost the address nothing.
+		res, err = limiter.Allow(ctx, []string{"ip:2"}, limit)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if !res.Allowed || res.Remaining != limit.Requests-1 {
+			t.Fatalf("request of another player from the address = %+v, want allowed with %d remaining", res, limit.Requests-1)
+		}
+
+		// An allowed request reports the bucket with the fewest tokens left.
+		res, err = limiter.Allow(ctx, []string{"ip:2", "player:Valiant"}, limit)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if !res.Allowed || res.Remaining != limit.Requests-2 {
+			t.Fatalf("request = %+v, want allowed with %d remaining", res, limit.Requests-2)
+		}
+	})
+}
+
+func TestPeekTakesNoToken(t *testing.T) {
+	forEachLimiter(t, func(t *testing.T, limiter Limiter) {
+		limit := Limit{Requests: 1, Period: time.Minute}
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			res, err := limiter.Peek(ctx, []string{"ip:1"}, limit)
+			if err != nil {
+				t.Fatalf("Peek: %v", err)
+			}
+			if !res.Allowed {
+				t.Fatalf("Peek %d = %+v, want allowed", i, res)
+			}
+		}
+		if res, err := limiter.Allow(ctx, []string{"ip:1"}, limit); err != nil || !res.Allowed {
+			t.Fatalf("Allow = %+v, %v, want allowed", res, err)
+		}
+		res, err := limiter.Peek(ctx, []string{"ip:1"}, limit)
+		if err != nil {
+			t.Fatalf("Peek: %v", err)
+		}
+		if res.Allowed {
+			t.Fatal("Peek allows a request on an empty bucket")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryLimiter keeps the buckets in process. Each replica enforces the limit
+// on its own, so it is meant as a fallback when Redis is unavailable.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, keys []string, limit Limit) (Result, error) {
+	return l.take(keys, limit, true), nil
+}
+
+func (l *MemoryLimiter) Peek(ctx context.Context, keys []string, limit Limit) (Result, error) {
+	return l.take(keys, limit, false), nil
+}
+
+// take refills the buckets of keys and, when every one of them holds a token
+// and charge is set, takes one from each.
+func (l *MemoryLimiter) take(keys []string, limit Limit, charge bool) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	tokens := make([]float64, len(keys))
+	allowed := true
+	for i, key := range keys {
+		tokens[i] = float64(limit.Requests)
+		if b, ok := l.buckets[key]; ok {
+			elapsed := float64(now.Sub(b.updated).Milliseconds())
+			tokens[i] = math.Min(float64(limit.Requests), b.tokens+elapsed*limit.perMillisecond())
+		}
+		allowed = allowed && tokens[i] >= 1
+	}
+	if !charge {
+		return tightest(limit, tokens, allowed)
+	}
+
+	for i, key := range keys {
+		if allowed {
+			tokens[i]--
+		}
+		l.buckets[key] = &bucket{tokens: tokens[i], updated: now, limit: limit}
+	}
+	return tightest(limit, tokens, allowed)
+}
+
+// sweep drops, about once a minute, the buckets that have refilled completely
+// and so are no different from missing ones.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= b.limit.Period {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterAllowsABurst(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Requests: 3, Period: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < limit.Requests; i++ {
+		res, err := limiter.Allow(ctx, []string{"ip:1"}, limit)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if !res.Allowed || res.Remaining != limit.Requests-1-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, res, limit.Requests-1-i)
+		}
+	}
+
+	res, err := limiter.Allow(ctx, []string{"ip:1"}, limit)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("request past the burst = %+v, want denied with none remaining", res)
+	}
+	// A token comes back every 20 seconds.
+	if res.RetryAfter <= 0 || res.RetryAfter > 20*time.Second {
+		t.Fatalf("RetryAfter = %v, want at most 20s", res.RetryAfter)
+	}
+
+	res, err = limiter.Allow(ctx, []string{"ip:2"}, limit)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !res.Allowed {
+		t.Fatal("another key shares the bucket of the first one")
+	}
+}
+
+func TestMemoryLimiterRefills(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	limit := Limit{Requests: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < limit.Requests; i++ {
+		if _, err := limiter.Allow(ctx, []string{"player:Furious"}, limit); err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+	}
+	// Half the period refills one token.
+	limiter.buckets["player:Furious"].updated = time.Now().Add(-limit.Period / 2)
+	res, err := limiter.Allow(ctx, []string{"player:Furious"}, limit)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("request after half the period = %+v, want allowed with none remaining", res)
+	}
+
+	// A bucket never holds more than the limit.
+	limiter.buckets["player:Furious"].updated = time.Now().Add(-10 * limit.Period)
+	res, err = limiter.Allow(ctx, []string{"player:Furious"}, limit)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !res.Allowed || res.Remaining != limit.Requests-1 {
+		t.Fatalf("request after a long pause = %+v, want allowed with %d remaining", res, limit.Requests-1)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("30/1m")
+	if err != nil {
+		t.Fatalf("ParseLimit: %v", err)
+	}
+	if limit != (Limit{Requests: 30, Period: time.Minute}) {
+		t.Fatalf("ParseLimit = %+v, want 30 per minute", limit)
+	}
+	for _, value := range []string{"30", "0/1m", "x/1m", "30/0s", "30/soon"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Fatalf("ParseLimit(%q) succeeded", value)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket holding Requests tokens that refills completely over
+// Period, so that Requests requests can be made at once and Requests per
+// Period in the long run.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads a limit written as "<requests>/<period>", e.g. "30/1m".
+func ParseLimit(value string) (Limit, error) {
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: %q is not of the form requests/period", value)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("ratelimit: %q does not allow a positive number of requests", value)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: %q does not have a positive period", value)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// perMillisecond is the refill rate of the bucket.
+func (l Limit) perMillisecond() float64 {
+	return float64(l.Requests) / float64(l.Period.Milliseconds())
+}
+
+// Result is the state of a bucket after taking a token from it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// How long until a token is available again; zero when Allowed.
+	RetryAfter time.Duration
+	// How long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Limiter keeps a bucket per key, creating it full on first use.
+type Limiter interface {
+	// Allow takes a token from the bucket of every key, or none of them when
+	// any is empty, so a request turned down by one bucket costs nothing in
+	// the others. It reports the bucket closest to turning the caller down.
+	Allow(ctx context.Context, keys []string, limit Limit) (Result, error)
+	// Peek reports whether Allow would let a request through, without taking
+	// a token.
+	Peek(ctx context.Context, keys []string, limit Limit) (Result, error)
+}
+
+// result describes a bucket holding tokens after a request was or was not allowed.
+func result(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.perMillisecond()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration(math.Ceil((float64(limit.Requests)-tokens)/rate)) * time.Millisecond,
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration(math.Ceil((1-tokens)/rate)) * time.Millisecond
+	}
+	return res
+}
+
+// tightest describes the buckets holding tokens after a request was or was not
+// allowed by the one of them closest to turning the caller down: an empty
+// bucket over a full one, and the one staying empty longest over the others.
+func tightest(limit Limit, tokens []float64, allowed bool) Result {
+	var reported Result
+	for i, held := range tokens {
+		res := result(limit, held, allowed || held >= 1)
+		if i == 0 || tighter(res, reported) {
+			reported = res
+		}
+	}
+	reported.Allowed = allowed
+	return reported
+}
+
+func tighter(a Result, b Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+)
+
+// takeTokens refills the buckets of KEYS for the time elapsed since they were
+// last used, by Redis' own clock so that replicas agree. Only when every one
+// of them holds a token, and ARGV[3] asks for it, does it take one from each.
+// It answers whether they all held one, followed by the tokens of each bucket.
+var takeTokens = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local charge = ARGV[3] == '1'
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tokens = {}
+local allowed = 1
+for i, key in ipairs(KEYS) do
+	local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+	local held = tonumber(bucket[1])
+	local ts = tonumber(bucket[2])
+	if held == nil or ts == nil then
+		held = burst
+		ts = now
+	end
+	tokens[i] = math.min(burst, held + math.max(0, now - ts) * rate)
+	if tokens[i] < 1 then
+		allowed = 0
+	end
+end
+
+local reply = {allowed}
+for i, key in ipairs(KEYS) do
+	if charge then
+		if allowed == 1 then
+			tokens[i] = tokens[i] - 1
+		end
+		redis.call('HSET', key, 'tokens', tostring(tokens[i]), 'ts', now)
+		redis.call('PEXPIRE', key, math.ceil(burst / rate))
+	end
+	reply[i + 1] = tostring(tokens[i])
+end
+return reply
+`)
+
+// RedisLimiter keeps the buckets in Redis so that a limit holds across replicas.
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisLimiter(client *redis.Client, prefix string) *RedisLimiter {
+	return &RedisLimiter{client: client, prefix: prefix}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, keys []string, limit Limit) (Result, error) {
+	return l.take(ctx, keys, limit, true)
+}
+
+func (l *RedisLimiter) Peek(ctx context.Context, keys []string, limit Limit) (Result, error) {
+	return l.take(ctx, keys, limit, false)
+}
+
+func (l *RedisLimiter) take(ctx context.Context, keys []string, limit Limit, charge bool) (Result, error) {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = l.prefix + ":" + key
+	}
+	chargeArg := 0
+	if charge {
+		chargeArg = 1
+	}
+	reply, err := takeTokens.Run(ctx, l.client, prefixed,
+		limit.perMillisecond(), limit.Requests, chargeArg).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(reply) != len(keys)+1 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokens := make([]float64, len(keys))
+	for i := range keys {
+		tokensText, _ := reply[i+1].(string)
+		if tokens[i], err = strconv.ParseFloat(tokensText, 64); err != nil {
+			return Result{}, err
+		}
+	}
+	return tightest(limit, tokens, allowed == 1), nil
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// APIKey is one document of the API key collection. Only the hash of the
+// secret part of a key is stored.
+type APIKey struct {
+	ID         string    `bson:"id" json:"id"`
+	Name       string    `bson:"name" json:"name"`
+	Hash       string    `bson:"hash" json:"-"`
+	Scopes     []string  `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time `bson:"createdat" json:"created_at"`
+	RevokedAt  time.Time `bson:"revokedat,omitempty" json:"revoked_at,omitempty"`
+	LastUsedAt time.Time `bson:"lastusedat,omitempty" json:"last_used_at,omitempty"`
+}
+
+// AuditEntry records one request made with an API key.
+type AuditEntry struct {
+	KeyID      string    `bson:"keyid" json:"key_id"`
+	Method     string    `bson:"method" json:"method"`
+	Path       string    `bson:"path" json:"path"`
+	Status     int       `bson:"status" json:"status"`
+	RemoteAddr string    `bson:"remoteaddr" json:"remote_addr"`
+	At         time.Time `bson:"at" json:"at"`
+}
+
+// APIKeyStorage keeps the API keys of services and the audit trail of their
+// use, apart from the game data.
+type APIKeyStorage struct {
+	keyCollection   *mongo.Collection
+	auditCollection *mongo.Collection
+}
+
+func NewAPIKeyStorage(keys *mongo.Collection, audit *mongo.Collection) *APIKeyStorage {
+	return &APIKeyStorage{
+		keyCollection:   keys,
+		auditCollection: audit,
+	}
+}
+
+// EnsureIndexes creates the indexes the key lookups and audit queries rely on.
+func (s *APIKeyStorage) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.keyCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	_, err := s.auditCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "keyid", Value: 1}, {Key: "at", Value: -1}},
+	})
+	return err
+}
+
+func (s *APIKeyStorage) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	_, err := s.keyCollection.InsertOne(ctx, key)
+	return err
+}
+
+func (s *APIKeyStorage) GetAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	var key APIKey
+	err := s.keyCollection.FindOne(ctx, bson.M{"id": id}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errormanagement.APIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns every key, revoked ones included, oldest first.
+func (s *APIKeyStorage) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	cursor, err := s.keyCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []APIKey{}
+	for cursor.Next(ctx) {
+		var key APIKey
+		if err := cursor.Decode(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, cursor.Err()
+}
+
+// RevokeAPIKey marks a key as revoked. The key and its audit trail are kept.
+func (s *APIKeyStorage) RevokeAPIKey(ctx context.Context, id string) error {
+	filter := bson.M{"id": id, "revokedat": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revokedat": time.Now().UTC()}}
+	result, err := s.keyCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// Either there is no such key or it was revoked already.
+		_, err := s.GetAPIKey(ctx, id)
+		return err
+	}
+	return nil
+}
+
+// RecordAPIKeyUse appends an entry to the audit trail and stamps the key as used.
+func (s *APIKeyStorage) RecordAPIKeyUse(ctx context.Context, entry AuditEntry) error {
+	if _, err := s.auditCollection.InsertOne(ctx, &entry); err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"lastusedat": entry.At}}
+	_, err := s.keyCollection.UpdateOne(ctx, bson.M{"id": entry.KeyID}, update)
+	return err
+}
+
+// GetAuditTrail returns the latest uses of a key, newest first.
+func (s *APIKeyStorage) GetAuditTrail(ctx context.Context, keyID string, limit int) ([]AuditEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.auditCollection.Find(ctx, bson.M{"keyid": keyID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []AuditEntry{}
+	for cursor.Next(ctx) {
+		var entry AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, cursor.Err()
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Block is one document of the blocks collection: Blocker does not want to
+// play with Blocked. Only the blocker ever gets to see it.
+type Block struct {
+	Blocker   string    `bson:"blocker"`
+	Blocked   string    `bson:"blocked"`
+	CreatedAt time.Time `bson:"createdat"`
+}
+
+// BlockPlayer stores a block. Blocking a player twice keeps the first block.
+func (s *MongoDBStorage) BlockPlayer(ctx context.Context, blocker string, blocked string) error {
+	filter := bson.M{"blocker": blocker, "blocked": blocked}
+	update := bson.M{"$setOnInsert": Block{Blocker: blocker, Blocked: blocked, CreatedAt: time.Now().UTC()}}
+	_, err := s.blockCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// UnblockPlayer removes a block and reports whether there was one.
+func (s *MongoDBStorage) UnblockPlayer(ctx context.Context, blocker string, blocked string) (bool, error) {
+	result, err := s.blockCollection.DeleteOne(ctx, bson.M{"blocker": blocker, "blocked": blocked})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// ListBlocks returns the blocks made by a player, oldest first.
+func (s *MongoDBStorage) ListBlocks(ctx context.Context, blocker string) ([]Block, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}, {Key: "blocked", Value: 1}})
+	cursor, err := s.blockCollection.Find(ctx, bson.M{"blocker": blocker}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	blocks := []Block{}
+	for cursor.Next(ctx) {
+		var block Block
+		if err := cursor.Decode(&block); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, cursor.Err()
+}
+
+// HasBlocked tells whether blocker has blocked the other player.
+func (s *MongoDBStorage) HasBlocked(ctx context.Context, blocker string, blocked string) (bool, error) {
+	count, err := s.blockCollection.CountDocuments(ctx, bson.M{"blocker": blocker, "blocked": blocked}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsBlockedAmong tells whether a player has blocked, or was blocked by, any
+// of the others.
+func (s *MongoDBStorage) IsBlockedAmong(ctx context.Context, playerID string, others []string) (bool, error) {
+	if len(others) == 0 {
+		return false, nil
+	}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"blocker": playerID, "blocked": bson.M{"$in": others}},
+		bson.M{"blocked": playerID, "blocker": bson.M{"$in": others}},
+	}}
+	count, err := s.blockCollection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteBlocksOf removes the blocks made by or against a player, so that they
+// don't carry over to whoever takes the ID next.
+func (s *MongoDBStorage) DeleteBlocksOf(ctx context.Context, playerID string) error {
+	_, err := s.blockCollection.DeleteMany(ctx, bson.M{"$or": bson.A{
+		bson.M{"blocker": playerID},
+		bson.M{"blocked": playerID},
+	}})
+	return err
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Kinds of actors behind a domain event. Services act with an API key; the
+// system acts on its own, without a request behind it.
+const (
+	ActorPlayer  = "player"
+	ActorService = "service"
+	ActorSystem  = "system"
+)
+
+// Actor is who made a change: a player, the API key of a service, or the
+// system.
+type Actor struct {
+	Type string `bson:"type"`
+	ID   string `bson:"id,omitempty"`
+}
+
+// DomainEvent records a change to the players and rooms. Which of the
+// optional fields are set depends on the type.
+type DomainEvent struct {
+	ID       string `bson:"id"`
+	Type     string `bson:"type"`
+	PlayerID string `bson:"playerid,omitempty"`
+	RoomID   string `bson:"roomid,omitempty"`
+	Mode     string `bson:"mode,omitempty"`
+	Region   string `bson:"region,omitempty"`
+	Reason   string `bson:"reason,omitempty"`
+	// The name a player took; empty when the name was removed.
+	DisplayName string `bson:"displayname,omitempty"`
+	BanID       string `bson:"banid,omitempty"`
+	Actor       Actor  `bson:"actor"`
+	// At orders the events; events of the same instant are ordered by ID.
+	At time.Time `bson:"at"`
+}
+
+// DomainEventFilter selects a page of the domain events, oldest first. Empty
+// fields don't filter; From is inclusive, To exclusive, and After continues
+// from the last event of a page.
+type DomainEventFilter struct {
+	PlayerID string
+	RoomID   string
+	From     time.Time
+	To       time.Time
+	After    *DomainEvent
+	Limit    int
+}
+
+// DomainEventStorage keeps the domain events. The log is append-only: events
+// are never deleted, and only changed to replace a deleted player with a
+// pseudonym.
+type DomainEventStorage struct {
+	eventCollection *mongo.Collection
+}
+
+func NewDomainEventStorage(domainEvents *mongo.Collection) *DomainEventStorage {
+	return &DomainEventStorage{eventCollection: domainEvents}
+}
+
+// EnsureIndexes creates the indexes the domain event queries rely on.
+func (s *DomainEventStorage) EnsureIndexes(ctx context.Context) error {
+	_, err := s.eventCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "at", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "playerid", Value: 1}, {Key: "at", Value: 1}, {Key: "id", Value: 1}}},
+		{Keys: bson.D{{Key: "roomid", Value: 1}, {Key: "at", Value: 1}, {Key: "id", Value: 1}}},
+		// Deleting a player looks up the events it acted in.
+		{Keys: bson.D{{Key: "actor.id", Value: 1}}},
+	})
+	return err
+}
+
+// AppendDomainEvents adds events to the log, in order.
+func (s *DomainEventStorage) AppendDomainEvents(ctx context.Context, domainEvents []DomainEvent) error {
+	if len(domainEvents) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, 0, len(domainEvents))
+	for _, event := range domainEvents {
+		documents = append(documents, event)
+	}
+	_, err := s.eventCollection.InsertMany(ctx, documents)
+	return err
+}
+
+// PseudonymizePlayer replaces a player with a pseudonym in the events naming
+// it, as their subject or as their actor.
+func (s *DomainEventStorage) PseudonymizePlayer(ctx context.Context, playerID string, pseudonym string) error {
+	update := bson.M{"$set": bson.M{"playerid": pseudonym}, "$unset": bson.M{"displayname": ""}}
+	if _, err := s.eventCollection.UpdateMany(ctx, bson.M{"playerid": playerID}, update); err != nil {
+		return err
+	}
+	actorFilter := bson.M{"actor.type": ActorPlayer, "actor.id": playerID}
+	_, err := s.eventCollection.UpdateMany(ctx, actorFilter, bson.M{"$set": bson.M{"actor.id": pseudonym}})
+	return err
+}
+
+// ListDomainEvents returns a page of the events matching the filter, oldest
+// first.
+func (s *DomainEventStorage) ListDomainEvents(ctx context.Context, query DomainEventFilter) ([]DomainEvent, error) {
+	filter := bson.M{}
+	if query.PlayerID != "" {
+		filter["playerid"] = query.PlayerID
+	}
+	if query.RoomID != "" {
+		filter["roomid"] = query.RoomID
+	}
+	at := bson.M{}
+	if !query.From.IsZero() {
+		at["$gte"] = query.From.UTC()
+	}
+	if !query.To.IsZero() {
+		at["$lt"] = query.To.UTC()
+	}
+	if len(at) > 0 {
+		filter["at"] = at
+	}
+	if query.After != nil {
+		filter["$or"] = bson.A{
+			bson.M{"at": bson.M{"$gt": query.After.At}},
+			bson.M{"at": query.After.At, "id": bson.M{"$gt": query.After.ID}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "id", Value: 1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := s.eventCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	domainEvents := []DomainEvent{}
+	for cursor.Next(ctx) {
+		var event DomainEvent
+		if err := cursor.Decode(&event); err != nil {
+			return nil, err
+		}
+		domainEvents = append(domainEvents, event)
+	}
+	return domainEvents, cursor.Err()
+}
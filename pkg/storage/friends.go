@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Friendship is one document of the friendships collection. It starts as a
+// request from Requester to Addressee and becomes a friendship once accepted.
+type Friendship struct {
+	// Pair identifies the two players whatever the direction of the request.
+	Pair       string    `bson:"pair"`
+	Players    []string  `bson:"players"`
+	Requester  string    `bson:"requester"`
+	Addressee  string    `bson:"addressee"`
+	Accepted   bool      `bson:"accepted"`
+	CreatedAt  time.Time `bson:"createdat"`
+	AcceptedAt time.Time `bson:"acceptedat,omitempty"`
+}
+
+// Other returns the player of the friendship that is not playerID.
+func (f *Friendship) Other(playerID string) string {
+	if f.Requester == playerID {
+		return f.Addressee
+	}
+	return f.Requester
+}
+
+// PlayerPresence holds what the presence of a player is made of.
+type PlayerPresence struct {
+	ID          string    `bson:"id"`
+	DisplayName string    `bson:"displayname"`
+	Room        string    `bson:"room"`
+	LastSeenAt  time.Time `bson:"lastseenat"`
+}
+
+// Helper function to key a pair of players the same way in both directions.
+// The length prefix keeps IDs containing the separator apart.
+func friendshipPair(a string, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d:%s|%s", len(a), a, b)
+}
+
+// GetFriendship returns the request or friendship between two players, or
+// nil when there is none.
+func (s *MongoDBStorage) GetFriendship(ctx context.Context, a string, b string) (*Friendship, error) {
+	var friendship Friendship
+	err := s.friendCollection.FindOne(ctx, bson.M{"pair": friendshipPair(a, b)}).Decode(&friendship)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &friendship, nil
+}
+
+// CreateFriendRequest stores a pending request. When two requests between the
+// same players race, the one stored first wins.
+func (s *MongoDBStorage) CreateFriendRequest(ctx context.Context, requester string, addressee string) error {
+	friendship := Friendship{
+		Pair:      friendshipPair(requester, addressee),
+		Players:   []string{requester, addressee},
+		Requester: requester,
+		Addressee: addressee,
+		CreatedAt: time.Now().UTC(),
+	}
+	_, err := s.friendCollection.InsertOne(ctx, &friendship)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// AcceptFriendRequest turns the pending request from requester to addressee
+// into a friendship.
+func (s *MongoDBStorage) AcceptFriendRequest(ctx context.Context, requester string, addressee string) error {
+	filter := bson.M{"pair": friendshipPair(requester, addressee), "requester": requester, "accepted": false}
+	update := bson.M{"$set": bson.M{"accepted": true, "acceptedat": time.Now().UTC()}}
+	result, err := s.friendCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormanagement.FriendRequestNotFound
+	}
+	return nil
+}
+
+// DeleteFriendship removes the pending request between two players, in either
+// direction, or their friendship when accepted is set. It reports whether
+// there was one.
+func (s *MongoDBStorage) DeleteFriendship(ctx context.Context, a string, b string, accepted bool) (bool, error) {
+	result, err := s.friendCollection.DeleteOne(ctx, bson.M{"pair": friendshipPair(a, b), "accepted": accepted})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// DeleteFriendshipsOf removes every request and friendship of a player.
+func (s *MongoDBStorage) DeleteFriendshipsOf(ctx context.Context, playerID string) error {
+	_, err := s.friendCollection.DeleteMany(ctx, bson.M{"players": playerID})
+	return err
+}
+
+// ListFriendships returns the friendships of a player, or its pending requests
+// in both directions when accepted is false, oldest first.
+func (s *MongoDBStorage) ListFriendships(ctx context.Context, playerID string, accepted bool) ([]Friendship, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}})
+	cursor, err := s.friendCollection.Find(ctx, bson.M{"players": playerID, "accepted": accepted}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	friendships := []Friendship{}
+	for cursor.Next(ctx) {
+		var friendship Friendship
+		if err := cursor.Decode(&friendship); err != nil {
+			return nil, err
+		}
+		friendships = append(friendships, friendship)
+	}
+	return friendships, cursor.Err()
+}
+
+// TouchPlayer records that a player was seen at the given time.
+func (s *MongoDBStorage) TouchPlayer(ctx context.Context, playerID string, at time.Time) error {
+	update := bson.M{"$set": bson.M{"lastseenat": at.UTC()}}
+	result, err := s.playerCollection.UpdateOne(ctx, bson.M{"id": playerID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormanagement.PlayerNotFound
+	}
+	return nil
+}
+
+// GetPlayerPresences returns the presence of the given players that exist.
+func (s *MongoDBStorage) GetPlayerPresences(ctx context.Context, playerIDs []string) ([]PlayerPresence, error) {
+	presences := []PlayerPresence{}
+	if len(playerIDs) == 0 {
+		return presences, nil
+	}
+	cursor, err := s.playerCollection.Find(ctx, bson.M{"id": bson.M{"$in": playerIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var presence PlayerPresence
+		if err := cursor.Decode(&presence); err != nil {
+			return nil, err
+		}
+		presences = append(presences, presence)
+	}
+	return presences, cursor.Err()
+}
+
+// GetRoomsByIDs returns the given rooms that exist.
+func (s *MongoDBStorage) GetRoomsByIDs(ctx context.Context, roomIDs []string) ([]*models.Room, error) {
+	rooms := []*models.Room{}
+	if len(roomIDs) == 0 {
+		return rooms, nil
+	}
+	cursor, err := s.roomCollection.Find(ctx, bson.M{"id": bson.M{"$in": roomIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var room models.Room
+		if err := cursor.Decode(&room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, &room)
+	}
+	return rooms, cursor.Err()
+}
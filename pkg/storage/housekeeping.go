@@ -10,33 +10,73 @@ import (
 	"time"
 )
 
-func (s *MongoDBStorage) GetPlayerByID(playerID string) (*models.Player, error) {
+func (s *MongoDBStorage) GetPlayerByID(ctx context.Context, playerID string) (*models.Player, error) {
 	filter := bson.M{"id": playerID}
 	var player models.Player
-	err := s.playerCollection.FindOne(context.Background(), filter).Decode(&player)
+	err := s.playerCollection.FindOne(ctx, filter).Decode(&player)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errormanagement.PlayerNotFound
 		}
+		return nil, err
 	}
 	return &player, nil
 }
 
+// GetPlayerSecretHash returns the hash of the secret of a player, empty for
+// players created before they had one.
+func (s *MongoDBStorage) GetPlayerSecretHash(playerID string) (string, error) {
+	filter := bson.M{"id": playerID}
+	var credentials struct {
+		SecretHash string `bson:"secrethash"`
+	}
+	err := s.playerCollection.FindOne(context.Background(), filter).Decode(&credentials)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", errormanagement.PlayerNotFound
+		}
+		return "", err
+	}
+	return credentials.SecretHash, nil
+}
+
+// SetPlayerSecretHash replaces the hash of the secret of a player.
+func (s *MongoDBStorage) SetPlayerSecretHash(ctx context.Context, playerID string, secretHash string) error {
+	update := bson.M{"$set": bson.M{"secrethash": secretHash}}
+	result, err := s.playerCollection.UpdateOne(ctx, bson.M{"id": playerID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormanagement.PlayerNotFound
+	}
+	return nil
+}
+
+// CountPlayersWithoutSecret returns how many players were created before
+// players had secrets and haven't been issued one since.
+func (s *MongoDBStorage) CountPlayersWithoutSecret(ctx context.Context) (int64, error) {
+	filter := bson.M{"secrethash": bson.M{"$in": bson.A{nil, ""}}}
+	return s.playerCollection.CountDocuments(ctx, filter)
+}
+
+// PlayerIsAlreadyRegistered reports whether the ID is taken, by a player or
+// by a deleted player whose ID may not be reused yet.
 func (s *MongoDBStorage) PlayerIsAlreadyRegistered(playerID string) bool {
 	filter := bson.M{"id": playerID}
 	var player models.Player
 	err := s.playerCollection.FindOne(context.Background(), filter).Decode(&player)
 	if err == mongo.ErrNoDocuments {
-		return false
+		return s.playerIDIsRetired(context.Background(), playerID)
 	}
 	return true
 }
 
-func (s *MongoDBStorage) GetRoomByID(roomID string) (*models.Room, error) {
+func (s *MongoDBStorage) GetRoomByID(ctx context.Context, roomID string) (*models.Room, error) {
 	filter := bson.M{"id": roomID}
 
 	var room models.Room
-	err := s.roomCollection.FindOne(context.Background(), filter).Decode(&room)
+	err := s.roomCollection.FindOne(ctx, filter).Decode(&room)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errormanagement.RoomNotFound
@@ -47,10 +87,33 @@ func (s *MongoDBStorage) GetRoomByID(roomID string) (*models.Room, error) {
 	return &room, nil
 }
 
-func (s *MongoDBStorage) DeleteRoom(roomID string) error {
+func (s *MongoDBStorage) DeleteRoom(ctx context.Context, roomID string) error {
+	room, err := s.GetRoomByID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
 	filter := bson.M{"id": roomID}
-	_, err := s.roomCollection.DeleteOne(context.Background(), filter)
-	return err
+	_, err = s.roomCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	// Release everyone who was still inside and take them off the trend counters.
+	for _, playerId := range room.PlayerIds {
+		player, err := s.GetPlayerByID(ctx, playerId)
+		if err != nil {
+			continue
+		}
+		update := bson.M{"$set": bson.M{"room": ""}}
+		if _, err := s.playerCollection.UpdateOne(ctx, bson.M{"id": playerId}, update); err != nil {
+			return err
+		}
+		if err := s.incrementTrend(ctx, player.Region, room.Mode, -1); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Helper function to generate a random room ID.
@@ -63,3 +126,36 @@ func generateRoomID() string {
 	}
 	return string(b)
 }
+
+// UpdatePlayerRegion moves a player to another region, along with the region
+// counts of its room and the trend counters it contributes to.
+func (s *MongoDBStorage) UpdatePlayerRegion(ctx context.Context, playerID string, region string) error {
+	player, err := s.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if player.Region == region {
+		return nil
+	}
+
+	update := bson.M{"$set": bson.M{"region": region}}
+	if _, err := s.playerCollection.UpdateOne(ctx, bson.M{"id": playerID}, update); err != nil {
+		return err
+	}
+	if player.Room == "" {
+		return nil
+	}
+
+	room, err := s.GetRoomByID(ctx, player.Room)
+	if err != nil {
+		return err
+	}
+	roomUpdate := bson.M{"$inc": bson.M{"regioncounts." + player.Region: -1, "regioncounts." + region: 1}}
+	if _, err := s.roomCollection.UpdateOne(ctx, bson.M{"id": room.Id}, roomUpdate); err != nil {
+		return err
+	}
+	if err := s.incrementTrend(ctx, player.Region, room.Mode, -1); err != nil {
+		return err
+	}
+	return s.incrementTrend(ctx, region, room.Mode, 1)
+}
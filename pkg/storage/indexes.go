@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// trendIndexName names the unique index of the trend counters. Older
+// deployments have a non-unique index of the same name, which is replaced.
+const trendIndexName = "region_1_mode_1"
+
+// EnsureIndexes creates the indexes the queries of this package rely on.
+// Indexes that already exist are left as they are.
+func (s *MongoDBStorage) EnsureIndexes(ctx context.Context) error {
+	if err := s.dedupeTrendCounters(ctx); err != nil {
+		return err
+	}
+
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		s.roomCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}},
+			// Room listings filter on the mode and page through one of the sort fields.
+			{Keys: bson.D{{Key: "mode", Value: 1}, {Key: SortRoomsByAge, Value: 1}, {Key: "id", Value: 1}}},
+			{Keys: bson.D{{Key: "mode", Value: 1}, {Key: SortRoomsByOccupancy, Value: 1}, {Key: "id", Value: 1}}},
+			{Keys: bson.D{{Key: "regioncounts.$**", Value: 1}}},
+		},
+		s.playerCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}},
+			{Keys: bson.D{{Key: "room", Value: 1}}},
+		},
+		s.trendCollection: {
+			{Keys: bson.D{{Key: "region", Value: 1}, {Key: "mode", Value: 1}}, Options: options.Index().SetName(trendIndexName).SetUnique(true)},
+		},
+		s.retiredCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// Retired IDs are dropped by MongoDB once they can be reused; those retired for good have no date.
+			{Keys: bson.D{{Key: "reusableat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		s.friendCollection: {
+			{Keys: bson.D{{Key: "pair", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "players", Value: 1}, {Key: "accepted", Value: 1}, {Key: "createdat", Value: 1}}},
+		},
+		s.blockCollection: {
+			{Keys: bson.D{{Key: "blocker", Value: 1}, {Key: "blocked", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// Joins look the blocks up from both sides.
+			{Keys: bson.D{{Key: "blocked", Value: 1}, {Key: "blocker", Value: 1}}},
+		},
+	}
+
+	for collection, collectionIndexes := range indexes {
+		if _, err := collection.Indexes().CreateMany(ctx, collectionIndexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
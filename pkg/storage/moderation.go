@@ -0,0 +1,387 @@
+package storage
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+// Match records that a player left a room, and who it had played with there.
+// Matches are only kept for as long as they can be reported.
+type Match struct {
+	Player    string    `bson:"player"`
+	RoomID    string    `bson:"roomid"`
+	Mode      string    `bson:"mode"`
+	Players   []string  `bson:"players"`
+	LeftAt    time.Time `bson:"leftat"`
+	ExpiresAt time.Time `bson:"expiresat"`
+}
+
+// ReportRoom is the room a report was made about, as it was when reported.
+// Source tells whether the players were still in it ("current") or had played
+// together recently ("recent").
+type ReportRoom struct {
+	ID      string   `bson:"id"`
+	Mode    string   `bson:"mode"`
+	Players []string `bson:"players"`
+	Source  string   `bson:"source"`
+}
+
+// Report is one entry of the moderation queue.
+type Report struct {
+	ID        string     `bson:"id"`
+	Reporter  string     `bson:"reporter"`
+	Reported  string     `bson:"reported"`
+	Category  string     `bson:"category"`
+	Text      string     `bson:"text,omitempty"`
+	Room      ReportRoom `bson:"room"`
+	State     string     `bson:"state"`
+	CreatedAt time.Time  `bson:"createdat"`
+	UpdatedAt time.Time  `bson:"updatedat"`
+	// The API key of the moderator who last changed the state, and their note.
+	ReviewedBy string `bson:"reviewedby,omitempty"`
+	Note       string `bson:"note,omitempty"`
+}
+
+// ReportFilter selects a page of the moderation queue, oldest first. Empty
+// fields don't filter; After continues from the last report of a page.
+type ReportFilter struct {
+	State    string
+	Reported string
+	After    *Report
+	Limit    int
+}
+
+// Ban keeps a player out of rooms until ExpiresAt, or for good when it is
+// zero, unless it is lifted earlier.
+type Ban struct {
+	ID        string    `bson:"id"`
+	PlayerID  string    `bson:"playerid"`
+	Reason    string    `bson:"reason"`
+	ReportIDs []string  `bson:"reportids,omitempty"`
+	IssuedBy  string    `bson:"issuedby"`
+	CreatedAt time.Time `bson:"createdat"`
+	ExpiresAt time.Time `bson:"expiresat,omitempty"`
+	LiftedAt  time.Time `bson:"liftedat,omitempty"`
+}
+
+// ModerationStorage keeps the reports players make, the bans moderators issue
+// and the recent matches reports are checked against.
+type ModerationStorage struct {
+	matchCollection  *mongo.Collection
+	reportCollection *mongo.Collection
+	banCollection    *mongo.Collection
+}
+
+func NewModerationStorage(matches *mongo.Collection, reports *mongo.Collection, bans *mongo.Collection) *ModerationStorage {
+	return &ModerationStorage{
+		matchCollection:  matches,
+		reportCollection: reports,
+		banCollection:    bans,
+	}
+}
+
+// EnsureIndexes creates the indexes the moderation queries rely on.
+func (s *ModerationStorage) EnsureIndexes(ctx context.Context) error {
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		s.matchCollection: {
+			{Keys: bson.D{{Key: "player", Value: 1}, {Key: "leftat", Value: -1}}},
+			{Keys: bson.D{{Key: "players", Value: 1}, {Key: "leftat", Value: -1}}},
+			{Keys: bson.D{{Key: "expiresat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		s.reportCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "state", Value: 1}, {Key: "createdat", Value: 1}, {Key: "id", Value: 1}}},
+			{Keys: bson.D{{Key: "reported", Value: 1}, {Key: "createdat", Value: 1}, {Key: "id", Value: 1}}},
+			{Keys: bson.D{{Key: "reporter", Value: 1}, {Key: "reported", Value: 1}, {Key: "state", Value: 1}}},
+			{Keys: bson.D{{Key: "room.players", Value: 1}}},
+		},
+		s.banCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "playerid", Value: 1}, {Key: "createdat", Value: -1}}},
+		},
+	}
+
+	for collection, collectionIndexes := range indexes {
+		if _, err := collection.Indexes().CreateMany(ctx, collectionIndexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordMatches stores a match for each of the players leaving a room. The
+// others are everyone who was in the room with them.
+func (s *ModerationStorage) RecordMatches(ctx context.Context, roomID string, mode string, members []string, leaving []string, leftAt time.Time, expiresAt time.Time) error {
+	matches := make([]interface{}, 0, len(leaving))
+	for _, playerID := range leaving {
+		others := make([]string, 0, len(members))
+		for _, member := range members {
+			if member != playerID {
+				others = append(others, member)
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		matches = append(matches, Match{
+			Player:    playerID,
+			RoomID:    roomID,
+			Mode:      mode,
+			Players:   others,
+			LeftAt:    leftAt.UTC(),
+			ExpiresAt: expiresAt.UTC(),
+		})
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	_, err := s.matchCollection.InsertMany(ctx, matches)
+	return err
+}
+
+// LastSharedMatch returns the latest match two players played together that
+// has not expired, or nil when there is none.
+func (s *ModerationStorage) LastSharedMatch(ctx context.Context, a string, b string, now time.Time) (*Match, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"player": a, "players": b},
+			bson.M{"player": b, "players": a},
+		},
+		// MongoDB only drops expired matches every minute or so.
+		"expiresat": bson.M{"$gt": now.UTC()},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "leftat", Value: -1}})
+	var match Match
+	err := s.matchCollection.FindOne(ctx, filter, opts).Decode(&match)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// DeleteMatchesOf removes the matches a player left.
+func (s *ModerationStorage) DeleteMatchesOf(ctx context.Context, playerID string) error {
+	_, err := s.matchCollection.DeleteMany(ctx, bson.M{"player": playerID})
+	return err
+}
+
+func (s *ModerationStorage) CreateReport(ctx context.Context, report *Report) error {
+	_, err := s.reportCollection.InsertOne(ctx, report)
+	return err
+}
+
+// FindOpenReport returns the open report a player already made about another
+// one for a room, or nil when there is none.
+func (s *ModerationStorage) FindOpenReport(ctx context.Context, reporter string, reported string, roomID string) (*Report, error) {
+	filter := bson.M{"reporter": reporter, "reported": reported, "state": "open", "room.id": roomID}
+	var report Report
+	err := s.reportCollection.FindOne(ctx, filter).Decode(&report)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (s *ModerationStorage) GetReport(ctx context.Context, id string) (*Report, error) {
+	var report Report
+	err := s.reportCollection.FindOne(ctx, bson.M{"id": id}).Decode(&report)
+	if err == mongo.ErrNoDocuments {
+		return nil, errormanagement.ReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports returns a page of the reports matching the filter, oldest first.
+func (s *ModerationStorage) ListReports(ctx context.Context, query ReportFilter) ([]Report, error) {
+	filter := bson.M{}
+	if query.State != "" {
+		filter["state"] = query.State
+	}
+	if query.Reported != "" {
+		filter["reported"] = query.Reported
+	}
+	if query.After != nil {
+		filter["$or"] = bson.A{
+			bson.M{"createdat": bson.M{"$gt": query.After.CreatedAt}},
+			bson.M{"createdat": query.After.CreatedAt, "id": bson.M{"$gt": query.After.ID}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}, {Key: "id", Value: 1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := s.reportCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []Report{}
+	for cursor.Next(ctx) {
+		var report Report
+		if err := cursor.Decode(&report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, cursor.Err()
+}
+
+// UpdateReportState moves reports to a state on behalf of a moderator. The
+// note is left as it is when empty.
+func (s *ModerationStorage) UpdateReportState(ctx context.Context, ids []string, state string, reviewedBy string, note string) error {
+	set := bson.M{"state": state, "reviewedby": reviewedBy, "updatedat": time.Now().UTC()}
+	if note != "" {
+		set["note"] = note
+	}
+	_, err := s.reportCollection.UpdateMany(ctx, bson.M{"id": bson.M{"$in": ids}}, bson.M{"$set": set})
+	return err
+}
+
+// PseudonymizePlayer replaces a deleted player with a pseudonym in the reports
+// naming them, as reporter, as reported or as someone in the room. The text of
+// the reports they made goes, and the pseudonym replaces their ID in the text
+// and notes of the others.
+func (s *ModerationStorage) PseudonymizePlayer(ctx context.Context, playerID string, pseudonym string) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"reporter": playerID},
+		bson.M{"reported": playerID},
+		bson.M{"room.players": playerID},
+	}}
+	cursor, err := s.reportCollection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var reports []Report
+	if err := cursor.All(ctx, &reports); err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		players := make([]string, len(report.Room.Players))
+		for i, player := range report.Room.Players {
+			players[i] = pseudonymOf(player, playerID, pseudonym)
+		}
+		set := bson.M{
+			"reporter":     pseudonymOf(report.Reporter, playerID, pseudonym),
+			"reported":     pseudonymOf(report.Reported, playerID, pseudonym),
+			"room.players": players,
+		}
+		update := bson.M{"$set": set}
+		if report.Note != "" {
+			set["note"] = strings.ReplaceAll(report.Note, playerID, pseudonym)
+		}
+		if report.Reporter == playerID {
+			update["$unset"] = bson.M{"text": ""}
+		} else if report.Text != "" {
+			set["text"] = strings.ReplaceAll(report.Text, playerID, pseudonym)
+		}
+		if _, err := s.reportCollection.UpdateOne(ctx, bson.M{"id": report.ID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ModerationStorage) CreateBan(ctx context.Context, ban *Ban) error {
+	_, err := s.banCollection.InsertOne(ctx, ban)
+	return err
+}
+
+// ActiveBan returns the ban keeping a player out of rooms at the given time,
+// preferring a permanent one and then the one ending last, or nil when the
+// player is free to play.
+func (s *ModerationStorage) ActiveBan(ctx context.Context, playerID string, now time.Time) (*Ban, error) {
+	filter := bson.M{
+		"playerid": playerID,
+		"liftedat": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"expiresat": bson.M{"$exists": false}},
+			bson.M{"expiresat": bson.M{"$gt": now.UTC()}},
+		},
+	}
+	bans, err := s.findBans(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var active *Ban
+	for i := range bans {
+		switch {
+		case active == nil:
+			active = &bans[i]
+		case active.ExpiresAt.IsZero():
+		case bans[i].ExpiresAt.IsZero() || bans[i].ExpiresAt.After(active.ExpiresAt):
+			active = &bans[i]
+		}
+	}
+	return active, nil
+}
+
+// ListBans returns every ban a player was issued, newest first.
+func (s *ModerationStorage) ListBans(ctx context.Context, playerID string) ([]Ban, error) {
+	return s.findBans(ctx, bson.M{"playerid": playerID})
+}
+
+// LiftBan ends a ban of a player early, and tells whether this call lifted
+// it. Lifting it again changes nothing.
+func (s *ModerationStorage) LiftBan(ctx context.Context, playerID string, id string) (*Ban, bool, error) {
+	filter := bson.M{"id": id, "playerid": playerID}
+	update := bson.M{"$set": bson.M{"liftedat": time.Now().UTC()}}
+	result, err := s.banCollection.UpdateOne(ctx, bson.M{"id": id, "playerid": playerID, "liftedat": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ban Ban
+	err = s.banCollection.FindOne(ctx, filter).Decode(&ban)
+	if err == mongo.ErrNoDocuments {
+		return nil, false, errormanagement.BanNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &ban, result.ModifiedCount > 0, nil
+}
+
+// Helper function to run a query for bans, newest first.
+func (s *ModerationStorage) findBans(ctx context.Context, filter bson.M) ([]Ban, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: -1}})
+	cursor, err := s.banCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bans := []Ban{}
+	for cursor.Next(ctx) {
+		var ban Ban
+		if err := cursor.Decode(&ban); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, cursor.Err()
+}
+
+// Helper function to swap the ID of a deleted player for its pseudonym.
+func pseudonymOf(id string, playerID string, pseudonym string) string {
+	if id == playerID {
+		return pseudonym
+	}
+	return id
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// UpdatePlayerDisplayName sets the name shown for a player, or removes it
+// when name is empty.
+func (s *MongoDBStorage) UpdatePlayerDisplayName(ctx context.Context, playerID string, name string) error {
+	update := bson.M{"$set": bson.M{"displayname": name}}
+	if name == "" {
+		update = bson.M{"$unset": bson.M{"displayname": ""}}
+	}
+	result, err := s.playerCollection.UpdateOne(ctx, bson.M{"id": playerID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormanagement.PlayerNotFound
+	}
+	return nil
+}
+
+// NormalizePlayerRegions rewrites the regions of players stored before region
+// codes were normalized, such as "blr " for "BLR". The room and trend counters
+// must be rebuilt afterwards.
+func (s *MongoDBStorage) NormalizePlayerRegions(ctx context.Context) error {
+	normalized := bson.M{"$toUpper": bson.M{"$trim": bson.M{"input": "$region"}}}
+	filter := bson.M{"$expr": bson.M{"$ne": bson.A{"$region", normalized}}}
+	update := bson.A{bson.M{"$set": bson.M{"region": normalized}}}
+	_, err := s.playerCollection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// DeletePlayer removes the document of a player, secret hash included. The
+// player must have left its room already.
+func (s *MongoDBStorage) DeletePlayer(ctx context.Context, playerID string) error {
+	result, err := s.playerCollection.DeleteOne(ctx, bson.M{"id": playerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errormanagement.PlayerNotFound
+	}
+	return nil
+}
+
+// RetirePlayerID keeps the ID of a deleted player from being registered again
+// until reusableAt, or for good when reusableAt is zero.
+func (s *MongoDBStorage) RetirePlayerID(ctx context.Context, playerID string, reusableAt time.Time) error {
+	retired := bson.M{"id": playerID, "retiredat": time.Now()}
+	if !reusableAt.IsZero() {
+		retired["reusableat"] = reusableAt
+	}
+	_, err := s.retiredCollection.ReplaceOne(ctx, bson.M{"id": playerID}, retired, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Helper function to check if an ID is retired. MongoDB only sweeps expired
+// documents once a minute, so the date is checked as well. An ID that can't
+// be looked up counts as retired rather than handing it out twice.
+func (s *MongoDBStorage) playerIDIsRetired(ctx context.Context, playerID string) bool {
+	filter := bson.M{
+		"id": playerID,
+		"$or": bson.A{
+			bson.M{"reusableat": bson.M{"$exists": false}},
+			bson.M{"reusableat": bson.M{"$gt": time.Now()}},
+		},
+	}
+	count, err := s.retiredCollection.CountDocuments(ctx, filter)
+	return err != nil || count > 0
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Room fields a listing can be sorted by.
+const (
+	SortRoomsByAge       = "createdat"
+	SortRoomsByOccupancy = "playercount"
+)
+
+// RoomFilter selects one page of the rooms of a mode. Zero values leave the
+// corresponding filter out.
+type RoomFilter struct {
+	Mode string
+	// Only rooms with fewer players than this.
+	MaxPlayers int
+	// Only rooms with at least this many players.
+	MinPlayers int
+	// Only rooms with at least one member from this region.
+	Region string
+	// Only private rooms when true, only public rooms when false.
+	Private *bool
+	// Only rooms created after this time, in Unix milliseconds.
+	CreatedAfter int64
+
+	SortBy     string
+	Descending bool
+	Limit      int
+	// Only rooms sorting after this one, to continue a previous page.
+	After *RoomPosition
+}
+
+// RoomPosition is the place of a room in a listing: the value of the sort
+// field with the room ID breaking ties.
+type RoomPosition struct {
+	Value int64  `json:"v"`
+	ID    string `json:"id"`
+}
+
+// Position returns the place of room in listings sorted by sortBy.
+func Position(room *models.Room, sortBy string) RoomPosition {
+	if sortBy == SortRoomsByOccupancy {
+		return RoomPosition{Value: int64(room.PlayerCount), ID: room.Id}
+	}
+	return RoomPosition{Value: room.CreatedAt, ID: room.Id}
+}
+
+// ListRooms returns the rooms matching the filter, sorted and paged on the
+// sort field and the room ID so that every query is served by an index.
+func (s *MongoDBStorage) ListRooms(ctx context.Context, query RoomFilter) ([]*models.Room, error) {
+	filter := bson.M{"mode": query.Mode}
+
+	playerCount := bson.M{}
+	if query.MaxPlayers > 0 {
+		playerCount["$lt"] = query.MaxPlayers
+	}
+	if query.MinPlayers > 0 {
+		playerCount["$gte"] = query.MinPlayers
+	}
+	if len(playerCount) > 0 {
+		filter["playercount"] = playerCount
+	}
+	if query.Region != "" {
+		filter["regioncounts."+query.Region] = bson.M{"$gt": 0}
+	}
+	if query.Private != nil {
+		// Rooms created before rooms could be private have no such field.
+		if *query.Private {
+			filter["private"] = true
+		} else {
+			filter["private"] = bson.M{"$ne": true}
+		}
+	}
+	if query.CreatedAfter > 0 {
+		filter["createdat"] = bson.M{"$gt": query.CreatedAfter}
+	}
+
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = SortRoomsByAge
+	}
+	direction, after := 1, "$gt"
+	if query.Descending {
+		direction, after = -1, "$lt"
+	}
+	if query.After != nil {
+		filter["$or"] = bson.A{
+			bson.M{sortBy: bson.M{after: query.After.Value}},
+			bson.M{sortBy: query.After.Value, "id": bson.M{after: query.After.ID}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: sortBy, Value: direction}, {Key: "id", Value: direction}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+
+	cursor, err := s.roomCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rooms := []*models.Room{}
+	for cursor.Next(ctx) {
+		var room models.Room
+		if err := cursor.Decode(&room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, &room)
+	}
+
+	return rooms, cursor.Err()
+}
+
+// RebuildRoomCounters recomputes the player and region counts of every room
+// from its members, filling them in for rooms stored before they existed.
+func (s *MongoDBStorage) RebuildRoomCounters(ctx context.Context) error {
+	regions := make(map[string]string)
+	playerCursor, err := s.playerCollection.Find(ctx, bson.M{"room": bson.M{"$ne": ""}})
+	if err != nil {
+		return err
+	}
+	defer playerCursor.Close(ctx)
+	for playerCursor.Next(ctx) {
+		var player models.Player
+		if err := playerCursor.Decode(&player); err != nil {
+			return err
+		}
+		regions[player.Id] = player.Region
+	}
+	if err := playerCursor.Err(); err != nil {
+		return err
+	}
+
+	roomCursor, err := s.roomCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer roomCursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for roomCursor.Next(ctx) {
+		var room models.Room
+		if err := roomCursor.Decode(&room); err != nil {
+			return err
+		}
+		regionCounts := make(map[string]int32)
+		for _, playerId := range room.PlayerIds {
+			if region, ok := regions[playerId]; ok {
+				regionCounts[region]++
+			}
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"id": room.Id}).
+			SetUpdate(bson.M{"$set": bson.M{"playercount": len(room.PlayerIds), "regioncounts": regionCounts}}))
+	}
+	if err := roomCursor.Err(); err != nil {
+		return err
+	}
+
+	if len(writes) == 0 {
+		return nil
+	}
+	_, err = s.roomCollection.BulkWrite(ctx, writes)
+	return err
+}
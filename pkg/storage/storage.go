@@ -1,173 +1,176 @@
 package storage
 
 import (
+	"DeathfireArsenal/internal/constants"
+	"DeathfireArsenal/internal/errormanagement"
 	"DeathfireArsenal/pkg/models"
 	"context"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"sort"
+	"time"
 )
 
 type MongoDBStorage struct {
-	roomCollection   *mongo.Collection
-	playerCollection *mongo.Collection
+	roomCollection    *mongo.Collection
+	playerCollection  *mongo.Collection
+	trendCollection   *mongo.Collection
+	retiredCollection *mongo.Collection
+	friendCollection  *mongo.Collection
+	blockCollection   *mongo.Collection
 }
 
-func NewMongoDBStorage(rooms *mongo.Collection, players *mongo.Collection) *MongoDBStorage {
+func NewMongoDBStorage(rooms *mongo.Collection, players *mongo.Collection, trends *mongo.Collection, retiredIDs *mongo.Collection, friendships *mongo.Collection, blocks *mongo.Collection) *MongoDBStorage {
 	return &MongoDBStorage{
-		roomCollection:   rooms,
-		playerCollection: players,
+		roomCollection:    rooms,
+		playerCollection:  players,
+		trendCollection:   trends,
+		retiredCollection: retiredIDs,
+		friendCollection:  friendships,
+		blockCollection:   blocks,
 	}
 }
 
-func (s *MongoDBStorage) CreatePlayer(playerId string, regionCode string) error {
-	player := models.Player{Id: playerId, Region: regionCode, Room: ""}
-	_, err := s.playerCollection.InsertOne(context.Background(), player)
+// CreatePlayer stores the player along with the hash of its secret. The hash
+// lives beside the fields of models.Player so that it never reaches clients.
+func (s *MongoDBStorage) CreatePlayer(ctx context.Context, playerId string, regionCode string, secretHash string) error {
+	player := bson.M{"id": playerId, "region": regionCode, "room": "", "secrethash": secretHash}
+	_, err := s.playerCollection.InsertOne(ctx, player)
 	return err
 }
 
-func (s *MongoDBStorage) CreateRoom(playerId string, mode string) (string, error) {
+func (s *MongoDBStorage) CreateRoom(ctx context.Context, playerId string, mode string, private bool) (string, error) {
 	filter := bson.M{"id": playerId}
 	playerList := []string{playerId}
 
+	player, err := s.GetPlayerByID(ctx, playerId)
+	if err != nil {
+		return "", err
+	}
+
 	//	Create room
 	random_room_id := generateRoomID()
-	room := models.Room{Id: random_room_id, Mode: mode, PlayerIds: playerList}
+	room := models.Room{
+		Id:           random_room_id,
+		Mode:         mode,
+		PlayerIds:    playerList,
+		CreatedAt:    time.Now().UnixMilli(),
+		Private:      private,
+		PlayerCount:  1,
+		RegionCounts: map[string]int32{player.Region: 1},
+	}
 	update := bson.M{"$set": bson.M{"room": random_room_id}}
-	_, err := s.playerCollection.UpdateOne(context.Background(), filter, update)
+	_, err = s.playerCollection.UpdateOne(ctx, filter, update)
 
 	if err != nil {
 		return "", err
 	}
 
-	_, err = s.roomCollection.InsertOne(context.Background(), room)
+	_, err = s.roomCollection.InsertOne(ctx, &room)
 	if err != nil {
 
 		// Reverting previous changes as well - That's attention to detail!
 		update := bson.M{"$set": bson.M{"room": ""}}
-		s.playerCollection.UpdateOne(context.Background(), filter, update)
+		s.playerCollection.UpdateOne(ctx, filter, update)
 
 		return "", err
 	}
+
+	err = s.incrementTrend(ctx, player.Region, mode, 1)
 	return random_room_id, err
 }
 
-func (s *MongoDBStorage) GetRoomsByMode(mode string) ([]*models.Room, error) {
-	filter := bson.M{"mode": mode}
-
-	cur, err := s.roomCollection.Find(context.Background(), filter)
+func (s *MongoDBStorage) AddPlayerToRoom(ctx context.Context, playerId string, roomID string) error {
+	player, err := s.GetPlayerByID(ctx, playerId)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	room, err := s.GetRoomByID(ctx, roomID)
+	if err != nil {
+		return err
 	}
 
-	var rooms []*models.Room
-	for cur.Next(context.Background()) {
-		var room models.Room
-		err := cur.Decode(&room)
+	// The counters only move when the player was not a member yet and the room
+	// still has a free slot, however many players join at once.
+	roomFilter := bson.M{
+		"id":          roomID,
+		"playerids":   bson.M{"$ne": playerId},
+		"playercount": bson.M{"$lt": constants.RoomLimit(constants.ParseMode(room.Mode))},
+	}
+	update := bson.M{
+		"$addToSet": bson.M{"playerids": playerId},
+		"$inc":      bson.M{"playercount": 1, "regioncounts." + player.Region: 1},
+	}
+	result, err := s.roomCollection.UpdateOne(ctx, roomFilter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// The room is gone or full, or the player got in by another request meanwhile.
+		room, err := s.GetRoomByID(ctx, roomID)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		rooms = append(rooms, &room)
-	}
-
-	if err := cur.Err(); err != nil {
-		return nil, err
+		for _, member := range room.PlayerIds {
+			if member == playerId {
+				return errormanagement.PlayerOccupied
+			}
+		}
+		return errormanagement.RoomIsFull
 	}
 
-	return rooms, nil
-}
-
-func (s *MongoDBStorage) AddPlayerToRoom(playerId string, roomID string) error {
 	playerFilter := bson.M{"id": playerId}
-	update := bson.M{"$set": bson.M{"room": roomID}}
-	_, err := s.playerCollection.UpdateOne(context.Background(), playerFilter, update)
+	update = bson.M{"$set": bson.M{"room": roomID}}
+	_, err = s.playerCollection.UpdateOne(ctx, playerFilter, update)
 	if err != nil {
+		revert := bson.M{
+			"$pull": bson.M{"playerids": playerId},
+			"$inc":  bson.M{"playercount": -1, "regioncounts." + player.Region: -1},
+		}
+		s.roomCollection.UpdateOne(ctx, bson.M{"id": roomID}, revert)
 		return err
 	}
-	roomFilter := bson.M{"id": roomID}
-	update = bson.M{"$addToSet": bson.M{"playerids": playerId}}
-	_, err = s.roomCollection.UpdateOne(context.Background(), roomFilter, update)
 
-	if err != nil {
-		update := bson.M{"$set": bson.M{"room": ""}}
-		s.playerCollection.UpdateOne(context.Background(), playerFilter, update)
-	}
-	return err
+	return s.incrementTrend(ctx, player.Region, room.Mode, 1)
 }
+
 func (s *MongoDBStorage) RemovePlayerFromRoom(ctx context.Context, playerId string) error {
 	// Find the room that the player is currently in.
 	playerFilter := bson.M{"id": playerId}
 	var player models.Player
 	err := s.playerCollection.FindOne(ctx, playerFilter).Decode(&player)
+	if err != nil {
+		return err
+	}
 	// Remove the player from the playerIds list of the room.
 	roomFilter := bson.M{"id": player.Room}
-	update := bson.M{"$pull": bson.M{"playerids": playerId}}
+	memberFilter := bson.M{"id": player.Room, "playerids": playerId}
+	update := bson.M{
+		"$pull": bson.M{"playerids": playerId},
+		"$inc":  bson.M{"playercount": -1, "regioncounts." + player.Region: -1},
+	}
 
-	_, err = s.roomCollection.UpdateOne(ctx, roomFilter, update)
+	var room models.Room
+	result, err := s.roomCollection.UpdateOne(ctx, memberFilter, update)
 	if err != nil {
 		return err
-	} else {
-		var room models.Room
-		err = s.roomCollection.FindOne(ctx, roomFilter).Decode(&room)
-		if err != nil {
-			return err
-		}
-		if len(room.PlayerIds) == 0 {
-			s.roomCollection.DeleteOne(ctx, roomFilter)
-		}
+	}
+	if result.MatchedCount == 0 {
+		// Another request took the player out meanwhile, or the room was closed.
+		return errormanagement.PlayerIdle
+	}
+	err = s.roomCollection.FindOne(ctx, roomFilter).Decode(&room)
+	if err != nil {
+		return err
+	}
+	if len(room.PlayerIds) == 0 {
+		s.roomCollection.DeleteOne(ctx, roomFilter)
 	}
 
 	// Update the player's room field to empty.
 	playerUpdate := bson.M{"$set": bson.M{"room": ""}}
 	_, err = s.playerCollection.UpdateOne(ctx, playerFilter, playerUpdate)
-	return err
-}
-
-func (s *MongoDBStorage) GetModesByRegionTrend(region string) (map[string]int, error) {
-	// Define the filter to find players with non-empty regions
-	filter := bson.M{"room": bson.M{"$ne": ""}, "region": region}
-	ans := make(map[string]int)
-
-	// Execute the query and get the cursor
-	cursor, err := s.playerCollection.Find(context.Background(), filter)
 	if err != nil {
-		return nil, err
-	}
-
-	for cursor.Next(context.Background()) {
-		var player models.Player
-		if err := cursor.Decode(&player); err != nil {
-			return nil, err
-		}
-
-		var room models.Room
-		if err := s.roomCollection.FindOne(context.Background(), bson.M{"id": player.Room}).Decode(&room); err != nil {
-			return nil, err
-		}
-
-		ans[room.Mode]++
-	}
-
-	type ModeCount struct {
-		Mode  string
-		Count int
-	}
-	var modeCounts []ModeCount
-	for mode, count := range ans {
-		modeCounts = append(modeCounts, ModeCount{Mode: mode, Count: count})
-	}
-
-	sort.Slice(modeCounts, func(i, j int) bool {
-		return modeCounts[i].Count > modeCounts[j].Count
-	})
-
-	top3Map := make(map[string]int)
-	for i, entry := range modeCounts {
-		top3Map[entry.Mode] = entry.Count
-		if i == 2 {
-			break
-		}
+		return err
 	}
-
-	return top3Map, nil
+	return s.incrementTrend(ctx, player.Region, room.Mode, -1)
 }
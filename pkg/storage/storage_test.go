@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDatabase returns a database of the test's own on the MongoDB replica
+// set of MONGODB_TEST_URL, dropped once the test is over. Tests needing
+// MongoDB are skipped without it, e.g. run them with
+// MONGODB_TEST_URL=mongodb://localhost:27017/?replicaSet=rs0.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	url := os.Getenv("MONGODB_TEST_URL")
+	if url == "" {
+		t.Skip("MONGODB_TEST_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(url))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	database := client.Database("test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return database
+}
+
+func newTestStorage(t *testing.T, database *mongo.Database) *MongoDBStorage {
+	t.Helper()
+	s := NewMongoDBStorage(database.Collection("rooms"), database.Collection("players"), database.Collection("trends"),
+		database.Collection("retiredplayerids"), database.Collection("friendships"), database.Collection("blocks"))
+	ctx := context.Background()
+	if err := s.CheckTransactions(ctx); err != nil {
+		t.Fatalf("CheckTransactions: %v", err)
+	}
+	if err := s.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("EnsureIndexes: %v", err)
+	}
+	return s
+}
+
+// Helper function to register players, each in its region.
+func createTestPlayers(t *testing.T, s *MongoDBStorage, regions map[string]string) {
+	t.Helper()
+	for id, region := range regions {
+		if err := s.CreatePlayer(context.Background(), id, region, ""); err != nil {
+			t.Fatalf("CreatePlayer(%s): %v", id, err)
+		}
+	}
+}
+
+// Helper function to create a room the way the business logic does.
+func createTestRoom(t *testing.T, s *MongoDBStorage, host string, region string, mode string) string {
+	t.Helper()
+	ctx := context.Background()
+	if err := s.EnsureTrendCounter(ctx, region, mode); err != nil {
+		t.Fatalf("EnsureTrendCounter: %v", err)
+	}
+	var roomID string
+	err := s.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		roomID, err = s.CreateRoom(ctx, host, mode, false)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	return roomID
+}
+
+func TestConcurrentJoinsDoNotOverfillARoom(t *testing.T) {
+	s := newTestStorage(t, testDatabase(t))
+	ctx := context.Background()
+	createTestPlayers(t, s, map[string]string{"host": "EU", "first": "EU", "second": "EU"})
+	// A 1 v 1 room has a single free slot left.
+	roomID := createTestRoom(t, s, "host", "EU", "1 v 1")
+
+	start := make(chan struct{})
+	errs := make(chan error, 2)
+	var wg sync.WaitGroup
+	for _, playerID := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(playerID string) {
+			defer wg.Done()
+			<-start
+			errs <- s.WithTransaction(ctx, func(ctx context.Context) error {
+				return s.AddPlayerToRoom(ctx, playerID, roomID)
+			})
+		}(playerID)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	var joined, full int
+	for err := range errs {
+		switch {
+		case err == nil:
+			joined++
+		case errors.Is(err, errormanagement.RoomIsFull):
+			full++
+		default:
+			t.Fatalf("concurrent join: %v", err)
+		}
+	}
+	if joined != 1 || full != 1 {
+		t.Fatalf("%d joins succeeded and %d found the room full, want one of each", joined, full)
+	}
+	room, err := s.GetRoomByID(ctx, roomID)
+	if err != nil {
+		t.Fatalf("GetRoomByID: %v", err)
+	}
+	if len(room.PlayerIds) != 2 || room.PlayerCount != 2 {
+		t.Fatalf("room has players %v and a count of %d, want 2 of each", room.PlayerIds, room.PlayerCount)
+	}
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WithTransaction runs fn in a MongoDB transaction, so that either all of its
+// writes are stored or none is. The storage calls fn makes take part in the
+// transaction when they are given the context fn is passed. The transaction
+// is retried as a whole on transient errors, so fn may run more than once.
+func (s *MongoDBStorage) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := s.roomCollection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// CheckTransactions makes sure MongoDB can run transactions, which takes a
+// replica set or a sharded cluster; a standalone server can't.
+func (s *MongoDBStorage) CheckTransactions(ctx context.Context) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	command := bson.D{{Key: "hello", Value: 1}}
+	if err := s.roomCollection.Database().RunCommand(ctx, command).Decode(&hello); err != nil {
+		return err
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return errors.New("MongoDB runs as a standalone server, but transactions need a replica set")
+	}
+	return nil
+}
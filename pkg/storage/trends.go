@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"DeathfireArsenal/pkg/models"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TrendCounter is one document of the trends collection: the number of
+// players of a region currently sitting in a room of a given mode.
+type TrendCounter struct {
+	Region string `bson:"region" json:"region"`
+	Mode   string `bson:"mode" json:"mode"`
+	Count  int    `bson:"count" json:"count"`
+}
+
+type trendKey struct {
+	region string
+	mode   string
+}
+
+// How many times seeding a counter is tried when concurrent upserts race to
+// create it.
+const trendUpsertAttempts = 3
+
+// EnsureTrendCounter creates the counter of a region/mode pair, at zero,
+// unless it exists already. It runs before, and outside of, the transaction
+// of a change that raises the counter: concurrent upserts race on the unique
+// index of the pair, and the losers fail with a duplicate key that would abort
+// the transaction. Here they are retried, and then find the counter.
+func (s *MongoDBStorage) EnsureTrendCounter(ctx context.Context, region string, mode string) error {
+	filter := bson.M{"region": region, "mode": mode}
+	update := bson.M{"$setOnInsert": bson.M{"count": 0}}
+	var err error
+	for attempt := 0; attempt < trendUpsertAttempts; attempt++ {
+		_, err = s.trendCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// incrementTrend atomically moves the counter of a region/mode pair by delta.
+// Raising a counter takes it to exist, see EnsureTrendCounter. Lowering a
+// missing one changes nothing; RebuildTrendCounters corrects such drift.
+func (s *MongoDBStorage) incrementTrend(ctx context.Context, region string, mode string, delta int) error {
+	filter := bson.M{"region": region, "mode": mode}
+	result, err := s.trendCollection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"count": delta}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 && delta > 0 {
+		return fmt.Errorf("storage: there is no trend counter for region %q and mode %q", region, mode)
+	}
+	return nil
+}
+
+// GetTrendCounters returns every non-zero counter of the given regions, or of
+// all regions when none are given, ordered by count.
+func (s *MongoDBStorage) GetTrendCounters(regions []string) ([]TrendCounter, error) {
+	filter := bson.M{"count": bson.M{"$gt": 0}}
+	if len(regions) > 0 {
+		filter["region"] = bson.M{"$in": regions}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "count", Value: -1}, {Key: "mode", Value: 1}})
+
+	cursor, err := s.trendCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	counters := []TrendCounter{}
+	for cursor.Next(context.Background()) {
+		var counter TrendCounter
+		if err := cursor.Decode(&counter); err != nil {
+			return nil, err
+		}
+		counters = append(counters, counter)
+	}
+
+	return counters, cursor.Err()
+}
+
+// RebuildTrendCounters recomputes every counter from the players and rooms
+// collections and overwrites the stored values, correcting any drift left by
+// partially applied mutations. The players are counted and the counters
+// written in one transaction, so a change committed meanwhile conflicts with
+// it and is retried, instead of being overwritten.
+func (s *MongoDBStorage) RebuildTrendCounters(ctx context.Context) error {
+	// Like on every change, missing counters are created ahead of the transaction.
+	counts, err := s.countTrends(ctx)
+	if err != nil {
+		return err
+	}
+	for key := range counts {
+		if err := s.EnsureTrendCounter(ctx, key.region, key.mode); err != nil {
+			return err
+		}
+	}
+
+	return s.WithTransaction(ctx, func(ctx context.Context) error {
+		counts, err := s.countTrends(ctx)
+		if err != nil || len(counts) == 0 {
+			return err
+		}
+		writes := make([]mongo.WriteModel, 0, len(counts))
+		for key, count := range counts {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"region": key.region, "mode": key.mode}).
+				SetUpdate(bson.M{"$set": bson.M{"count": count}}))
+		}
+		_, err = s.trendCollection.BulkWrite(ctx, writes)
+		return err
+	})
+}
+
+// countTrends counts the players sitting in a room of every region/mode pair,
+// including the pairs with a counter but no players anymore.
+func (s *MongoDBStorage) countTrends(ctx context.Context) (map[trendKey]int, error) {
+	roomModes := make(map[string]string)
+	roomCursor, err := s.roomCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer roomCursor.Close(ctx)
+	for roomCursor.Next(ctx) {
+		var room models.Room
+		if err := roomCursor.Decode(&room); err != nil {
+			return nil, err
+		}
+		roomModes[room.Id] = room.Mode
+	}
+	if err := roomCursor.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[trendKey]int)
+	playerCursor, err := s.playerCollection.Find(ctx, bson.M{"room": bson.M{"$ne": ""}})
+	if err != nil {
+		return nil, err
+	}
+	defer playerCursor.Close(ctx)
+	for playerCursor.Next(ctx) {
+		var player models.Player
+		if err := playerCursor.Decode(&player); err != nil {
+			return nil, err
+		}
+		mode, ok := roomModes[player.Room]
+		if !ok {
+			continue
+		}
+		counts[trendKey{region: player.Region, mode: mode}]++
+	}
+	if err := playerCursor.Err(); err != nil {
+		return nil, err
+	}
+
+	// Counters that no longer have any players are reset rather than removed.
+	counterCursor, err := s.trendCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer counterCursor.Close(ctx)
+	for counterCursor.Next(ctx) {
+		var counter TrendCounter
+		if err := counterCursor.Decode(&counter); err != nil {
+			return nil, err
+		}
+		key := trendKey{region: counter.Region, mode: counter.Mode}
+		if _, ok := counts[key]; !ok {
+			counts[key] = 0
+		}
+	}
+	if err := counterCursor.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// dedupeTrendCounters drops the extra counters of a region/mode pair left by
+// upserts made before the pair had a unique index, so that the index can be
+// built. The counts are rebuilt from the players and rooms on startup anyway.
+func (s *MongoDBStorage) dedupeTrendCounters(ctx context.Context) error {
+	indexes, err := s.trendCollection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index.Name == trendIndexName && (index.Unique == nil || !*index.Unique) {
+			if _, err := s.trendCollection.Indexes().DropOne(ctx, trendIndexName); err != nil {
+				return err
+			}
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"region": "$region", "mode": "$mode"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+	cursor, err := s.trendCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var duplicates struct {
+			IDs bson.A `bson:"ids"`
+		}
+		if err := cursor.Decode(&duplicates); err != nil {
+			return err
+		}
+		if _, err := s.trendCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": duplicates.IDs[1:]}}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
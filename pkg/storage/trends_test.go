@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentFirstJoinsOfARegion(t *testing.T) {
+	s := newTestStorage(t, testDatabase(t))
+	ctx := context.Background()
+	createTestPlayers(t, s, map[string]string{"host": "EU", "first": "ASIA", "second": "ASIA"})
+	roomID := createTestRoom(t, s, "host", "EU", "mayhem")
+
+	// Neither join finds a counter for ASIA, so both try to create it.
+	start := make(chan struct{})
+	errs := make(chan error, 2)
+	var wg sync.WaitGroup
+	for _, playerID := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(playerID string) {
+			defer wg.Done()
+			<-start
+			if err := s.EnsureTrendCounter(ctx, "ASIA", "mayhem"); err != nil {
+				errs <- err
+				return
+			}
+			errs <- s.WithTransaction(ctx, func(ctx context.Context) error {
+				return s.AddPlayerToRoom(ctx, playerID, roomID)
+			})
+		}(playerID)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent first join: %v", err)
+		}
+	}
+
+	counters, err := s.GetTrendCounters([]string{"ASIA"})
+	if err != nil {
+		t.Fatalf("GetTrendCounters: %v", err)
+	}
+	if len(counters) != 1 || counters[0].Count != 2 {
+		t.Fatalf("ASIA counters = %+v, want a single one counting 2", counters)
+	}
+}
+
+func TestRebuildTrendCountersCorrectsDrift(t *testing.T) {
+	s := newTestStorage(t, testDatabase(t))
+	ctx := context.Background()
+	createTestPlayers(t, s, map[string]string{"host": "EU"})
+	createTestRoom(t, s, "host", "EU", "mayhem")
+
+	// A counter that drifted up, and one whose players are all gone.
+	if _, err := s.trendCollection.UpdateOne(ctx, bson.M{"region": "EU", "mode": "mayhem"}, bson.M{"$set": bson.M{"count": 7}}); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if _, err := s.trendCollection.InsertOne(ctx, TrendCounter{Region: "ASIA", Mode: "mayhem", Count: 3}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+
+	if err := s.RebuildTrendCounters(ctx); err != nil {
+		t.Fatalf("RebuildTrendCounters: %v", err)
+	}
+	counters, err := s.GetTrendCounters(nil)
+	if err != nil {
+		t.Fatalf("GetTrendCounters: %v", err)
+	}
+	if len(counters) != 1 || counters[0] != (TrendCounter{Region: "EU", Mode: "mayhem", Count: 1}) {
+		t.Fatalf("counters = %+v, want EU mayhem counting 1", counters)
+	}
+}
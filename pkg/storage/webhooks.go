@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// States of a webhook delivery. Dead deliveries ran out of attempts and stay
+// on the dead-letter list until they are redelivered or their webhook is
+// deleted.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Webhook subscribes a URL to some or all of the webhook events. The secret
+// signs the deliveries, so unlike API keys it is kept as given.
+type Webhook struct {
+	ID          string    `bson:"id"`
+	URL         string    `bson:"url"`
+	Events      []string  `bson:"events,omitempty"`
+	Secret      string    `bson:"secret"`
+	Description string    `bson:"description,omitempty"`
+	CreatedBy   string    `bson:"createdby"`
+	CreatedAt   time.Time `bson:"createdat"`
+}
+
+// OutboxEvent is a webhook event waiting to be handed to the webhooks that
+// subscribe to it. Body is the JSON every delivery of the event sends, and
+// Players the players it names, so that they can be taken out of it.
+type OutboxEvent struct {
+	ID         string    `bson:"id"`
+	Type       string    `bson:"type"`
+	Body       string    `bson:"body"`
+	Players    []string  `bson:"players"`
+	CreatedAt  time.Time `bson:"createdat"`
+	DispatchAt time.Time `bson:"dispatchat"`
+}
+
+// WebhookDelivery is one event on its way to one webhook.
+type WebhookDelivery struct {
+	ID            string    `bson:"id"`
+	WebhookID     string    `bson:"webhookid"`
+	EventID       string    `bson:"eventid"`
+	EventType     string    `bson:"eventtype"`
+	Body          string    `bson:"body"`
+	Players       []string  `bson:"players"`
+	State         string    `bson:"state"`
+	Attempts      int       `bson:"attempts"`
+	NextAttemptAt time.Time `bson:"nextattemptat,omitempty"`
+	LastAttemptAt time.Time `bson:"lastattemptat,omitempty"`
+	LastStatus    int       `bson:"laststatus,omitempty"`
+	LastError     string    `bson:"lasterror,omitempty"`
+	CreatedAt     time.Time `bson:"createdat"`
+	// Set once delivered; MongoDB drops the delivery then.
+	ExpiresAt time.Time `bson:"expiresat,omitempty"`
+}
+
+// WebhookStorage keeps the webhooks, the outbox of events the mutations
+// append to and the deliveries made from it.
+type WebhookStorage struct {
+	webhookCollection  *mongo.Collection
+	outboxCollection   *mongo.Collection
+	deliveryCollection *mongo.Collection
+}
+
+func NewWebhookStorage(webhooks *mongo.Collection, outbox *mongo.Collection, deliveries *mongo.Collection) *WebhookStorage {
+	return &WebhookStorage{
+		webhookCollection:  webhooks,
+		outboxCollection:   outbox,
+		deliveryCollection: deliveries,
+	}
+}
+
+// EnsureIndexes creates the indexes the webhook queries rely on.
+func (s *WebhookStorage) EnsureIndexes(ctx context.Context) error {
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		s.webhookCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		s.outboxCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "dispatchat", Value: 1}}},
+			{Keys: bson.D{{Key: "players", Value: 1}}},
+		},
+		s.deliveryCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// An event fanned out again after a crash doesn't deliver twice.
+			{Keys: bson.D{{Key: "eventid", Value: 1}, {Key: "webhookid", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "state", Value: 1}, {Key: "nextattemptat", Value: 1}}},
+			{Keys: bson.D{{Key: "webhookid", Value: 1}, {Key: "state", Value: 1}, {Key: "createdat", Value: -1}}},
+			{Keys: bson.D{{Key: "expiresat", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+			{Keys: bson.D{{Key: "players", Value: 1}}},
+		},
+	}
+
+	for collection, collectionIndexes := range indexes {
+		if _, err := collection.Indexes().CreateMany(ctx, collectionIndexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WebhookStorage) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	_, err := s.webhookCollection.InsertOne(ctx, webhook)
+	return err
+}
+
+func (s *WebhookStorage) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	var webhook Webhook
+	err := s.webhookCollection.FindOne(ctx, bson.M{"id": id}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, errormanagement.WebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks returns every webhook, oldest first.
+func (s *WebhookStorage) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	return s.findWebhooks(ctx, bson.M{})
+}
+
+// WebhooksFor returns the webhooks subscribing to an event type. Webhooks
+// without an event filter subscribe to every type.
+func (s *WebhookStorage) WebhooksFor(ctx context.Context, eventType string) ([]Webhook, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"events": eventType},
+		bson.M{"events": bson.M{"$exists": false}},
+	}}
+	return s.findWebhooks(ctx, filter)
+}
+
+// DeleteWebhook removes a webhook along with its deliveries, dead or not.
+func (s *WebhookStorage) DeleteWebhook(ctx context.Context, id string) error {
+	result, err := s.webhookCollection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errormanagement.WebhookNotFound
+	}
+	_, err = s.deliveryCollection.DeleteMany(ctx, bson.M{"webhookid": id})
+	return err
+}
+
+// AppendOutbox stores events for the dispatcher to pick up.
+func (s *WebhookStorage) AppendOutbox(ctx context.Context, outboxEvents []OutboxEvent) error {
+	if len(outboxEvents) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, 0, len(outboxEvents))
+	for _, event := range outboxEvents {
+		documents = append(documents, event)
+	}
+	_, err := s.outboxCollection.InsertMany(ctx, documents)
+	return err
+}
+
+// ClaimOutboxEvent takes the oldest event due for dispatch and holds it back
+// from the other replicas for the lease, or returns nil when none is due. An
+// event that is not removed in time is dispatched again.
+func (s *WebhookStorage) ClaimOutboxEvent(ctx context.Context, lease time.Duration) (*OutboxEvent, error) {
+	now := time.Now().UTC()
+	filter := bson.M{"dispatchat": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"dispatchat": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "dispatchat", Value: 1}})
+
+	var event OutboxEvent
+	err := s.outboxCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// FanOut queues a delivery of the event for each webhook and then takes the
+// event out of the outbox. Deliveries queued by an earlier attempt are kept.
+func (s *WebhookStorage) FanOut(ctx context.Context, event *OutboxEvent, deliveries []WebhookDelivery) error {
+	if len(deliveries) > 0 {
+		documents := make([]interface{}, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			documents = append(documents, delivery)
+		}
+		_, err := s.deliveryCollection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+		if err != nil && !onlyDuplicateKeys(err) {
+			return err
+		}
+	}
+	_, err := s.outboxCollection.DeleteOne(ctx, bson.M{"id": event.ID})
+	return err
+}
+
+// ClaimDelivery takes the pending delivery that has been due the longest and
+// holds it back from the other replicas for the lease, or returns nil when
+// none is due. A delivery whose attempt is not recorded in time is retried.
+func (s *WebhookStorage) ClaimDelivery(ctx context.Context, lease time.Duration) (*WebhookDelivery, error) {
+	now := time.Now().UTC()
+	filter := bson.M{"state": DeliveryPending, "nextattemptat": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"nextattemptat": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextattemptat", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery WebhookDelivery
+	err := s.deliveryCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RecordAttempt stores the outcome of an attempt at a delivery. A pending
+// delivery is tried again at NextAttemptAt, a delivered one expires after
+// the retention.
+func (s *WebhookStorage) RecordAttempt(ctx context.Context, delivery *WebhookDelivery, retention time.Duration) error {
+	set := bson.M{
+		"state":         delivery.State,
+		"attempts":      delivery.Attempts,
+		"lastattemptat": delivery.LastAttemptAt.UTC(),
+		"laststatus":    delivery.LastStatus,
+		"lasterror":     delivery.LastError,
+	}
+	unset := bson.M{}
+	switch delivery.State {
+	case DeliveryPending:
+		set["nextattemptat"] = delivery.NextAttemptAt.UTC()
+	case DeliveryDelivered:
+		set["expiresat"] = delivery.LastAttemptAt.Add(retention).UTC()
+		unset["nextattemptat"] = ""
+	default:
+		unset["nextattemptat"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	_, err := s.deliveryCollection.UpdateOne(ctx, bson.M{"id": delivery.ID}, update)
+	return err
+}
+
+// ListDeliveries returns up to limit of the latest deliveries to a webhook,
+// newest first, optionally in a single state.
+func (s *WebhookStorage) ListDeliveries(ctx context.Context, webhookID string, state string, limit int) ([]WebhookDelivery, error) {
+	filter := bson.M{"webhookid": webhookID}
+	if state != "" {
+		filter["state"] = state
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.deliveryCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []WebhookDelivery{}
+	for cursor.Next(ctx) {
+		var delivery WebhookDelivery
+		if err := cursor.Decode(&delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, cursor.Err()
+}
+
+// Redeliver puts a delivery of a webhook back in the queue with a fresh set
+// of attempts.
+func (s *WebhookStorage) Redeliver(ctx context.Context, webhookID string, id string) (*WebhookDelivery, error) {
+	filter := bson.M{"id": id, "webhookid": webhookID}
+	update := bson.M{
+		"$set":   bson.M{"state": DeliveryPending, "attempts": 0, "nextattemptat": time.Now().UTC()},
+		"$unset": bson.M{"expiresat": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var delivery WebhookDelivery
+	err := s.deliveryCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, errormanagement.WebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RewriteBodiesOf replaces a player with a pseudonym in the events of the
+// outbox and in the deliveries naming them, dead letters included. rewrite
+// makes the same change to the body.
+func (s *WebhookStorage) RewriteBodiesOf(ctx context.Context, playerID string, pseudonym string, rewrite func(body string) (string, error)) error {
+	for _, collection := range []*mongo.Collection{s.outboxCollection, s.deliveryCollection} {
+		cursor, err := collection.Find(ctx, bson.M{"players": playerID})
+		if err != nil {
+			return err
+		}
+		var documents []webhookBody
+		if err := cursor.All(ctx, &documents); err != nil {
+			return err
+		}
+		for _, document := range documents {
+			body, err := rewrite(document.Body)
+			if err != nil {
+				return err
+			}
+			players := make([]string, len(document.Players))
+			for i, player := range document.Players {
+				players[i] = pseudonymOf(player, playerID, pseudonym)
+			}
+			update := bson.M{"$set": bson.M{"body": body, "players": players}}
+			if _, err := collection.UpdateOne(ctx, bson.M{"id": document.ID}, update); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IndexBodyPlayers records the players named by the events of the outbox and
+// the deliveries stored before they kept track of them.
+func (s *WebhookStorage) IndexBodyPlayers(ctx context.Context, playersOf func(body string) ([]string, error)) error {
+	for _, collection := range []*mongo.Collection{s.outboxCollection, s.deliveryCollection} {
+		cursor, err := collection.Find(ctx, bson.M{"players": bson.M{"$exists": false}})
+		if err != nil {
+			return err
+		}
+		var documents []webhookBody
+		if err := cursor.All(ctx, &documents); err != nil {
+			return err
+		}
+		for _, document := range documents {
+			players, err := playersOf(document.Body)
+			if err != nil {
+				return err
+			}
+			if players == nil {
+				players = []string{}
+			}
+			if _, err := collection.UpdateOne(ctx, bson.M{"id": document.ID}, bson.M{"$set": bson.M{"players": players}}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// webhookBody is what outbox events and deliveries have in common.
+type webhookBody struct {
+	ID      string   `bson:"id"`
+	Body    string   `bson:"body"`
+	Players []string `bson:"players"`
+}
+
+// Helper function to run a query for webhooks, oldest first.
+func (s *WebhookStorage) findWebhooks(ctx context.Context, filter bson.M) ([]Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}})
+	cursor, err := s.webhookCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []Webhook{}
+	for cursor.Next(ctx) {
+		var webhook Webhook
+		if err := cursor.Decode(&webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, cursor.Err()
+}
+
+// Helper function to tell whether every write of a failed bulk insert was
+// turned down for a duplicate key.
+func onlyDuplicateKeys(err error) bool {
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+package webhooks
+
+import (
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the secret of the webhook, prefixed with
+// "sha256=".
+const (
+	EventHeader     = "X-Deathfire-Event"
+	DeliveryHeader  = "X-Deathfire-Delivery"
+	TimestampHeader = "X-Deathfire-Timestamp"
+	SignatureHeader = "X-Deathfire-Signature"
+)
+
+// How long a claimed event or delivery is held back from the other replicas.
+// It has to outlast an attempt, which the client cuts off after its timeout.
+const (
+	claimLease      = time.Minute
+	deliveryTimeout = 10 * time.Second
+)
+
+// RetryPolicy spaces the attempts at a delivery. The delay doubles from
+// BaseDelay after every failed attempt, up to MaxDelay; a delivery that
+// failed MaxAttempts times becomes a dead letter.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 8, BaseDelay: 10 * time.Second, MaxDelay: time.Hour}
+
+// Backoff returns the delay before the next attempt, once attempts have failed.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Dispatcher hands the events of the outbox out to the webhooks subscribing
+// to them and delivers them. Every replica can run one; the events and
+// deliveries are claimed one at a time.
+type Dispatcher struct {
+	store        *storage.WebhookStorage
+	client       *http.Client
+	policy       RetryPolicy
+	workers      int
+	pollInterval time.Duration
+	// How long delivered deliveries are kept for inspection.
+	retention time.Duration
+}
+
+func NewDispatcher(store *storage.WebhookStorage, policy RetryPolicy, workers int, pollInterval time.Duration, retention time.Duration) *Dispatcher {
+	return &Dispatcher{
+		store:        store,
+		client:       &http.Client{Timeout: deliveryTimeout},
+		policy:       policy,
+		workers:      workers,
+		pollInterval: pollInterval,
+		retention:    retention,
+	}
+}
+
+// Run dispatches and delivers until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1 + d.workers)
+	go func() {
+		defer wg.Done()
+		d.poll(ctx, d.fanOutNext)
+	}()
+	for i := 0; i < d.workers; i++ {
+		go func() {
+			defer wg.Done()
+			d.poll(ctx, d.deliverNext)
+		}()
+	}
+	wg.Wait()
+}
+
+// Helper function to run a step for as long as it finds work, and then once
+// every poll interval.
+func (d *Dispatcher) poll(ctx context.Context, step func(ctx context.Context) (bool, error)) {
+	for {
+		worked, err := step(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("Webhook dispatch failed:", err)
+		}
+		if worked && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// fanOutNext queues a delivery of the next event of the outbox for each
+// webhook subscribing to it.
+func (d *Dispatcher) fanOutNext(ctx context.Context) (bool, error) {
+	event, err := d.store.ClaimOutboxEvent(ctx, claimLease)
+	if err != nil || event == nil {
+		return false, err
+	}
+	webhooks, err := d.store.WebhooksFor(ctx, event.Type)
+	if err != nil {
+		return true, err
+	}
+
+	now := time.Now().UTC()
+	deliveries := make([]storage.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		id, err := newID(12)
+		if err != nil {
+			return true, err
+		}
+		deliveries = append(deliveries, storage.WebhookDelivery{
+			ID:            id,
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Body:          event.Body,
+			Players:       event.Players,
+			State:         storage.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return true, d.store.FanOut(ctx, event, deliveries)
+}
+
+// deliverNext makes an attempt at the next due delivery and schedules the
+// following one if it fails.
+func (d *Dispatcher) deliverNext(ctx context.Context) (bool, error) {
+	delivery, err := d.store.ClaimDelivery(ctx, claimLease)
+	if err != nil || delivery == nil {
+		return false, err
+	}
+	webhook, err := d.store.GetWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, errormanagement.WebhookNotFound) {
+		// Deleted since; its deliveries are on their way out too.
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = time.Now().UTC()
+	delivery.LastStatus, err = d.send(ctx, webhook, delivery)
+	delivery.LastError = ""
+	switch {
+	case err == nil:
+		delivery.State = storage.DeliveryDelivered
+	case delivery.Attempts >= d.policy.MaxAttempts:
+		delivery.State = storage.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.State = storage.DeliveryPending
+		delivery.NextAttemptAt = delivery.LastAttemptAt.Add(d.policy.Backoff(delivery.Attempts))
+		delivery.LastError = err.Error()
+	}
+	if ctx.Err() != nil {
+		// Shutting down mid-attempt; the lease runs out and another attempt is made.
+		return true, nil
+	}
+	return true, d.store.RecordAttempt(ctx, delivery, d.retention)
+}
+
+// send posts a delivery to its webhook and returns the status it answered
+// with. Anything but a 2xx status is a failure.
+func (d *Dispatcher) send(ctx context.Context, webhook *storage.Webhook, delivery *storage.WebhookDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "DeathfireArsenal-Webhooks")
+	request.Header.Set(EventHeader, delivery.EventType)
+	request.Header.Set(DeliveryHeader, delivery.ID)
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, []byte(delivery.Body)))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	// Drain a little of the body so that the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("webhook answered %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// Sign returns the signature header of a delivery body sent at timestamp.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
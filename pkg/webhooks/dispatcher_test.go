@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testSecret = "0123456789abcdef"
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	// HMAC-SHA256 of "1700000000.{"id":"1"}" keyed with the test secret.
+	want := "sha256=d5f5834972cbc6cf5590800c46ccaa0cd6c16f19c0c73dbdf9b4c56390cbc2a3"
+	if got := Sign(testSecret, "1700000000", body); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+
+	for name, got := range map[string]string{
+		"secret":    Sign("fedcba9876543210", "1700000000", body),
+		"timestamp": Sign(testSecret, "1700000001", body),
+		"body":      Sign(testSecret, "1700000000", []byte(`{"id":"2"}`)),
+	} {
+		if got == want {
+			t.Fatalf("the signature doesn't depend on the %s", name)
+		}
+	}
+}
+
+func TestSendSignsTheDelivery(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	dispatcher := &Dispatcher{client: server.Client()}
+	webhook := &storage.Webhook{URL: server.URL, Secret: testSecret}
+	delivery := &storage.WebhookDelivery{ID: "d1", EventType: RoomCreated, Body: `{"id":"1"}`}
+	status, err := dispatcher.send(context.Background(), webhook, delivery)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("send = %d, %v, want 200", status, err)
+	}
+
+	if string(body) != delivery.Body {
+		t.Fatalf("body = %s, want %s", body, delivery.Body)
+	}
+	if received.Header.Get(EventHeader) != RoomCreated || received.Header.Get(DeliveryHeader) != "d1" {
+		t.Fatalf("event headers = %v", received.Header)
+	}
+	timestamp := received.Header.Get(TimestampHeader)
+	if got, want := received.Header.Get(SignatureHeader), Sign(testSecret, timestamp, body); got != want {
+		t.Fatalf("%s = %s, want %s", SignatureHeader, got, want)
+	}
+}
@@ -0,0 +1,243 @@
+package webhooks
+
+import (
+	"DeathfireArsenal/internal/constants"
+	"DeathfireArsenal/internal/errormanagement"
+	"DeathfireArsenal/pkg/events"
+	"DeathfireArsenal/pkg/storage"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Types of the events webhooks receive.
+const (
+	RoomCreated  = "room_created"
+	RoomFilled   = "room_filled"
+	RoomEmptied  = "room_emptied"
+	RoomDeleted  = "room_deleted"
+	PlayerJoined = "player_joined"
+	PlayerLeft   = "player_left"
+)
+
+var AllEvents = []string{RoomCreated, RoomFilled, RoomEmptied, RoomDeleted, PlayerJoined, PlayerLeft}
+
+// MinSecretLength is the shortest secret a webhook can be given.
+const MinSecretLength = 16
+
+const secretPrefix = "whsec_"
+
+// Event is the JSON body of a delivery. The room fields reflect the room
+// right after the change, like those of the room events.
+type Event struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	RoomID      string    `json:"room_id"`
+	Mode        string    `json:"mode"`
+	PlayerID    string    `json:"player_id,omitempty"`
+	Host        string    `json:"host,omitempty"`
+	State       string    `json:"state,omitempty"`
+	PlayerCount int       `json:"player_count"`
+	Capacity    int       `json:"capacity"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Registry manages the webhooks and records the events due to them in the
+// outbox, from which the Dispatcher delivers them.
+type Registry struct {
+	store *storage.WebhookStorage
+}
+
+func NewRegistry(store *storage.WebhookStorage) *Registry {
+	return &Registry{store: store}
+}
+
+// Create stores a webhook for a URL. Without event types it receives every
+// event; without a secret one is generated. The secret is returned only here.
+func (r *Registry) Create(ctx context.Context, webhook *storage.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", errormanagement.InvalidParameter)
+	}
+	for _, eventType := range webhook.Events {
+		if !validEvent(eventType) {
+			return fmt.Errorf("%w: unknown event %q", errormanagement.InvalidParameter, eventType)
+		}
+	}
+	if webhook.Secret == "" {
+		if webhook.Secret, err = newID(24); err != nil {
+			return err
+		}
+		webhook.Secret = secretPrefix + webhook.Secret
+	} else if len(webhook.Secret) < MinSecretLength {
+		return fmt.Errorf("%w: secret must be at least %d characters long", errormanagement.InvalidParameter, MinSecretLength)
+	}
+
+	if webhook.ID, err = newID(8); err != nil {
+		return err
+	}
+	webhook.CreatedAt = time.Now().UTC()
+	return r.store.CreateWebhook(ctx, webhook)
+}
+
+func (r *Registry) Get(ctx context.Context, id string) (*storage.Webhook, error) {
+	return r.store.GetWebhook(ctx, id)
+}
+
+func (r *Registry) List(ctx context.Context) ([]storage.Webhook, error) {
+	return r.store.ListWebhooks(ctx)
+}
+
+// Delete removes a webhook. Deliveries still on their way are dropped.
+func (r *Registry) Delete(ctx context.Context, id string) error {
+	return r.store.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns up to limit of the latest deliveries to a webhook,
+// newest first. The dead state lists the dead letters.
+func (r *Registry) Deliveries(ctx context.Context, id string, state string, limit int) ([]storage.WebhookDelivery, error) {
+	switch state {
+	case "", storage.DeliveryPending, storage.DeliveryDelivered, storage.DeliveryDead:
+	default:
+		return nil, fmt.Errorf("%w: state must be one of pending, delivered or dead", errormanagement.InvalidParameter)
+	}
+	if _, err := r.store.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	return r.store.ListDeliveries(ctx, id, state, limit)
+}
+
+// Redeliver queues a delivery, usually a dead letter, for a fresh set of
+// attempts.
+func (r *Registry) Redeliver(ctx context.Context, id string, deliveryID string) (*storage.WebhookDelivery, error) {
+	return r.store.Redeliver(ctx, id, deliveryID)
+}
+
+// Enqueue records the webhook events room events stand for in the outbox. It
+// is called in the transaction of the change the room events describe, so
+// that the webhook events are stored if and only if the change is.
+func (r *Registry) Enqueue(ctx context.Context, roomEvents ...events.Event) error {
+	var outbox []storage.OutboxEvent
+	for _, event := range roomEvents {
+		for _, eventType := range eventTypesOf(event) {
+			id, err := newID(12)
+			if err != nil {
+				return err
+			}
+			body, err := json.Marshal(Event{
+				ID:          id,
+				Type:        eventType,
+				RoomID:      event.RoomID,
+				Mode:        event.Mode,
+				PlayerID:    event.PlayerID,
+				Host:        event.Host,
+				State:       event.State,
+				PlayerCount: event.PlayerCount,
+				Capacity:    event.Capacity,
+				Timestamp:   event.Timestamp,
+			})
+			if err != nil {
+				return err
+			}
+			outbox = append(outbox, storage.OutboxEvent{
+				ID:         id,
+				Type:       eventType,
+				Body:       string(body),
+				Players:    playersOf(event.PlayerID, event.Host),
+				CreatedAt:  event.Timestamp.UTC(),
+				DispatchAt: event.Timestamp.UTC(),
+			})
+		}
+	}
+	return r.store.AppendOutbox(ctx, outbox)
+}
+
+// PseudonymizePlayer replaces a deleted player with a pseudonym in the events
+// waiting in the outbox and in the deliveries, dead letters included. It is
+// called in the transaction deleting the player.
+func (r *Registry) PseudonymizePlayer(ctx context.Context, playerID string, pseudonym string) error {
+	return r.store.RewriteBodiesOf(ctx, playerID, pseudonym, func(body string) (string, error) {
+		var event Event
+		if err := json.Unmarshal([]byte(body), &event); err != nil {
+			return "", err
+		}
+		if event.PlayerID == playerID {
+			event.PlayerID = pseudonym
+		}
+		if event.Host == playerID {
+			event.Host = pseudonym
+		}
+		rewritten, err := json.Marshal(event)
+		return string(rewritten), err
+	})
+}
+
+// IndexPlayers records the players named by the webhook events stored before
+// they kept track of them, so that deleting a player reaches those too.
+func (r *Registry) IndexPlayers(ctx context.Context) error {
+	return r.store.IndexBodyPlayers(ctx, func(body string) ([]string, error) {
+		var event Event
+		if err := json.Unmarshal([]byte(body), &event); err != nil {
+			return nil, err
+		}
+		return playersOf(event.PlayerID, event.Host), nil
+	})
+}
+
+// Helper function to list the players an event names, without repeats.
+func playersOf(playerID string, host string) []string {
+	var players []string
+	if playerID != "" {
+		players = append(players, playerID)
+	}
+	if host != "" && host != playerID {
+		players = append(players, host)
+	}
+	return players
+}
+
+// Helper function to list the webhook events a room event stands for. The
+// last player leaving a room deletes it, and host changes aren't sent.
+func eventTypesOf(event events.Event) []string {
+	switch event.Type {
+	case events.RoomCreated:
+		return []string{RoomCreated}
+	case events.PlayerJoined:
+		return []string{PlayerJoined}
+	case events.PlayerLeft:
+		return []string{PlayerLeft}
+	case events.StateChanged:
+		if event.State == constants.RoomFull {
+			return []string{RoomFilled}
+		}
+	case events.RoomDeleted:
+		if event.PlayerID != "" {
+			return []string{PlayerLeft, RoomEmptied, RoomDeleted}
+		}
+		return []string{RoomDeleted}
+	}
+	return nil
+}
+
+// Helper function to check if the event type is valid.
+func validEvent(eventType string) bool {
+	for _, known := range AllEvents {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function to generate a random hex ID of the given number of bytes.
+func newID(size int) (string, error) {
+	id := make([]byte, size)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}